@@ -4,12 +4,14 @@ import (
 	"fmt"
 	"log"
 	"net/http"
+	"os"
 
 	"github.com/neozhixuan/project-visualgo-backend/data-ingest/grpcServer"
 	"github.com/neozhixuan/project-visualgo-backend/data-ingest/websocketClient"
 	"github.com/neozhixuan/project-visualgo-backend/data-ingest/websocketServer"
 
 	"github.com/neozhixuan/project-visualgo-backend/pb"
+	"github.com/neozhixuan/project-visualgo-backend/streamHub"
 )
 
 func healthCheckHandler(w http.ResponseWriter, r *http.Request) {
@@ -33,19 +35,22 @@ func main() {
 	// - Buffered: allow non-blocking sends up to a certain capacity // if you expect that there might be a delay in receiving the message
 	// -- Cons of buffered: high memory usage
 
-	// Initialise an empty channel to send messages to WSS clients
-	var broadcast = make(chan []byte)
+	// Initialise a channel to send market events to WSS clients
+	// - buffered, as BinanceConnection drops events rather than wait for the hub
+	var broadcast = make(chan *pb.MarketEvent, 256)
 
 	// Initialise our tradeDataChan to send data to our gRPC clients
 	tradeDataChan := make(chan *pb.KlineData)
 
-	// Write a message from the `broadcast` channel to each client
+	// Write a message from the `broadcast` channel to each client subscribed to it
 	// - NOTE: Golang will process the code above before processing this goroutine
-	go websocketServer.HandleMessages(broadcast)
+	// - WS_ALLOWED_ORIGINS, WS_JWT_SECRET and the other WS_ settings decide who may connect, see streamHub.AccessFromEnv
+	hub := websocketServer.NewHub(streamHub.AccessFromEnv())
+	go websocketServer.Run(hub, broadcast)
 
 	//////////////////////////////////////////////////////////////////////////
 	// Start a HTTP server on port 8080
-	// - Use a WSS Upgrader (handleConnections(r, w)) to upgrade the requests and response from HTTP to WSS
+	// - Use a WSS Upgrader (hub.ServeHTTP(w, r)) to upgrade the requests and response from HTTP to WSS
 	// -- e.g. upgrade HTTP requests, handle WSS data
 	//////////////////////////////////////////////////////////////////////////
 
@@ -63,7 +68,10 @@ func main() {
 	//    	log.Fatalf("Failed to serve: %v", err)
 	//    }
 
-	go http.HandleFunc("/ws", websocketServer.HandleConnections)
+	http.Handle("/ws", hub)
+
+	// Stream the same events as Server-Sent Events, for clients that cannot use WebSockets
+	http.HandleFunc("/sse", hub.ServeSSE)
 	//////////////////////////////////////////////////////////////////////////
 
 	//////////////////////////////////////////////////////////////////////////
@@ -77,7 +85,8 @@ func main() {
 	// - WebSocket upgrade happens via a standard HTTP header negotiation (e.g., Upgrade: websocket)
 	// - The use of upgrade mechanic is common even in Node.js socketIO, Python Django Channels
 
-	go websocketClient.BinanceConnection(broadcast, tradeDataChan)
+	// - WS_RAW_CHANNEL=true also passes Binance's messages on unchanged, on "raw:" channels, for debugging
+	go websocketClient.BinanceConnection(broadcast, tradeDataChan, os.Getenv("WS_RAW_CHANNEL") == "true")
 	//////////////////////////////////////////////////////////////////////////
 
 	//////////////////////////////////////////////////////////////////////////
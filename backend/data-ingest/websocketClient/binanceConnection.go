@@ -1,17 +1,17 @@
 package websocketClient
 
 import (
-	"encoding/json"
 	"log"
 	"net/url"
 
 	"github.com/neozhixuan/project-visualgo-backend/pb"
 
 	"github.com/gorilla/websocket"
-	"github.com/neozhixuan/project-visualgo-backend/data-ingest/utils"
 )
 
-func BinanceConnection(broadcast chan []byte, tradeDataChan chan *pb.KlineData) {
+// BinanceConnection streams Binance's trades and klines to the WebSocket clients through `broadcast`, and klines to the gRPC clients through `tradeDataChan`
+// - with `raw` set, every message is also passed on unchanged on its "raw:" channel
+func BinanceConnection(broadcast chan *pb.MarketEvent, tradeDataChan chan *pb.KlineData, raw bool) {
 	binanceURL := url.URL{
 		Scheme: "wss",
 		Host:   "stream.binance.com:9443",
@@ -37,45 +37,29 @@ func BinanceConnection(broadcast chan []byte, tradeDataChan chan *pb.KlineData)
 				return
 			}
 
-			// Make sure that broadcast doesn't block indefinitely
-			select {
-			case broadcast <- message:
-				// Successfully sent to broadcast
-				log.Println("Sent a message to WSS client")
-			default:
-				// Handle when no one is reading from broadcast (could log or handle differently)
-				// log.Println("Warning: broadcast channel is full, dropping message")
+			// Convert the message into our own schema, so clients do not depend on Binance's field names
+			event, err := parseEvent(message)
+			if err != nil {
+				log.Printf("Error parsing Binance message: %v", err)
+				continue
 			}
-
-			// Unmarshal our raw message into the specified structure
-			// - unmarshal = parse the JSON message into our interface
-			var msgMap map[string]interface{}
-			if err := json.Unmarshal(message, &msgMap); err != nil {
-				log.Printf("Error unmarshaling into map: %v", err)
+			if event == nil {
 				continue
 			}
+			send(broadcast, event)
 
-			// If this is a kline, send data to the gRPC client.
-			if eventType, ok := msgMap["e"].(string); ok && eventType == "kline" {
-				kData, ok := msgMap["k"].(map[string]interface{})
-				if !ok {
-					log.Println("Error extracting kline data from message")
-					continue
-				}
-
-				klineData := &pb.KlineData{
-					Symbol:        msgMap["s"].(string),
-					OpenTime:      int64(kData["t"].(float64)),
-					CloseTime:     int64(kData["T"].(float64)),
-					OpenPrice:     utils.ParsePrice(kData["o"]),
-					ClosePrice:    utils.ParsePrice(kData["c"]),
-					HighPrice:     utils.ParsePrice(kData["h"]),
-					LowPrice:      utils.ParsePrice(kData["l"]),
-					Volume:        utils.ParsePrice(kData["v"]),
-					NumTrades:     int32(kData["n"].(float64)),
-					IsKlineClosed: kData["x"].(bool),
+			// Pass the message on as it was received too, for debugging
+			if raw {
+				rawMessage, err := rawEvent(event, message)
+				if err != nil {
+					log.Printf("Error parsing Binance message: %v", err)
+				} else {
+					send(broadcast, rawMessage)
 				}
+			}
 
+			// If this is a kline, send data to the gRPC client.
+			if klineData := event.GetKline(); klineData != nil {
 				// Non-blocking write to tradeDataChan to avoid deadlock
 				//the program is in a deadlock situation where all goroutines are either waiting for something (like receiving or sending on a channel), but no progress can be made because they are waiting indefinitely.
 				// - No goroutines can proceed because they are waiting for each other.
@@ -110,3 +94,15 @@ func BinanceConnection(broadcast chan []byte, tradeDataChan chan *pb.KlineData)
 	// Wait for the goroutine to signal that it has finished
 	<-done
 }
+
+// Send an event to the WebSocket clients without blocking, as Binance's stream must keep being read
+func send(broadcast chan *pb.MarketEvent, event *pb.MarketEvent) {
+	select {
+	case broadcast <- event:
+		// Successfully sent to broadcast
+		log.Printf("Sent a %s message to WSS client", event.Type)
+	default:
+		// Handle when no one is reading from broadcast (could log or handle differently)
+		// log.Println("Warning: broadcast channel is full, dropping message")
+	}
+}
@@ -0,0 +1,114 @@
+package websocketClient
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/neozhixuan/project-visualgo-backend/data-ingest/utils"
+	"github.com/neozhixuan/project-visualgo-backend/pb"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+// A kline event of Binance's `<symbol>@kline_<interval>` stream
+// - JSON keys are matched case-insensitively when no key matches exactly, so keys differing only in case are all declared
+type binanceKline struct {
+	EventType string `json:"e"`
+	EventTime int64  `json:"E"`
+	Symbol    string `json:"s"`
+	Kline     struct {
+		OpenTime      int64  `json:"t"`
+		CloseTime     int64  `json:"T"`
+		Interval      string `json:"i"`
+		Open          string `json:"o"`
+		Close         string `json:"c"`
+		High          string `json:"h"`
+		Low           string `json:"l"`
+		LastTradeID   int64  `json:"L"`
+		Volume        string `json:"v"`
+		TakerVolume   string `json:"V"`
+		QuoteVolume   string `json:"q"`
+		TakerQuoteVol string `json:"Q"`
+		NumTrades     int32  `json:"n"`
+		IsClosed      bool   `json:"x"`
+	} `json:"k"`
+}
+
+// A trade event of Binance's `<symbol>@trade` stream
+type binanceTrade struct {
+	EventType    string `json:"e"`
+	EventTime    int64  `json:"E"`
+	Symbol       string `json:"s"`
+	TradeID      int64  `json:"t"`
+	Price        string `json:"p"`
+	Quantity     string `json:"q"`
+	TradeTime    int64  `json:"T"`
+	IsBuyerMaker bool   `json:"m"`
+	Ignore       bool   `json:"M"`
+}
+
+// Convert a Binance stream message into a market event, or nil for messages that are not market events, like replies to our subscriptions
+func parseEvent(message []byte) (*pb.MarketEvent, error) {
+	var header struct {
+		EventType string `json:"e"`
+		EventTime int64  `json:"E"`
+	}
+	if err := json.Unmarshal(message, &header); err != nil {
+		return nil, err
+	}
+
+	switch header.EventType {
+	case "kline":
+		var event binanceKline
+		if err := json.Unmarshal(message, &event); err != nil {
+			return nil, err
+		}
+		kline := &pb.KlineData{
+			Symbol:        event.Symbol,
+			OpenTime:      event.Kline.OpenTime,
+			CloseTime:     event.Kline.CloseTime,
+			OpenPrice:     utils.ParsePrice(event.Kline.Open),
+			ClosePrice:    utils.ParsePrice(event.Kline.Close),
+			HighPrice:     utils.ParsePrice(event.Kline.High),
+			LowPrice:      utils.ParsePrice(event.Kline.Low),
+			Volume:        utils.ParsePrice(event.Kline.Volume),
+			NumTrades:     event.Kline.NumTrades,
+			IsKlineClosed: event.Kline.IsClosed,
+			Interval:      event.Kline.Interval,
+		}
+		return &pb.MarketEvent{
+			Type:    "kline",
+			Channel: "kline:" + kline.Symbol + ":" + kline.Interval,
+			Data:    &pb.MarketEvent_Kline{Kline: kline},
+		}, nil
+
+	case "trade":
+		var event binanceTrade
+		if err := json.Unmarshal(message, &event); err != nil {
+			return nil, err
+		}
+		trade := &pb.TradeData{
+			Symbol:       event.Symbol,
+			TradeId:      event.TradeID,
+			Price:        utils.ParsePrice(event.Price),
+			Quantity:     utils.ParsePrice(event.Quantity),
+			Time:         event.TradeTime,
+			IsBuyerMaker: event.IsBuyerMaker,
+		}
+		return &pb.MarketEvent{
+			Type:    "trade",
+			Channel: "trade:" + trade.Symbol,
+			Data:    &pb.MarketEvent_Trade{Trade: trade},
+		}, nil
+	}
+	return nil, nil
+}
+
+// Wrap a Binance stream message as it was received, on the "raw:" counterpart of its event's channel
+func rawEvent(event *pb.MarketEvent, message []byte) (*pb.MarketEvent, error) {
+	raw := &structpb.Value{}
+	if err := protojson.Unmarshal(message, raw); err != nil {
+		return nil, fmt.Errorf("raw %s message: %w", event.Type, err)
+	}
+	return &pb.MarketEvent{Type: "raw", Channel: "raw:" + event.Channel, Data: &pb.MarketEvent_Raw{Raw: raw}}, nil
+}
@@ -0,0 +1,76 @@
+package websocketServer
+
+import (
+	"github.com/neozhixuan/project-visualgo-backend/pb"
+	"github.com/neozhixuan/project-visualgo-backend/streamHub"
+	"github.com/vmihailenco/msgpack/v5"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+// Helper function to encode a market event
+// - protobuf events are pb.MarketEvent, batched by the hub as pb.MarketEventBatch
+func encode(event *pb.MarketEvent, e streamHub.Encoding) ([]byte, error) {
+	switch e {
+	case streamHub.EncodingProtobuf:
+		return proto.Marshal(event)
+	case streamHub.EncodingMsgpack:
+		return msgpack.Marshal(msgpackValue(event.ProtoReflect()))
+	default:
+		return marshalOptions.Marshal(event)
+	}
+}
+
+// Helper function to convert a protobuf message to the map MessagePack encodes, keyed and filled like its JSON form
+// - unlike in JSON, 64 bit integers stay numbers
+func msgpackValue(m protoreflect.Message) interface{} {
+	if value, ok := m.Interface().(*structpb.Value); ok {
+		return value.AsInterface()
+	}
+	value := map[string]interface{}{}
+	fields := m.Descriptor().Fields()
+	for i := 0; i < fields.Len(); i++ {
+		field := fields.Get(i)
+		if field.ContainingOneof() != nil && !m.Has(field) {
+			continue
+		}
+		value[field.JSONName()] = msgpackField(field, m.Get(field))
+	}
+	return value
+}
+
+// Helper function to convert a field's value for MessagePack
+func msgpackField(field protoreflect.FieldDescriptor, value protoreflect.Value) interface{} {
+	switch {
+	case field.IsList():
+		list := value.List()
+		items := make([]interface{}, list.Len())
+		for i := range items {
+			items[i] = msgpackScalar(field, list.Get(i))
+		}
+		return items
+	case field.IsMap():
+		entries := map[string]interface{}{}
+		value.Map().Range(func(key protoreflect.MapKey, entry protoreflect.Value) bool {
+			entries[key.String()] = msgpackScalar(field.MapValue(), entry)
+			return true
+		})
+		return entries
+	}
+	return msgpackScalar(field, value)
+}
+
+// Helper function to convert a single value, not a list or map, for MessagePack
+func msgpackScalar(field protoreflect.FieldDescriptor, value protoreflect.Value) interface{} {
+	switch field.Kind() {
+	case protoreflect.MessageKind, protoreflect.GroupKind:
+		return msgpackValue(value.Message())
+	case protoreflect.EnumKind:
+		if enum := field.Enum().Values().ByNumber(value.Enum()); enum != nil {
+			return string(enum.Name())
+		}
+		return int32(value.Enum())
+	}
+	return value.Interface()
+}
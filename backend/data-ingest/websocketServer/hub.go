@@ -0,0 +1,39 @@
+package websocketServer
+
+import (
+	"github.com/neozhixuan/project-visualgo-backend/pb"
+	"github.com/neozhixuan/project-visualgo-backend/streamHub"
+)
+
+// NewHub creates a streamHub.Hub for market events, letting clients in as `access` allows
+// - clients start from the next event, as market events carry no state worth a snapshot
+func NewHub(access streamHub.Access) *streamHub.Hub {
+	return streamHub.NewHub(streamHub.Config{Access: access, Version: EnvelopeVersion})
+}
+
+// Run broadcasts every event of `broadcast` to the clients subscribed to its channel, until the channel is closed
+func Run(hub *streamHub.Hub, broadcast chan *pb.MarketEvent) {
+	for event := range broadcast {
+		event.Version = EnvelopeVersion
+		hub.Broadcast(streamHub.Message{
+			Type:     event.Type,
+			Channel:  event.Channel,
+			Conflate: conflated(event),
+			Encode: func(e streamHub.Encoding) ([]byte, error) {
+				return encode(event, e)
+			},
+		})
+	}
+}
+
+// Helper function to check whether only the latest of an event's channel matters, so rate limited clients may skip it
+// - trades and in-progress klines are conflated, while closed klines are always sent
+func conflated(event *pb.MarketEvent) bool {
+	switch data := event.Data.(type) {
+	case *pb.MarketEvent_Kline:
+		return !data.Kline.IsKlineClosed
+	case *pb.MarketEvent_Trade:
+		return true
+	}
+	return false
+}
@@ -130,3 +130,116 @@ var KlineService_ServiceDesc = grpc.ServiceDesc{
 	},
 	Metadata: "trade.proto",
 }
+
+// SignalServiceClient is the client API for SignalService service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+type SignalServiceClient interface {
+	StreamSignals(ctx context.Context, in *SignalRequest, opts ...grpc.CallOption) (SignalService_StreamSignalsClient, error)
+}
+
+type signalServiceClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewSignalServiceClient(cc grpc.ClientConnInterface) SignalServiceClient {
+	return &signalServiceClient{cc}
+}
+
+func (c *signalServiceClient) StreamSignals(ctx context.Context, in *SignalRequest, opts ...grpc.CallOption) (SignalService_StreamSignalsClient, error) {
+	stream, err := c.cc.NewStream(ctx, &SignalService_ServiceDesc.Streams[0], "/SignalService/StreamSignals", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &signalServiceStreamSignalsClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type SignalService_StreamSignalsClient interface {
+	Recv() (*Signal, error)
+	grpc.ClientStream
+}
+
+type signalServiceStreamSignalsClient struct {
+	grpc.ClientStream
+}
+
+func (x *signalServiceStreamSignalsClient) Recv() (*Signal, error) {
+	m := new(Signal)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// SignalServiceServer is the server API for SignalService service.
+// All implementations must embed UnimplementedSignalServiceServer
+// for forward compatibility
+type SignalServiceServer interface {
+	StreamSignals(*SignalRequest, SignalService_StreamSignalsServer) error
+	mustEmbedUnimplementedSignalServiceServer()
+}
+
+// UnimplementedSignalServiceServer must be embedded to have forward compatible implementations.
+type UnimplementedSignalServiceServer struct {
+}
+
+func (UnimplementedSignalServiceServer) StreamSignals(*SignalRequest, SignalService_StreamSignalsServer) error {
+	return status.Errorf(codes.Unimplemented, "method StreamSignals not implemented")
+}
+func (UnimplementedSignalServiceServer) mustEmbedUnimplementedSignalServiceServer() {}
+
+// UnsafeSignalServiceServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to SignalServiceServer will
+// result in compilation errors.
+type UnsafeSignalServiceServer interface {
+	mustEmbedUnimplementedSignalServiceServer()
+}
+
+func RegisterSignalServiceServer(s grpc.ServiceRegistrar, srv SignalServiceServer) {
+	s.RegisterService(&SignalService_ServiceDesc, srv)
+}
+
+func _SignalService_StreamSignals_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(SignalRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(SignalServiceServer).StreamSignals(m, &signalServiceStreamSignalsServer{stream})
+}
+
+type SignalService_StreamSignalsServer interface {
+	Send(*Signal) error
+	grpc.ServerStream
+}
+
+type signalServiceStreamSignalsServer struct {
+	grpc.ServerStream
+}
+
+func (x *signalServiceStreamSignalsServer) Send(m *Signal) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+// SignalService_ServiceDesc is the grpc.ServiceDesc for SignalService service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var SignalService_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "SignalService",
+	HandlerType: (*SignalServiceServer)(nil),
+	Methods:     []grpc.MethodDesc{},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "StreamSignals",
+			Handler:       _SignalService_StreamSignals_Handler,
+			ServerStreams: true,
+		},
+	},
+	Metadata: "trade.proto",
+}
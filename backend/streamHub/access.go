@@ -0,0 +1,252 @@
+package streamHub
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/gorilla/websocket"
+)
+
+const (
+	// Time a client has to send its "auth" request, when it did not connect with a token
+	authTimeout = 10 * time.Second
+
+	// Close code for clients without a valid token, from the range kept for applications
+	closeUnauthorized = 4001
+
+	// Longest close reason a close frame can carry
+	maxCloseReason = 123
+)
+
+// Access decides who may connect to a WebSocket server and how much they may send, and who may use the HTTP APIs served next to it
+type Access struct {
+	AllowedOrigins []string // origins browsers may connect from, e.g. "https://app.example.com", or any origin when empty
+	JWTSecret      []byte   // HMAC secret of the JWTs clients must authenticate with, or no authentication when empty
+	MaxConnsPerIP  int      // connections one IP address may hold at once, or no limit when 0
+	MessageRate    float64  // requests a client may send per second on average, or no limit when 0
+	MessageBurst   int      // requests a client may send at once before MessageRate applies
+}
+
+// AccessFromEnv reads the access settings from the environment
+// - WS_ALLOWED_ORIGINS is a comma separated list of origins, and every origin is allowed without it
+// - WS_JWT_SECRET turns on authentication, with HS256, HS384 or HS512 tokens signed with it
+// - WS_MAX_CONNECTIONS_PER_IP caps the connections of one IP address (default 20, 0 for no cap)
+// - WS_MESSAGE_RATE and WS_MESSAGE_BURST limit the requests of a client (default 5 per second, in bursts of up to 20)
+// - WS_REQUIRE_AUTH=true makes WS_ALLOWED_ORIGINS and WS_JWT_SECRET required, so a deployment missing them fails to start rather than serving everyone
+func AccessFromEnv() Access {
+	access := Access{MaxConnsPerIP: 20, MessageRate: 5, MessageBurst: 20}
+
+	for _, origin := range strings.Split(os.Getenv("WS_ALLOWED_ORIGINS"), ",") {
+		if origin = strings.TrimSpace(origin); origin != "" {
+			access.AllowedOrigins = append(access.AllowedOrigins, origin)
+		}
+	}
+	access.JWTSecret = []byte(os.Getenv("WS_JWT_SECRET"))
+
+	if value := os.Getenv("WS_MAX_CONNECTIONS_PER_IP"); value != "" {
+		limit, err := strconv.Atoi(value)
+		if err != nil || limit < 0 {
+			log.Fatalf("Invalid WS_MAX_CONNECTIONS_PER_IP: %q", value)
+		}
+		access.MaxConnsPerIP = limit
+	}
+	if value := os.Getenv("WS_MESSAGE_RATE"); value != "" {
+		rate, err := strconv.ParseFloat(value, 64)
+		if err != nil || rate < 0 {
+			log.Fatalf("Invalid WS_MESSAGE_RATE: %q", value)
+		}
+		access.MessageRate = rate
+	}
+	if value := os.Getenv("WS_MESSAGE_BURST"); value != "" {
+		burst, err := strconv.Atoi(value)
+		if err != nil || burst < 1 {
+			log.Fatalf("Invalid WS_MESSAGE_BURST: %q", value)
+		}
+		access.MessageBurst = burst
+	}
+	if value := os.Getenv("WS_REQUIRE_AUTH"); value != "" {
+		required, err := strconv.ParseBool(value)
+		if err != nil {
+			log.Fatalf("Invalid WS_REQUIRE_AUTH: %q", value)
+		}
+		if required && (len(access.AllowedOrigins) == 0 || len(access.JWTSecret) == 0) {
+			log.Fatalf("Invalid access config: WS_ALLOWED_ORIGINS and WS_JWT_SECRET are required with WS_REQUIRE_AUTH")
+		}
+	}
+	return access
+}
+
+// Helper function to check whether a request comes from an allowed origin
+// - requests without an Origin header do not come from a browser, which is what origins protect, so they are allowed
+func (a Access) checkOrigin(r *http.Request) bool {
+	origin := r.Header.Get("Origin")
+	if len(a.AllowedOrigins) == 0 || origin == "" {
+		return true
+	}
+	for _, allowed := range a.AllowedOrigins {
+		if allowed == "*" || strings.EqualFold(allowed, origin) {
+			return true
+		}
+	}
+	return false
+}
+
+// Helper function to check a client's token, returning when it expires, or the zero time if it never does
+// - every token is accepted when authentication is off
+func (a Access) verify(token string) (time.Time, error) {
+	if len(a.JWTSecret) == 0 {
+		return time.Time{}, nil
+	}
+	if token == "" {
+		return time.Time{}, errors.New("missing token")
+	}
+	var claims jwt.RegisteredClaims
+	_, err := jwt.ParseWithClaims(token, &claims, func(*jwt.Token) (interface{}, error) {
+		return a.JWTSecret, nil
+	}, jwt.WithValidMethods([]string{"HS256", "HS384", "HS512"}))
+	if err != nil {
+		return time.Time{}, err
+	}
+	if claims.ExpiresAt == nil {
+		return time.Time{}, nil
+	}
+	return claims.ExpiresAt.Time, nil
+}
+
+// Helper function to authenticate a client that just connected, by its `token` query parameter, or else by its first message, which must be an "auth" request
+// - returns the "auth" request if the client sent one, to ack once the client is registered
+func (a Access) authenticate(conn *websocket.Conn, token string) (time.Time, *request, error) {
+	if len(a.JWTSecret) == 0 {
+		return time.Time{}, nil, nil
+	}
+	var req *request
+	if token == "" {
+		conn.SetReadLimit(maxMessageSize)
+		conn.SetReadDeadline(time.Now().Add(authTimeout))
+		_, data, err := conn.ReadMessage()
+		if err != nil {
+			return time.Time{}, nil, fmt.Errorf("no auth request within %s", authTimeout)
+		}
+		req = &request{}
+		if err := json.Unmarshal(data, req); err != nil || req.Op != "auth" {
+			return time.Time{}, nil, errors.New(`expected {"op": "auth", "token": "<JWT>"} first`)
+		}
+		token = req.Token
+	}
+	expires, err := a.verify(token)
+	return expires, req, err
+}
+
+// Helper function to check the origin and token of a plain HTTP request, such as for an SSE stream, answering it with an error and returning false if it may not go on
+// - allowed origins are echoed in Access-Control-Allow-Origin, so browsers let pages of other origins read the answer
+// - the token comes from the `token` query parameter, as EventSource cannot set headers, or else from an "Authorization: Bearer" header
+// - kind names what is rejected in logs, e.g. "SSE client"
+func (a Access) admit(w http.ResponseWriter, r *http.Request, kind string) (time.Time, bool) {
+	if !a.checkOrigin(r) {
+		log.Printf("Rejecting %s %s: origin %q not allowed", kind, r.RemoteAddr, r.Header.Get("Origin"))
+		http.Error(w, "origin not allowed", http.StatusForbidden)
+		return time.Time{}, false
+	}
+	if origin := r.Header.Get("Origin"); origin != "" {
+		w.Header().Set("Access-Control-Allow-Origin", origin)
+		w.Header().Add("Vary", "Origin")
+	}
+	token := r.URL.Query().Get("token")
+	if token == "" {
+		token, _ = strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	}
+	expires, err := a.verify(token)
+	if err != nil {
+		log.Printf("Rejecting %s %s: unauthorized: %v", kind, r.RemoteAddr, err)
+		http.Error(w, "unauthorized: "+err.Error(), http.StatusUnauthorized)
+		return time.Time{}, false
+	}
+	return expires, true
+}
+
+// Counts the connections of every IP address, refusing those beyond the cap
+type connectionCounter struct {
+	max int
+
+	mu     sync.Mutex
+	counts map[string]int
+}
+
+func newConnectionCounter(max int) *connectionCounter {
+	return &connectionCounter{max: max, counts: map[string]int{}}
+}
+
+// Helper function to count a new connection of an IP address, or return false if the address already has too many
+func (c *connectionCounter) acquire(ip string) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.max > 0 && c.counts[ip] >= c.max {
+		return false
+	}
+	c.counts[ip]++
+	return true
+}
+
+// Helper function to stop counting a connection of an IP address
+func (c *connectionCounter) release(ip string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.counts[ip]--; c.counts[ip] <= 0 {
+		delete(c.counts, ip)
+	}
+}
+
+// Token bucket limiting how fast a client may send, only used by the client's reader goroutine
+type messageLimiter struct {
+	rate   float64
+	burst  float64
+	tokens float64
+	last   time.Time
+}
+
+func newMessageLimiter(rate float64, burst int) *messageLimiter {
+	return &messageLimiter{rate: rate, burst: float64(burst), tokens: float64(burst), last: time.Now()}
+}
+
+// Helper function to take a token for a message, or return false if the client is sending too fast
+func (l *messageLimiter) allow(now time.Time) bool {
+	if l.rate == 0 {
+		return true
+	}
+	l.tokens = min(l.burst, l.tokens+now.Sub(l.last).Seconds()*l.rate)
+	l.last = now
+	if l.tokens < 1 {
+		return false
+	}
+	l.tokens--
+	return true
+}
+
+// Helper function to find the IP address a request comes from
+func remoteIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
+
+// Helper function to close a connection that was never registered, telling the client why
+func reject(conn *websocket.Conn, code int, reason string) {
+	if len(reason) > maxCloseReason {
+		reason = reason[:maxCloseReason]
+	}
+	log.Printf("Rejecting WebSocket client %s: %s", conn.RemoteAddr(), reason)
+	conn.WriteControl(websocket.CloseMessage, websocket.FormatCloseMessage(code, reason), time.Now().Add(writeWait))
+	conn.Close()
+}
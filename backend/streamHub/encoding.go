@@ -0,0 +1,87 @@
+package streamHub
+
+import (
+	"bytes"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/vmihailenco/msgpack/v5"
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+// Longest a client may ask for its messages to be batched
+const maxBatchInterval = time.Second
+
+// Encoding is how messages are written to a client, chosen with the `encoding` query parameter when connecting
+// - every service encodes its own messages in each encoding, see Message.Encode
+type Encoding int
+
+const (
+	EncodingJSON     Encoding = iota // text frames of the service's JSON envelope, the default
+	EncodingProtobuf                 // binary frames of the service's protobuf message, whose batches are a message with the batched messages in field 1
+	EncodingMsgpack                  // binary frames of MessagePack, holding the same keys and values as the JSON envelope
+	encodingCount
+)
+
+var encodingNames = [encodingCount]string{"json", "protobuf", "msgpack"}
+
+func (e Encoding) String() string {
+	return encodingNames[e]
+}
+
+// Helper function to parse the encoding a client asked for when connecting, or JSON if it asked for none
+func parseEncoding(value string) (Encoding, error) {
+	if value == "" {
+		return EncodingJSON, nil
+	}
+	for e, name := range encodingNames {
+		if value == name {
+			return Encoding(e), nil
+		}
+	}
+	return 0, fmt.Errorf("unknown encoding %q, expected json, protobuf or msgpack", value)
+}
+
+// Helper function to parse how many milliseconds a client asked for its messages to be batched when connecting, or 0 not to batch them
+func parseBatch(value string) (time.Duration, error) {
+	if value == "" {
+		return 0, nil
+	}
+	milliseconds, err := strconv.Atoi(value)
+	if err != nil || milliseconds < 0 || time.Duration(milliseconds)*time.Millisecond > maxBatchInterval {
+		return 0, fmt.Errorf("invalid batch %q, expected milliseconds from 0 to %d", value, maxBatchInterval.Milliseconds())
+	}
+	return time.Duration(milliseconds) * time.Millisecond, nil
+}
+
+// Helper function to get the WebSocket frame type of the encoding
+func (e Encoding) frameType() int {
+	if e == EncodingJSON {
+		return websocket.TextMessage
+	}
+	return websocket.BinaryMessage
+}
+
+// Helper function to join encoded messages into one batch frame
+// - JSON and MessagePack batches are arrays, protobuf batches are a message whose field 1 repeats the messages, like pb.MarketEventBatch and pb.StreamMessageBatch
+func (e Encoding) join(messages [][]byte) []byte {
+	var frame bytes.Buffer
+	switch e {
+	case EncodingProtobuf:
+		for _, message := range messages {
+			frame.Write(protowire.AppendBytes(protowire.AppendTag(nil, 1, protowire.BytesType), message))
+		}
+	case EncodingMsgpack:
+		msgpack.NewEncoder(&frame).EncodeArrayLen(len(messages))
+		for _, message := range messages {
+			frame.Write(message)
+		}
+	default:
+		frame.WriteByte('[')
+		frame.Write(bytes.Join(messages, []byte(",")))
+		frame.WriteByte(']')
+	}
+	return frame.Bytes()
+}
@@ -0,0 +1,12 @@
+module github.com/neozhixuan/project-visualgo-backend/streamHub
+
+go 1.22.1
+
+require (
+	github.com/golang-jwt/jwt/v5 v5.3.1
+	github.com/gorilla/websocket v1.5.3
+	github.com/vmihailenco/msgpack/v5 v5.4.1
+	google.golang.org/protobuf v1.34.1
+)
+
+require github.com/vmihailenco/tagparser/v2 v2.0.0 // indirect
@@ -0,0 +1,89 @@
+package streamHub
+
+import (
+	"log"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// How often the request limiters of IP addresses that have stopped sending are forgotten
+const limiterPruneInterval = time.Minute
+
+// Guard lets requests through to `next` as `access` allows, for HTTP APIs served next to the streams
+// - origins and tokens are checked like an SSE client's, with the token in an "Authorization: Bearer" header or the `token` query parameter
+// - preflight requests from allowed origins are answered, so browsers may send the Authorization header
+// - one IP address may have MaxConnsPerIP requests in flight, and send MessageRate requests per second in bursts of up to MessageBurst
+// - requests from origins that are not allowed get a 403, without a valid token a 401, and over either limit a 429
+func Guard(access Access, next http.Handler) http.Handler {
+	inFlight := newConnectionCounter(access.MaxConnsPerIP)
+	limiters := newRequestLimiters(access.MessageRate, access.MessageBurst)
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodOptions && r.Header.Get("Access-Control-Request-Method") != "" {
+			if !access.checkOrigin(r) {
+				http.Error(w, "origin not allowed", http.StatusForbidden)
+				return
+			}
+			w.Header().Set("Access-Control-Allow-Origin", r.Header.Get("Origin"))
+			w.Header().Set("Access-Control-Allow-Methods", "GET")
+			w.Header().Set("Access-Control-Allow-Headers", "Authorization")
+			w.Header().Add("Vary", "Origin")
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+		if _, ok := access.admit(w, r, "HTTP request"); !ok {
+			return
+		}
+		ip := remoteIP(r)
+		if !limiters.allow(ip, time.Now()) {
+			log.Printf("Rejecting HTTP request %s: too many requests", r.RemoteAddr)
+			http.Error(w, "too many requests", http.StatusTooManyRequests)
+			return
+		}
+		if !inFlight.acquire(ip) {
+			log.Printf("Rejecting HTTP request %s: too many requests in flight", r.RemoteAddr)
+			http.Error(w, "too many requests in flight", http.StatusTooManyRequests)
+			return
+		}
+		defer inFlight.release(ip)
+		next.ServeHTTP(w, r)
+	})
+}
+
+// Token buckets limiting how fast every IP address may send requests
+type requestLimiters struct {
+	rate  float64
+	burst int
+
+	mu       sync.Mutex
+	limiters map[string]*messageLimiter
+	pruned   time.Time
+}
+
+func newRequestLimiters(rate float64, burst int) *requestLimiters {
+	return &requestLimiters{rate: rate, burst: burst, limiters: map[string]*messageLimiter{}, pruned: time.Now()}
+}
+
+// Helper function to take a token for a request of an IP address, or return false if the address is sending too fast
+// - addresses whose bucket has filled up again are forgotten every limiterPruneInterval, as a new bucket would be the same
+func (l *requestLimiters) allow(ip string, now time.Time) bool {
+	if l.rate == 0 {
+		return true
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if now.Sub(l.pruned) >= limiterPruneInterval {
+		for address, limiter := range l.limiters {
+			if now.Sub(limiter.last).Seconds()*l.rate >= float64(l.burst) {
+				delete(l.limiters, address)
+			}
+		}
+		l.pruned = now
+	}
+	limiter, ok := l.limiters[ip]
+	if !ok {
+		limiter = newMessageLimiter(l.rate, l.burst)
+		l.limiters[ip] = limiter
+	}
+	return limiter.allow(now)
+}
@@ -0,0 +1,540 @@
+package streamHub
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+const (
+	// Time allowed to write a message to a client, after which it is evicted
+	writeWait = 10 * time.Second
+
+	// Time allowed without hearing from a client, pongs included, after which it is evicted as idle
+	pongWait = 60 * time.Second
+
+	// How often clients are pinged, which must be less than pongWait
+	pingPeriod = pongWait * 9 / 10
+
+	// Largest message a client may send
+	maxMessageSize = 4096
+
+	// Messages queued per client, beyond which the client is too slow and evicted
+	sendQueueSize = 256
+
+	// Shards the clients are spread over, each broadcasting to its clients on its own goroutine
+	shardCount = 16
+
+	// Broadcasts queued per shard, beyond which Broadcast waits for the shard to catch up
+	shardQueueSize = 64
+)
+
+// Message is a message a service publishes, which the hub encodes the first time a client needs it in each encoding
+type Message struct {
+	Type     string // what the message is, for logs
+	Channel  string // channel the message is published on, empty for snapshots
+	Conflate bool   // only the channel's latest state matters, so rate limited clients may skip it for a later one
+
+	// Encode writes the message in an encoding, where the JSON form must be a single line
+	Encode func(e Encoding) ([]byte, error)
+}
+
+// Config decides how a Hub lets clients in, and what they get when they do
+type Config struct {
+	Access  Access
+	Version int // envelope version, sent as "v" in the "ack" and "error" replies
+
+	// Snapshot takes the message a client first gets of the channels it wants, on connect and on subscribe, or nil for none
+	Snapshot func(wanted func(channel string) bool) Message
+}
+
+// Hub fans every message a service publishes out to the WebSocket and SSE clients subscribed to its channel
+// - clients are spread over shards, each with its own lock and goroutine, so thousands of clients are broadcast to in parallel
+// - each client has its own queue and writer goroutine, so a slow client never holds up the others
+// - a client whose queue fills up is evicted, rather than blocking or silently missing messages
+// - clients are pinged every pingPeriod, and evicted when they stop answering
+// - every message is encoded at most once per encoding, and frames sent on their own are compressed at most once, for every client that negotiated permessage-deflate
+// - who may connect, and how much they may send, is decided by its Access
+// - the same messages are streamed as Server-Sent Events to clients that cannot use WebSockets
+type Hub struct {
+	upgrader    websocket.Upgrader
+	config      Config
+	connections *connectionCounter
+	sse         *sseStreams
+	shards      [shardCount]*shard
+	next        atomic.Uint64 // shard the next client joins, round robin
+	clients     atomic.Int64
+}
+
+// A group of clients, broadcast to by one goroutine
+type shard struct {
+	hub       *Hub
+	broadcast chan *outbound
+
+	mu      sync.Mutex
+	clients map[*client]bool
+}
+
+// A message to send, encoded the first time a client needs it in each encoding
+type outbound struct {
+	message Message
+	shared  bool // sent to many clients, so its frames are prepared once for all of them
+	once    [encodingCount]sync.Once
+	frames  [encodingCount]*frame
+}
+
+// A message queued for a client
+type frame struct {
+	data     []byte
+	prepared *websocket.PreparedMessage // data prepared once for every client sent it on its own, nil for messages to one client
+	control  bool                       // an "ack" or "error" reply, sent as JSON text right away whatever the client's encoding
+	channel  string                     // channel the message is published on, empty for messages to one client
+	conflate bool                       // only the channel's latest state matters, so rate limited clients may skip it for a later one
+	id       uint64                     // id of the SSE event, unused for WebSocket messages
+}
+
+// A message queued for a client, with the least time between the client's conflated messages of its channel, or 0 for no limit
+type delivery struct {
+	frame    *frame
+	interval time.Duration
+}
+
+// A connected client
+type client struct {
+	shard    *shard
+	conn     *websocket.Conn
+	send     chan delivery
+	encoding Encoding
+	batch    time.Duration // how long messages are gathered into one frame, or 0 to send each on its own
+	batched  [][]byte      // messages gathered for the next frame, only touched by the writer goroutine
+	limiter  *messageLimiter
+
+	// Channels the client is subscribed to, guarded by its shard's lock
+	subscriptions subscriptions
+
+	// Closes the connection when the client's token expires, guarded by its shard's lock
+	expiry *time.Timer
+
+	// Closed once the client is removed, telling its writer to close the connection
+	// - reason is the close message the writer sends, if any
+	done   chan struct{}
+	reason []byte
+	once   sync.Once
+}
+
+// The "ack" and "error" replies to a client's request
+type replyMessage struct {
+	Version int    `json:"v"`
+	Type    string `json:"type"`
+	Data    Reply  `json:"data"`
+}
+
+// NewHub creates a Hub with no clients, letting clients in as `config` allows
+func NewHub(config Config) *Hub {
+	h := &Hub{
+		config:      config,
+		connections: newConnectionCounter(config.Access.MaxConnsPerIP),
+		sse:         newSSEStreams(),
+		upgrader: websocket.Upgrader{
+			ReadBufferSize:    1024,
+			WriteBufferSize:   1024,
+			CheckOrigin:       config.Access.checkOrigin,
+			EnableCompression: true, // Negotiate permessage-deflate with clients that offer it
+		},
+	}
+	for i := range h.shards {
+		h.shards[i] = &shard{hub: h, broadcast: make(chan *outbound, shardQueueSize), clients: map[*client]bool{}}
+		go h.shards[i].run()
+	}
+	return h
+}
+
+// Broadcast queues a message for every client subscribed to its channel, evicting clients whose queue is full
+// - it must only be called from one goroutine, so every client gets messages in the order they are published
+// - every message is kept for SSE clients to resume from, even while none is connected
+func (h *Hub) Broadcast(message Message) {
+	o := &outbound{message: message, shared: true}
+	h.sse.publish(o.frame(EncodingJSON))
+	for _, s := range h.shards {
+		s.broadcast <- o
+	}
+}
+
+// Clients returns the number of connected WebSocket clients
+func (h *Hub) Clients() int {
+	return int(h.clients.Load())
+}
+
+// ServeHTTP upgrades a request to a WebSocket, and sends the client the snapshot, if there is one, followed by every message of its channels from then on
+// - the client starts subscribed to the comma separated `channels` query parameter, or to every channel without it
+// - messages are sent in the `encoding` query parameter, json, protobuf or msgpack, and gathered into one frame every `batch` milliseconds if it is set
+// - requests from origins that are not allowed get a 403, and clients over their IP address's cap or without a valid token are closed with a code saying why
+func (h *Hub) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	subscribed, err := parseSubscriptions(query.Get("channels"), query.Get("rate"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	encoding, err := parseEncoding(query.Get("encoding"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	batch, err := parseBatch(query.Get("batch"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	conn, err := h.upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		log.Printf("Error upgrading connection to WebSocket: %v", err)
+		return
+	}
+	ip := remoteIP(r)
+	if !h.connections.acquire(ip) {
+		reject(conn, websocket.CloseTryAgainLater, "too many connections")
+		return
+	}
+	defer h.connections.release(ip)
+	expires, auth, err := h.config.Access.authenticate(conn, query.Get("token"))
+	if err != nil {
+		reject(conn, closeUnauthorized, "unauthorized: "+err.Error())
+		return
+	}
+
+	// Queue the snapshot and register the client together under the shard's lock, so no broadcast the snapshot misses falls between them
+	// - the snapshot may already hold the next broadcast's state, which clients replace by openTime
+	s := h.shards[h.next.Add(1)%shardCount]
+	c := &client{shard: s, conn: conn, send: make(chan delivery, sendQueueSize), encoding: encoding, batch: batch, limiter: newMessageLimiter(h.config.Access.MessageRate, h.config.Access.MessageBurst), subscriptions: subscribed, done: make(chan struct{})}
+	s.mu.Lock()
+	s.clients[c] = true
+	s.expireAt(c, expires)
+	if auth != nil {
+		s.reply(c, *auth, nil)
+	}
+	s.queueSnapshot(c, subscribed.covers)
+	s.mu.Unlock()
+	log.Printf("WebSocket client %s connected, %d clients", conn.RemoteAddr(), h.clients.Add(1))
+
+	go c.writePump()
+	c.readPump()
+}
+
+// ServeSSE streams the messages ServeHTTP sends as Server-Sent Events, for clients behind proxies that break WebSockets
+// - the client is subscribed to the comma separated `channels` query parameter, or to every channel without it, with the `rate` limit if it is set
+// - every event's data is a message in JSON, and its id is what a reconnecting client sends back as Last-Event-ID, or as the `lastEventId` query parameter
+// - a client resuming from one of the last replaySize events gets every event of its channels it missed, and any other client first gets the snapshot, if there is one
+// - requests from origins that are not allowed get a 403, without a valid token a 401, and over their IP address's cap a 429
+func (h *Hub) ServeSSE(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	subscribed, err := parseSubscriptions(query.Get("channels"), query.Get("rate"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	expires, ok := h.config.Access.admit(w, r, "SSE client")
+	if !ok {
+		return
+	}
+	ip := remoteIP(r)
+	if !h.connections.acquire(ip) {
+		log.Printf("Rejecting SSE client %s: too many connections", r.RemoteAddr)
+		http.Error(w, "too many connections", http.StatusTooManyRequests)
+		return
+	}
+	defer h.connections.release(ip)
+	lastEventID := r.Header.Get("Last-Event-ID")
+	if lastEventID == "" {
+		lastEventID = query.Get("lastEventId")
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("X-Accel-Buffering", "no") // Stop nginx from buffering the stream
+	w.WriteHeader(http.StatusOK)
+
+	// Take the missed events or the snapshot and register the client together, so no broadcast falls between them
+	c := &sseClient{addr: r.RemoteAddr, send: make(chan delivery, sendQueueSize), subscriptions: subscribed, done: make(chan struct{})}
+	backlog := h.sse.register(c, lastEventID, h.sseSnapshot)
+	log.Printf("SSE client %s connected, %d SSE clients", c.addr, h.sse.count())
+
+	c.stream(w, r, h.sse.epoch, backlog, expires)
+	log.Printf("SSE client %s disconnected, %d SSE clients", c.addr, h.sse.unregister(c))
+}
+
+// Helper function to take a snapshot of the channels `wanted` accepts for an SSE client, or nil if the hub takes none
+func (h *Hub) sseSnapshot(wanted func(channel string) bool) *frame {
+	if h.config.Snapshot == nil {
+		return nil
+	}
+	snapshot := &outbound{message: h.config.Snapshot(wanted)}
+	return snapshot.frame(EncodingJSON)
+}
+
+// Queues every broadcast for the shard's clients subscribed to its channel
+func (s *shard) run() {
+	for broadcast := range s.broadcast {
+		s.mu.Lock()
+		for c := range s.clients {
+			covered, interval := c.subscriptions.match(broadcast.message.Channel)
+			if !covered {
+				continue
+			}
+			if f := broadcast.frame(c.encoding); f != nil {
+				s.queue(c, f, interval)
+			}
+		}
+		s.mu.Unlock()
+	}
+}
+
+// Helper function to encode the message the first time a client needs it in an encoding, or nil if it cannot be
+// - shards share the message, so it is encoded once however many clients need it
+func (o *outbound) frame(e Encoding) *frame {
+	o.once[e].Do(func() {
+		data, err := o.message.Encode(e)
+		if err != nil {
+			log.Printf("Error encoding %s message as %s: %v", o.message.Type, e, err)
+			return
+		}
+		f := &frame{data: data, channel: o.message.Channel, conflate: o.message.Conflate}
+		if o.shared {
+			if f.prepared, err = websocket.NewPreparedMessage(e.frameType(), data); err != nil {
+				log.Printf("Error preparing %s message as %s: %v", o.message.Type, e, err)
+				return
+			}
+		}
+		o.frames[e] = f
+	})
+	return o.frames[e]
+}
+
+// Helper function to queue a snapshot of the channels `wanted` accepts, if the hub takes snapshots, with the shard locked
+func (s *shard) queueSnapshot(c *client, wanted func(channel string) bool) {
+	if s.hub.config.Snapshot == nil {
+		return
+	}
+	snapshot := &outbound{message: s.hub.config.Snapshot(wanted)}
+	if f := snapshot.frame(c.encoding); f != nil {
+		s.queue(c, f, 0)
+	}
+}
+
+// Helper function to queue a message for a client, evicting it if its queue is full, with the shard locked
+func (s *shard) queue(c *client, f *frame, interval time.Duration) {
+	if !s.clients[c] {
+		return
+	}
+	select {
+	case c.send <- delivery{frame: f, interval: interval}:
+	default:
+		log.Printf("Evicting slow WebSocket client %s: %d messages queued", c.conn.RemoteAddr(), len(c.send))
+		s.remove(c, websocket.FormatCloseMessage(websocket.ClosePolicyViolation, "slow consumer"))
+	}
+}
+
+// Helper function to answer a client's request with an "ack" or an "error", with the shard locked
+// - subscribing is acked, then followed by a snapshot of the channels subscribed to, if the hub takes snapshots, which may repeat what the client already has
+// - an "auth" request replaces the client's token, e.g. with a fresh one before the current one expires
+func (s *shard) handle(c *client, data []byte) {
+	var req request
+	var added []string
+	err := json.Unmarshal(data, &req)
+	switch {
+	case err != nil:
+		err = fmt.Errorf("invalid request: %w", err)
+	case req.Op == "auth":
+		var expires time.Time
+		if expires, err = s.hub.config.Access.verify(req.Token); err != nil {
+			err = fmt.Errorf("unauthorized: %w", err)
+		} else {
+			s.expireAt(c, expires)
+		}
+	default:
+		added, err = c.subscriptions.apply(req)
+	}
+	s.reply(c, req, err)
+	if len(added) > 0 {
+		subscribed := subscriptions{}
+		for _, channel := range added {
+			subscribed[channel] = 0
+		}
+		s.queueSnapshot(c, subscribed.covers)
+	}
+}
+
+// Helper function to queue the "ack" of a request, or the "error" it failed with, always in JSON, with the shard locked
+func (s *shard) reply(c *client, req request, err error) {
+	reply := replyMessage{Version: s.hub.config.Version, Type: "ack", Data: Reply{ID: req.ID, Op: req.Op, Channels: c.subscriptions.list(), Rates: c.subscriptions.rates()}}
+	if err != nil {
+		reply.Type, reply.Data.Error = "error", err.Error()
+	}
+	jsonBytes, err := json.Marshal(reply)
+	if err != nil {
+		log.Printf("Error marshaling %s reply to JSON: %v", reply.Type, err)
+		return
+	}
+	s.queue(c, &frame{data: jsonBytes, control: true}, 0)
+}
+
+// Helper function to close a client's connection once its token expires, if it ever does, with the shard locked
+func (s *shard) expireAt(c *client, expires time.Time) {
+	if c.expiry != nil {
+		c.expiry.Stop()
+		c.expiry = nil
+	}
+	if !expires.IsZero() {
+		c.expiry = time.AfterFunc(time.Until(expires), func() {
+			s.unregister(c, websocket.FormatCloseMessage(closeUnauthorized, "token expired"))
+		})
+	}
+}
+
+// Helper function to remove a client and tell its writer to close the connection, with the shard locked
+func (s *shard) remove(c *client, reason []byte) {
+	if !s.clients[c] {
+		return
+	}
+	delete(s.clients, c)
+	if c.expiry != nil {
+		c.expiry.Stop()
+	}
+	s.hub.clients.Add(-1)
+	c.once.Do(func() {
+		c.reason = reason
+		close(c.done)
+	})
+}
+
+// Helper function to remove a client from outside its shard
+func (s *shard) unregister(c *client, reason []byte) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.remove(c, reason)
+}
+
+// Reads requests from the client until it goes away or idles, keeping the connection alive on every pong
+// - reading is also what processes pings, pongs and close frames
+// - a client sending faster than its Access allows is evicted
+func (c *client) readPump() {
+	defer c.shard.unregister(c, nil)
+
+	c.conn.SetReadLimit(maxMessageSize)
+	c.conn.SetReadDeadline(time.Now().Add(pongWait))
+	c.conn.SetPongHandler(func(string) error {
+		return c.conn.SetReadDeadline(time.Now().Add(pongWait))
+	})
+	for {
+		_, data, err := c.conn.ReadMessage()
+		if err != nil {
+			if websocket.IsUnexpectedCloseError(err, websocket.CloseGoingAway, websocket.CloseNormalClosure, websocket.CloseNoStatusReceived) {
+				log.Printf("Error reading from WebSocket client %s: %v", c.conn.RemoteAddr(), err)
+			}
+			return
+		}
+		if !c.limiter.allow(time.Now()) {
+			c.shard.unregister(c, websocket.FormatCloseMessage(websocket.ClosePolicyViolation, "too many messages"))
+			return
+		}
+		c.conn.SetReadDeadline(time.Now().Add(pongWait))
+		c.shard.mu.Lock()
+		c.shard.handle(c, data)
+		c.shard.mu.Unlock()
+	}
+}
+
+// Writes queued messages and pings to the client, the only goroutine that writes to the connection
+// - when the client batches, messages are gathered and written as one frame every batch interval, or sooner once sendQueueSize of them are waiting
+// - conflated messages of rate limited channels are held back until their channel may send again
+func (c *client) writePump() {
+	ticker := time.NewTicker(pingPeriod)
+	var flush <-chan time.Time
+	if c.batch > 0 {
+		batchTicker := time.NewTicker(c.batch)
+		defer batchTicker.Stop()
+		flush = batchTicker.C
+	}
+	defer func() {
+		ticker.Stop()
+		c.conn.Close()
+		log.Printf("WebSocket client %s disconnected, %d clients", c.conn.RemoteAddr(), c.shard.hub.Clients())
+	}()
+
+	throttle := newThrottle()
+	var wake <-chan time.Time
+	var wakeAt time.Time
+	for {
+		var err error
+		select {
+		case d := <-c.send:
+			if f := throttle.admit(d.frame, d.interval, time.Now()); f != nil {
+				err = c.write(f)
+			}
+		case <-wake:
+			wake = nil
+			for _, f := range throttle.due(time.Now()) {
+				if err = c.write(f); err != nil {
+					break
+				}
+			}
+		case <-flush:
+			err = c.flush()
+		case <-ticker.C:
+			c.conn.SetWriteDeadline(time.Now().Add(writeWait))
+			if err := c.conn.WriteMessage(websocket.PingMessage, nil); err != nil {
+				c.shard.unregister(c, nil)
+				return
+			}
+		case <-c.done:
+			if c.reason != nil {
+				c.conn.WriteControl(websocket.CloseMessage, c.reason, time.Now().Add(writeWait))
+			}
+			return
+		}
+		if err != nil {
+			log.Printf("Error sending message to WebSocket client %s: %v", c.conn.RemoteAddr(), err)
+			c.shard.unregister(c, nil)
+			return
+		}
+
+		// Wake up for the next held back message, unless already waking up before it
+		if next, held := throttle.next(); held && (wake == nil || next.Before(wakeAt)) {
+			wake, wakeAt = time.After(time.Until(next)), next
+		}
+	}
+}
+
+// Helper function to write a message, or gather it for the next frame when the client batches, from the writer goroutine
+func (c *client) write(f *frame) error {
+	c.conn.SetWriteDeadline(time.Now().Add(writeWait))
+	switch {
+	case f.control:
+		return c.conn.WriteMessage(websocket.TextMessage, f.data)
+	case c.batch > 0:
+		if c.batched = append(c.batched, f.data); len(c.batched) >= sendQueueSize {
+			return c.flush()
+		}
+		return nil
+	case f.prepared != nil:
+		return c.conn.WritePreparedMessage(f.prepared)
+	}
+	return c.conn.WriteMessage(c.encoding.frameType(), f.data)
+}
+
+// Helper function to write the gathered messages as one frame, from the writer goroutine
+func (c *client) flush() error {
+	if len(c.batched) == 0 {
+		return nil
+	}
+	c.conn.SetWriteDeadline(time.Now().Add(writeWait))
+	err := c.conn.WriteMessage(c.encoding.frameType(), c.encoding.join(c.batched))
+	c.batched = nil
+	return err
+}
@@ -0,0 +1,222 @@
+package streamHub
+
+import (
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// Latest events kept for reconnecting SSE clients to resume from
+	replaySize = 1024
+
+	// How often a comment is sent on an SSE stream, so proxies do not close it while it is idle
+	sseKeepAlive = 15 * time.Second
+)
+
+// Streams every published event to the SSE clients subscribed to its channel, keeping the latest ones for clients to resume from
+// - event ids are the stream's epoch followed by a sequence number, e.g. "lx3kq9a2-42", so ids from before a restart are never mistaken for current ones
+// - a client whose queue fills up is evicted, like a WebSocket client
+type sseStreams struct {
+	epoch string
+
+	mu      sync.Mutex
+	clients map[*sseClient]bool
+	lastID  uint64   // sequence number of the latest event
+	replay  []*frame // the latest events, up to replaySize of them, oldest first
+}
+
+// A connected SSE client
+type sseClient struct {
+	addr          string
+	send          chan delivery
+	subscriptions subscriptions // channels the client is subscribed to, fixed once it connects
+
+	// Closed once the client is removed, telling its writer to end the stream
+	done chan struct{}
+	once sync.Once
+}
+
+func newSSEStreams() *sseStreams {
+	return &sseStreams{epoch: strconv.FormatInt(time.Now().UnixNano(), 36), clients: map[*sseClient]bool{}}
+}
+
+// Helper function to number a message as the next event, keep it for resuming and queue it for every SSE client subscribed to its channel
+// - nil messages, which could not be encoded, are skipped
+func (s *sseStreams) publish(f *frame) {
+	if f == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.lastID++
+	event := &frame{data: f.data, channel: f.channel, conflate: f.conflate, id: s.lastID}
+	if len(s.replay) == replaySize {
+		s.replay = s.replay[1:]
+	}
+	s.replay = append(s.replay, event)
+
+	for c := range s.clients {
+		covered, interval := c.subscriptions.match(event.channel)
+		if !covered {
+			continue
+		}
+		select {
+		case c.send <- delivery{frame: event, interval: interval}:
+		default:
+			log.Printf("Evicting slow SSE client %s: %d messages queued", c.addr, len(c.send))
+			s.remove(c)
+		}
+	}
+}
+
+// Helper function to register a client, returning what it gets before the events published from then on
+// - a client resuming from one of the kept events gets every event of its channels it missed
+// - any other client gets the message `snapshot` takes of its channels, if `snapshot` is set
+func (s *sseStreams) register(c *sseClient, lastEventID string, snapshot func(wanted func(channel string) bool) *frame) []delivery {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.clients[c] = true
+
+	if from, ok := s.resumable(lastEventID); ok {
+		var missed []delivery
+		for _, event := range s.replay {
+			if event.id <= from {
+				continue
+			}
+			if covered, interval := c.subscriptions.match(event.channel); covered {
+				missed = append(missed, delivery{frame: event, interval: interval})
+			}
+		}
+		return missed
+	}
+	if snapshot == nil {
+		return nil
+	}
+	f := snapshot(c.subscriptions.covers)
+	if f == nil {
+		return nil
+	}
+	f.id = s.lastID
+	return []delivery{{frame: f}}
+}
+
+// Helper function to parse the id of the last event a client got, returning its sequence number if every event after it is still kept
+func (s *sseStreams) resumable(lastEventID string) (uint64, bool) {
+	epoch, sequence, found := strings.Cut(lastEventID, "-")
+	if !found || epoch != s.epoch {
+		return 0, false
+	}
+	id, err := strconv.ParseUint(sequence, 10, 64)
+	if err != nil || id > s.lastID {
+		return 0, false
+	}
+	if len(s.replay) > 0 && id+1 < s.replay[0].id {
+		return 0, false
+	}
+	return id, true
+}
+
+// Helper function to remove a client and tell its writer to end the stream, with the streams locked
+func (s *sseStreams) remove(c *sseClient) {
+	if !s.clients[c] {
+		return
+	}
+	delete(s.clients, c)
+	c.once.Do(func() {
+		close(c.done)
+	})
+}
+
+// Helper function to remove a client from outside the streams, returning how many clients are left
+func (s *sseStreams) unregister(c *sseClient) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.remove(c)
+	return len(s.clients)
+}
+
+// Helper function to count the connected SSE clients
+func (s *sseStreams) count() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.clients)
+}
+
+// Writes the backlog, then every queued event, to the client until it goes away, is evicted or its token expires
+// - conflated events of rate limited channels are held back until their channel may send again, like a WebSocket client's
+func (c *sseClient) stream(w http.ResponseWriter, r *http.Request, epoch string, backlog []delivery, expires time.Time) {
+	rc := http.NewResponseController(w)
+	keepAlive := time.NewTicker(sseKeepAlive)
+	defer keepAlive.Stop()
+	var expiry <-chan time.Time
+	if !expires.IsZero() {
+		timer := time.NewTimer(time.Until(expires))
+		defer timer.Stop()
+		expiry = timer.C
+	}
+
+	throttle := newThrottle()
+	var wake <-chan time.Time
+	var wakeAt time.Time
+	var err error
+	rc.SetWriteDeadline(time.Now().Add(writeWait))
+	for _, d := range backlog {
+		if f := throttle.admit(d.frame, d.interval, time.Now()); f != nil {
+			if err = writeEvent(w, epoch, f); err != nil {
+				break
+			}
+		}
+	}
+	for {
+		if err == nil {
+			err = rc.Flush()
+		}
+		if err != nil {
+			log.Printf("Error sending message to SSE client %s: %v", c.addr, err)
+			return
+		}
+
+		// Wake up for the next held back event, unless already waking up before it
+		if next, held := throttle.next(); held && (wake == nil || next.Before(wakeAt)) {
+			wake, wakeAt = time.After(time.Until(next)), next
+		}
+
+		select {
+		case d := <-c.send:
+			rc.SetWriteDeadline(time.Now().Add(writeWait))
+			if f := throttle.admit(d.frame, d.interval, time.Now()); f != nil {
+				err = writeEvent(w, epoch, f)
+			}
+		case <-wake:
+			wake = nil
+			rc.SetWriteDeadline(time.Now().Add(writeWait))
+			for _, f := range throttle.due(time.Now()) {
+				if err = writeEvent(w, epoch, f); err != nil {
+					break
+				}
+			}
+		case <-keepAlive.C:
+			rc.SetWriteDeadline(time.Now().Add(writeWait))
+			_, err = io.WriteString(w, ": keep-alive\n\n")
+		case <-expiry:
+			log.Printf("Closing SSE client %s: token expired", c.addr)
+			return
+		case <-c.done:
+			return
+		case <-r.Context().Done():
+			return
+		}
+	}
+}
+
+// Helper function to write a message as an event, whose data is the message's single line of JSON
+func writeEvent(w io.Writer, epoch string, f *frame) error {
+	_, err := fmt.Fprintf(w, "id: %s-%d\ndata: %s\n\n", epoch, f.id, f.data)
+	return err
+}
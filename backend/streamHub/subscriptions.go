@@ -0,0 +1,210 @@
+package streamHub
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Most channels a client may be subscribed to at once
+const maxSubscriptions = 64
+
+// Longest channel a client may subscribe to
+const maxChannelLength = 128
+
+// Rate limits a client may ask for, in messages per second per channel
+const (
+	minRate = 0.01
+	maxRate = 1000
+)
+
+// A control message sent by a client, e.g. {"op": "subscribe", "channels": ["kline:BNBBTC:1m"], "rate": 4, "id": 1}
+type request struct {
+	Op       string          `json:"op"`
+	Channels []string        `json:"channels"`
+	Rate     float64         `json:"rate,omitempty"`  // most conflated messages per second per channel of the subscriptions, or 0 for no limit
+	Token    string          `json:"token,omitempty"` // JWT of an "auth" request
+	ID       json.RawMessage `json:"id,omitempty"`
+}
+
+// Reply is the Data of the "ack" and "error" messages answering a client's request
+type Reply struct {
+	ID       json.RawMessage    `json:"id,omitempty"`    // the request's id, so clients can match replies to requests
+	Op       string             `json:"op,omitempty"`    // the request's op
+	Channels []string           `json:"channels"`        // every channel the client is subscribed to once the request is done
+	Rates    map[string]float64 `json:"rates,omitempty"` // rate limit of every rate limited subscription
+	Error    string             `json:"error,omitempty"`
+}
+
+// Channels a client is subscribed to, each of which may hold wildcards
+// - a channel is a list of segments split by ':', e.g. "kline:BNBBTC:1m"
+// - a "*" segment matches any segment, and trailing segments left out match anything, so "kline:BNBBTC" covers every interval of BNBBTC
+// - "*" on its own covers every channel
+// - each subscription has a rate limit, in messages per second per channel, or 0 for none
+type subscriptions map[string]float64
+
+// Helper function to parse the comma separated channels a client asked for when connecting, or every channel if it asked for none
+// - they are all limited to `rate` messages per second per channel, if it is set
+func parseSubscriptions(value, rate string) (subscriptions, error) {
+	limit := 0.0
+	if rate != "" {
+		var err error
+		if limit, err = strconv.ParseFloat(rate, 64); err != nil {
+			return nil, fmt.Errorf("invalid rate %q, expected messages per second", rate)
+		}
+		if err := validateRate(limit); err != nil {
+			return nil, err
+		}
+	}
+	if value == "" {
+		return subscriptions{"*": limit}, nil
+	}
+	channels := strings.Split(value, ",")
+	if err := validateChannels(channels, len(channels)); err != nil {
+		return nil, err
+	}
+	subscribed := subscriptions{}
+	for _, channel := range channels {
+		subscribed[channel] = limit
+	}
+	return subscribed, nil
+}
+
+// Helper function to check whether any of the subscriptions covers a channel
+func (s subscriptions) covers(channel string) bool {
+	covered, _ := s.match(channel)
+	return covered
+}
+
+// Helper function to check whether any of the subscriptions covers a channel, and find the least time between its conflated messages
+// - when several subscriptions cover the channel the least limited one wins, so the time is 0 if any of them has no limit
+func (s subscriptions) match(channel string) (bool, time.Duration) {
+	covered := false
+	var interval time.Duration
+	for pattern, rate := range s {
+		if !matches(pattern, channel) {
+			continue
+		}
+		if rate == 0 {
+			return true, 0
+		}
+		if limit := time.Duration(float64(time.Second) / rate); !covered || limit < interval {
+			interval = limit
+		}
+		covered = true
+	}
+	return covered, interval
+}
+
+// Helper function to list the subscriptions, sorted
+func (s subscriptions) list() []string {
+	channels := make([]string, 0, len(s))
+	for channel := range s {
+		channels = append(channels, channel)
+	}
+	sort.Strings(channels)
+	return channels
+}
+
+// Helper function to list the rate limits of the subscriptions that have one, or nil if none has
+func (s subscriptions) rates() map[string]float64 {
+	var rates map[string]float64
+	for channel, rate := range s {
+		if rate == 0 {
+			continue
+		}
+		if rates == nil {
+			rates = map[string]float64{}
+		}
+		rates[channel] = rate
+	}
+	return rates
+}
+
+// Helper function to apply a request, returning the channels it subscribed to
+// - nothing is changed if any of the request's channels is invalid
+// - subscribing to a channel again sets its rate limit to the request's
+func (s subscriptions) apply(req request) ([]string, error) {
+	if req.Op != "subscribe" && req.Op != "unsubscribe" {
+		return nil, fmt.Errorf("unknown op %q, expected subscribe, unsubscribe or auth", req.Op)
+	}
+	if len(req.Channels) == 0 {
+		return nil, fmt.Errorf("%s needs at least one channel", req.Op)
+	}
+	switch req.Op {
+	case "subscribe":
+		added := map[string]bool{}
+		for _, channel := range req.Channels {
+			if _, subscribed := s[channel]; !subscribed {
+				added[channel] = true
+			}
+		}
+		if err := validateRate(req.Rate); err != nil {
+			return nil, err
+		}
+		if err := validateChannels(req.Channels, len(s)+len(added)); err != nil {
+			return nil, err
+		}
+		for _, channel := range req.Channels {
+			s[channel] = req.Rate
+		}
+		return req.Channels, nil
+	default:
+		for _, channel := range req.Channels {
+			if _, subscribed := s[channel]; !subscribed {
+				return nil, fmt.Errorf("not subscribed to %q", channel)
+			}
+		}
+		for _, channel := range req.Channels {
+			delete(s, channel)
+		}
+		return nil, nil
+	}
+}
+
+// Helper function to check channels a client wants to subscribe to, and that it would not have too many subscriptions
+func validateChannels(channels []string, total int) error {
+	if total > maxSubscriptions {
+		return fmt.Errorf("too many subscriptions, at most %d are allowed", maxSubscriptions)
+	}
+	for _, channel := range channels {
+		if len(channel) > maxChannelLength {
+			return fmt.Errorf("channel %.20q... is longer than %d characters", channel, maxChannelLength)
+		}
+		for _, segment := range strings.Split(channel, ":") {
+			if segment == "" || strings.ContainsAny(segment, " \t\r\n,") {
+				return fmt.Errorf("invalid channel %q, expected segments split by ':', e.g. \"kline:BNBBTC:1m\"", channel)
+			}
+		}
+	}
+	return nil
+}
+
+// Helper function to check a rate limit a client asked for
+func validateRate(rate float64) error {
+	if rate != 0 && !(rate >= minRate && rate <= maxRate) {
+		return fmt.Errorf("invalid rate %g, expected %g to %g messages per second, or 0 for no limit", rate, minRate, float64(maxRate))
+	}
+	return nil
+}
+
+// Helper function to check whether a subscription covers a channel
+func matches(pattern, channel string) bool {
+	if pattern == "*" {
+		return true
+	}
+	patternSegments := strings.Split(pattern, ":")
+	channelSegments := strings.Split(channel, ":")
+	if len(patternSegments) > len(channelSegments) {
+		return false
+	}
+	for i, segment := range patternSegments {
+		if segment != "*" && segment != channelSegments[i] {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,79 @@
+package streamHub
+
+import "time"
+
+// Holds back a client's conflated messages on its rate limited channels, keeping only the latest of each channel
+// - a channel's conflated message is sent at once if the channel's interval has passed since it last sent anything, and held back otherwise
+// - a held back message is replaced by the channel's next conflated message, and dropped by its next other message, which is sent at once
+// - it is only used by the client's writer goroutine
+type throttle struct {
+	channels map[string]*throttled
+}
+
+// A rate limited channel of a client
+type throttled struct {
+	interval time.Duration
+	sent     time.Time // when a message of the channel was last sent
+	pending  *frame    // latest message held back, if any
+}
+
+func newThrottle() *throttle {
+	return &throttle{channels: map[string]*throttled{}}
+}
+
+// Helper function to decide whether a message is sent now, returning it if so, or nil if it is held back
+// - interval is the least time between conflated messages of the message's channel, or 0 if the channel has no limit
+func (t *throttle) admit(f *frame, interval time.Duration, now time.Time) *frame {
+	if interval == 0 {
+		delete(t.channels, f.channel)
+		return f
+	}
+	channel, ok := t.channels[f.channel]
+	if !ok {
+		channel = &throttled{}
+		t.channels[f.channel] = channel
+	}
+	channel.interval = interval
+	if f.conflate && (channel.pending != nil || now.Sub(channel.sent) < interval) {
+		channel.pending = f
+		return nil
+	}
+	channel.pending = nil
+	channel.sent = now
+	return f
+}
+
+// Helper function to take the held back messages whose channel may send again
+// - channels with nothing held back are forgotten once their interval has passed
+func (t *throttle) due(now time.Time) []*frame {
+	var frames []*frame
+	for name, channel := range t.channels {
+		if now.Sub(channel.sent) < channel.interval {
+			continue
+		}
+		if channel.pending == nil {
+			delete(t.channels, name)
+			continue
+		}
+		frames = append(frames, channel.pending)
+		channel.pending = nil
+		channel.sent = now
+	}
+	return frames
+}
+
+// Helper function to find when the next held back message is due, or false if none is held back
+func (t *throttle) next() (time.Time, bool) {
+	var next time.Time
+	held := false
+	for _, channel := range t.channels {
+		if channel.pending == nil {
+			continue
+		}
+		if at := channel.sent.Add(channel.interval); !held || at.Before(next) {
+			next = at
+		}
+		held = true
+	}
+	return next, held
+}
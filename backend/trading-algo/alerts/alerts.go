@@ -0,0 +1,282 @@
+package alerts
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/neozhixuan/project-visualgo-backend/trading-algo/financeFunctions"
+	"github.com/neozhixuan/project-visualgo-backend/trading-algo/strategy"
+)
+
+// Rule is a declarative alert, as written in the rules file
+// - e.g. {"name": "oversold", "symbol": "BNBBTC", "condition": "rsi(14) < 30", "cooldown": "1h", "channels": ["webhook"]}
+type Rule struct {
+	Name      string   `json:"name"`
+	Symbol    string   `json:"symbol,omitempty"`   // empty matches every symbol
+	Condition string   `json:"condition"`          // see ParseCondition
+	Cooldown  string   `json:"cooldown,omitempty"` // least time between two alerts of the rule on a symbol, e.g. "15m"
+	Channels  []string `json:"channels,omitempty"` // notifiers to deliver to, every configured one when empty
+	Message   string   `json:"message,omitempty"`  // replaces the generated message
+}
+
+// Alert is a rule firing on a symbol's closed candle
+type Alert struct {
+	ID        string  `json:"id"` // the same for every delivery of the alert, so receivers can drop duplicates
+	Rule      string  `json:"rule"`
+	Symbol    string  `json:"symbol"`
+	Condition string  `json:"condition"`
+	Message   string  `json:"message"`
+	Left      float64 `json:"left"`  // value of the condition's left side
+	Right     float64 `json:"right"` // value of the condition's right side
+	Price     float64 `json:"price"` // close of the candle that fired the alert
+	Time      int64   `json:"time"`  // close time of the candle that fired the alert, in Unix milliseconds
+}
+
+// LoadRules reads a JSON array of rules from a file
+func LoadRules(path string) ([]Rule, error) {
+	bytes, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var rules []Rule
+	if err := json.Unmarshal(bytes, &rules); err != nil {
+		return nil, fmt.Errorf("could not read rules from %s: %w", path, err)
+	}
+	return rules, nil
+}
+
+// A rule with its condition parsed
+type compiledRule struct {
+	Rule
+	condition Condition
+	cooldown  time.Duration
+	channels  []string
+}
+
+// State of a rule on a single symbol
+type ruleState struct {
+	active    bool  // whether the condition held on the last candle
+	lastCheck int64 // close time of the last candle checked
+	lastFired int64 // close time of the last candle that fired
+}
+
+// Engine evaluates every rule on every closed candle, and delivers the alerts that fire
+// - a comparison only fires when it starts to hold, not on every candle it keeps holding
+// - a rule does not fire again on a symbol within its cooldown, measured in candle time
+// - a candle that is checked twice (e.g. resent after a reconnect) cannot fire twice
+// - each notifier delivers from its own queue, so a slow mail server does not hold up the WebSocket
+type Engine struct {
+	rules     []*compiledRule
+	notifiers map[string]*worker
+
+	mu     sync.Mutex
+	states map[string]*ruleState // keyed by rule and symbol
+}
+
+// Queue of alerts waiting for a notifier
+type worker struct {
+	notifier Notifier
+	queue    chan Alert
+}
+
+// Size of every notifier's queue, beyond which alerts are dropped
+const queueSize = 100
+
+// Delivery attempts per alert and notifier, doubling the wait after each failure
+const (
+	maxAttempts  = 3
+	retryBackoff = time.Second
+)
+
+// NewEngine checks the rules and creates an Engine delivering to `notifiers`
+func NewEngine(rules []Rule, notifiers ...Notifier) (*Engine, error) {
+	engine := &Engine{notifiers: map[string]*worker{}, states: map[string]*ruleState{}}
+	for _, notifier := range notifiers {
+		engine.notifiers[notifier.Channel()] = &worker{notifier: notifier, queue: make(chan Alert, queueSize)}
+	}
+
+	names := map[string]bool{}
+	for _, rule := range rules {
+		compiled, err := engine.compile(rule)
+		if err != nil {
+			return nil, fmt.Errorf("rule %q: %w", rule.Name, err)
+		}
+		if names[rule.Name] {
+			return nil, fmt.Errorf("rule %q is defined twice", rule.Name)
+		}
+		names[rule.Name] = true
+		engine.rules = append(engine.rules, compiled)
+	}
+	return engine, nil
+}
+
+// Helper function to parse a rule and check its channels exist
+func (e *Engine) compile(rule Rule) (*compiledRule, error) {
+	if rule.Name == "" {
+		return nil, fmt.Errorf("a name is required")
+	}
+	condition, err := ParseCondition(rule.Condition)
+	if err != nil {
+		return nil, err
+	}
+	compiled := &compiledRule{Rule: rule, condition: condition, channels: rule.Channels}
+	compiled.Symbol = strings.ToUpper(rule.Symbol)
+
+	if rule.Cooldown != "" {
+		if compiled.cooldown, err = time.ParseDuration(rule.Cooldown); err != nil || compiled.cooldown < 0 {
+			return nil, fmt.Errorf("invalid cooldown %q", rule.Cooldown)
+		}
+	}
+
+	if len(compiled.channels) == 0 {
+		for channel := range e.notifiers {
+			compiled.channels = append(compiled.channels, channel)
+		}
+		sort.Strings(compiled.channels)
+	}
+	for _, channel := range compiled.channels {
+		if _, ok := e.notifiers[channel]; !ok {
+			return nil, fmt.Errorf("channel %q is not configured", channel)
+		}
+	}
+	return compiled, nil
+}
+
+// Rules returns the number of rules being evaluated
+func (e *Engine) Rules() int {
+	return len(e.rules)
+}
+
+// OnCandle evaluates the rules of `symbol` on its closed candles, queues the alerts that fire and returns them
+// - `candles` holds every closed candle of the symbol so far, oldest first
+func (e *Engine) OnCandle(symbol string, candles []financeFunctions.Candlestick) []Alert {
+	if len(candles) == 0 || len(e.rules) == 0 {
+		return nil
+	}
+	ctx := strategy.NewContext(symbol, candles)
+	candle := ctx.Last()
+
+	e.mu.Lock()
+	var fired []Alert
+	var deliveries [][]string
+	for _, rule := range e.rules {
+		if rule.Symbol != "" && rule.Symbol != symbol {
+			continue
+		}
+		key := rule.Name + "|" + symbol
+		state, ok := e.states[key]
+		if !ok {
+			state = &ruleState{}
+			e.states[key] = state
+		}
+		if candle.CloseTime <= state.lastCheck {
+			continue
+		}
+		state.lastCheck = candle.CloseTime
+
+		hit, left, right, ok := rule.condition.Evaluate(ctx)
+		wasActive := state.active
+		state.active = hit && ok
+		if !state.active || wasActive {
+			continue
+		}
+		if state.lastFired > 0 && candle.CloseTime-state.lastFired < rule.cooldown.Milliseconds() {
+			continue
+		}
+		state.lastFired = candle.CloseTime
+
+		alert := Alert{
+			ID:        fmt.Sprintf("%s-%s-%d", rule.Name, symbol, candle.CloseTime),
+			Rule:      rule.Name,
+			Symbol:    symbol,
+			Condition: rule.condition.String(),
+			Message:   rule.Message,
+			Left:      left,
+			Right:     right,
+			Price:     candle.Close,
+			Time:      candle.CloseTime,
+		}
+		if alert.Message == "" {
+			alert.Message = describe(alert, rule.condition)
+		}
+		fired = append(fired, alert)
+		deliveries = append(deliveries, rule.channels)
+	}
+	e.mu.Unlock()
+
+	for i, alert := range fired {
+		log.Printf("Alert: %s", alert.Message)
+		for _, channel := range deliveries[i] {
+			select {
+			case e.notifiers[channel].queue <- alert:
+			default:
+				log.Printf("Warning: %s alert queue is full, dropping alert %s", channel, alert.ID)
+			}
+		}
+	}
+	return fired
+}
+
+// Run delivers queued alerts until `ctx` is cancelled
+func (e *Engine) Run(ctx context.Context) {
+	var wg sync.WaitGroup
+	for _, w := range e.notifiers {
+		wg.Add(1)
+		go func(w *worker) {
+			defer wg.Done()
+			for {
+				select {
+				case <-ctx.Done():
+					return
+				case alert := <-w.queue:
+					deliver(ctx, w.notifier, alert)
+				}
+			}
+		}(w)
+	}
+	wg.Wait()
+}
+
+// Helper function to deliver an alert, retrying failures with a growing wait
+func deliver(ctx context.Context, notifier Notifier, alert Alert) {
+	backoff := retryBackoff
+	for attempt := 1; ; attempt++ {
+		err := notifier.Notify(ctx, alert)
+		if err == nil {
+			return
+		}
+		if attempt == maxAttempts {
+			log.Printf("Error while delivering alert %s via %s, giving up: %v", alert.ID, notifier.Channel(), err)
+			return
+		}
+		log.Printf("Error while delivering alert %s via %s, retrying in %s: %v", alert.ID, notifier.Channel(), backoff, err)
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(backoff):
+		}
+		backoff *= 2
+	}
+}
+
+// Helper function to describe an alert, e.g. "BNBBTC: rsi(14) < 30 (rsi(14) = 27.4) at 0.0091"
+func describe(alert Alert, condition Condition) string {
+	values := fmt.Sprintf("%s = %s", condition.Left, formatValue(alert.Left))
+	if condition.Right.Name != "" {
+		values += fmt.Sprintf(", %s = %s", condition.Right, formatValue(alert.Right))
+	}
+	return fmt.Sprintf("%s: %s (%s) at %s", alert.Symbol, alert.Condition, values, formatValue(alert.Price))
+}
+
+// Helper function to print a value without trailing zeros
+func formatValue(value float64) string {
+	return strconv.FormatFloat(value, 'g', 8, 64)
+}
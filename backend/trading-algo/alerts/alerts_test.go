@@ -0,0 +1,107 @@
+package alerts
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/neozhixuan/project-visualgo-backend/trading-algo/financeFunctions"
+)
+
+// Helper function to make one minute candles closing at `closes`, the i-th closing at the end of minute i
+func minuteCandles(closes ...float64) []financeFunctions.Candlestick {
+	candles := make([]financeFunctions.Candlestick, len(closes))
+	for i, close := range closes {
+		openTime := int64(i) * 60000
+		candles[i] = financeFunctions.Candlestick{Open: close, High: close, Low: close, Close: close, Volume: 1, OpenTime: openTime, CloseTime: openTime + 59999}
+	}
+	return candles
+}
+
+// Helper function to feed the candles in one at a time, returning the indices of the candles each rule fired on
+func firedOn(t *testing.T, engine *Engine, candles []financeFunctions.Candlestick) map[string][]int {
+	t.Helper()
+	fired := map[string][]int{}
+	for i := range candles {
+		for _, alert := range engine.OnCandle("BNBBTC", candles[:i+1]) {
+			if alert.Time != candles[i].CloseTime || alert.Price != candles[i].Close {
+				t.Errorf("alert %s is for the candle closing at %d, want the last candle, closing at %d", alert.ID, alert.Time, candles[i].CloseTime)
+			}
+			fired[alert.Rule] = append(fired[alert.Rule], i)
+		}
+	}
+	return fired
+}
+
+func newTestEngine(t *testing.T, rules ...Rule) *Engine {
+	t.Helper()
+	engine, err := NewEngine(rules)
+	if err != nil {
+		t.Fatalf("NewEngine(): %v", err)
+	}
+	return engine
+}
+
+func TestComparisonFiresWhenItStartsToHold(t *testing.T) {
+	engine := newTestEngine(t, Rule{Name: "above", Condition: "price > 10"})
+	fired := firedOn(t, engine, minuteCandles(9, 11, 12, 13, 9, 11))
+
+	// Not again while the price stays above 10, only once it has dropped below and risen again
+	if want := []int{1, 5}; !reflect.DeepEqual(fired["above"], want) {
+		t.Errorf("fired on candles %v, want %v", fired["above"], want)
+	}
+}
+
+func TestCooldown(t *testing.T) {
+	engine := newTestEngine(t, Rule{Name: "above", Condition: "price > 10", Cooldown: "3m"})
+	fired := firedOn(t, engine, minuteCandles(9, 11, 9, 11, 9, 11))
+
+	// Candle 3 closes 2 minutes after candle 1 fired, within the cooldown, and candle 5 closes 4 minutes after
+	if want := []int{1, 5}; !reflect.DeepEqual(fired["above"], want) {
+		t.Errorf("fired on candles %v, want %v", fired["above"], want)
+	}
+}
+
+func TestSameCandleNeverFiresTwice(t *testing.T) {
+	engine := newTestEngine(t, Rule{Name: "above", Condition: "price > 10"})
+	candles := minuteCandles(9, 11)
+
+	if alerts := engine.OnCandle("BNBBTC", candles); len(alerts) != 1 {
+		t.Fatalf("OnCandle() = %d alerts, want 1", len(alerts))
+	}
+	// e.g. candles sent again after a reconnect
+	if alerts := engine.OnCandle("BNBBTC", candles); len(alerts) != 0 {
+		t.Errorf("OnCandle() on the same candle again = %+v, want no alerts", alerts)
+	}
+	if alerts := engine.OnCandle("BNBBTC", candles[:1]); len(alerts) != 0 {
+		t.Errorf("OnCandle() on an older candle = %+v, want no alerts", alerts)
+	}
+}
+
+func TestCrosses(t *testing.T) {
+	engine := newTestEngine(t,
+		Rule{Name: "up", Condition: "price crosses above 10"},
+		Rule{Name: "down", Condition: "price crosses below 10"},
+		Rule{Name: "emaUp", Condition: "ema(2) crosses above sma(3)"},
+	)
+	fired := firedOn(t, engine, minuteCandles(9, 9, 9, 11, 12, 12, 8, 8, 11))
+
+	want := map[string][]int{
+		"up":   {3, 8},
+		"down": {6},
+		// The EMA reacts faster than the SMA, so it crosses above it on the first rise
+		"emaUp": {3, 8},
+	}
+	if !reflect.DeepEqual(fired, want) {
+		t.Errorf("fired on candles %v, want %v", fired, want)
+	}
+}
+
+func TestRulesOfOtherSymbolsAreSkipped(t *testing.T) {
+	engine := newTestEngine(t, Rule{Name: "eth", Symbol: "ethbtc", Condition: "price > 10"})
+	if fired := firedOn(t, engine, minuteCandles(9, 11)); len(fired) != 0 {
+		t.Errorf("rule of ETHBTC fired on BNBBTC candles: %v", fired)
+	}
+	if alerts := engine.OnCandle("ETHBTC", minuteCandles(9, 11)); len(alerts) != 1 {
+		t.Errorf("OnCandle() on ETHBTC = %d alerts, want 1", len(alerts))
+	}
+}
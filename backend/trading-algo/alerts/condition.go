@@ -0,0 +1,338 @@
+package alerts
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/neozhixuan/project-visualgo-backend/trading-algo/financeFunctions"
+	"github.com/neozhixuan/project-visualgo-backend/trading-algo/strategy"
+)
+
+// Comparator is how the two sides of a condition are compared
+type Comparator string
+
+const (
+	Above        Comparator = ">"
+	AtOrAbove    Comparator = ">="
+	Below        Comparator = "<"
+	AtOrBelow    Comparator = "<="
+	CrossesAbove Comparator = "crosses above"
+	CrossesBelow Comparator = "crosses below"
+)
+
+// Condition compares two operands on a closed candle, e.g. "ema(9) crosses above ema(21)"
+// - comparisons (>, >=, <, <=) hold for as long as they are true, crosses only hold on the candle they happen
+type Condition struct {
+	Left       Operand
+	Comparator Comparator
+	Right      Operand
+}
+
+// Operand is one side of a condition: a number, a candle field or an indicator, optionally scaled
+// - e.g. "30", "price", "rsi(14)" or "3 * avgVolume(20)"
+type Operand struct {
+	Scale  float64
+	Name   string  // empty for a plain number
+	Period int     // for indicators
+	Number float64 // for a plain number
+}
+
+// Names of the candle fields and indicators a condition can use, and whether they take a period
+var operandNames = map[string]bool{
+	"price":     false, // the close
+	"open":      false,
+	"high":      false,
+	"low":       false,
+	"close":     false,
+	"volume":    false,
+	"ema":       true,
+	"sma":       true,
+	"rsi":       true,
+	"atr":       true,
+	"avgVolume": true, // average volume of the candles before this one, for spotting volume spikes
+}
+
+// ParseCondition parses a condition such as "price > 0.0091", "rsi(14) < 30" or "volume > 3 * avgVolume(20)"
+func ParseCondition(text string) (Condition, error) {
+	tokens, err := tokenize(text)
+	if err != nil {
+		return Condition{}, err
+	}
+	parser := &conditionParser{tokens: tokens}
+
+	var condition Condition
+	if condition.Left, err = parser.operand(); err != nil {
+		return Condition{}, err
+	}
+	if condition.Comparator, err = parser.comparator(); err != nil {
+		return Condition{}, err
+	}
+	if condition.Right, err = parser.operand(); err != nil {
+		return Condition{}, err
+	}
+	if !parser.done() {
+		return Condition{}, fmt.Errorf("unexpected %q after the condition", parser.peek())
+	}
+	if condition.Left.Name == "" && condition.Right.Name == "" {
+		return Condition{}, fmt.Errorf("condition %q compares two numbers", text)
+	}
+	return condition, nil
+}
+
+func (c Condition) String() string {
+	return fmt.Sprintf("%s %s %s", c.Left, c.Comparator, c.Right)
+}
+
+func (o Operand) String() string {
+	var term string
+	switch {
+	case o.Name == "":
+		return strconv.FormatFloat(o.Scale*o.Number, 'f', -1, 64)
+	case operandNames[o.Name]:
+		term = fmt.Sprintf("%s(%d)", o.Name, o.Period)
+	default:
+		term = o.Name
+	}
+	if o.Scale != 1 {
+		return fmt.Sprintf("%s * %s", strconv.FormatFloat(o.Scale, 'f', -1, 64), term)
+	}
+	return term
+}
+
+// Evaluate checks the condition on the last candle of `ctx`, returning both sides' values for the alert message
+// - ok is false when an indicator has no valid value yet
+func (c Condition) Evaluate(ctx *strategy.Context) (hit bool, left, right float64, ok bool) {
+	left, okLeft := c.Left.value(ctx, 0)
+	right, okRight := c.Right.value(ctx, 0)
+	if !okLeft || !okRight {
+		return false, left, right, false
+	}
+
+	switch c.Comparator {
+	case Above:
+		return left > right, left, right, true
+	case AtOrAbove:
+		return left >= right, left, right, true
+	case Below:
+		return left < right, left, right, true
+	case AtOrBelow:
+		return left <= right, left, right, true
+	}
+
+	// Crosses compare against the previous candle
+	previousLeft, okLeft := c.Left.value(ctx, 1)
+	previousRight, okRight := c.Right.value(ctx, 1)
+	if !okLeft || !okRight {
+		return false, left, right, false
+	}
+	if c.Comparator == CrossesAbove {
+		return previousLeft <= previousRight && left > right, left, right, true
+	}
+	return previousLeft >= previousRight && left < right, left, right, true
+}
+
+// Helper function to read an operand `offset` candles before the last one
+func (o Operand) value(ctx *strategy.Context, offset int) (float64, bool) {
+	if o.Name == "" {
+		return o.Scale * o.Number, true
+	}
+	i := len(ctx.Candles) - 1 - offset
+	if i < 0 {
+		return 0, false
+	}
+
+	var value float64
+	candle := ctx.Candles[i]
+	switch o.Name {
+	case "price", "close":
+		value = candle.Close
+	case "open":
+		value = candle.Open
+	case "high":
+		value = candle.High
+	case "low":
+		value = candle.Low
+	case "volume":
+		value = candle.Volume
+	case "avgVolume":
+		if i < o.Period {
+			return 0, false
+		}
+		sum := 0.0
+		for _, previous := range ctx.Candles[i-o.Period : i] {
+			sum += previous.Volume
+		}
+		value = sum / float64(o.Period)
+	default:
+		line := o.line(ctx)
+		if i >= len(line.Values) || !line.Valid[i] {
+			return 0, false
+		}
+		value = line.Values[i]
+	}
+
+	value *= o.Scale
+	return value, !math.IsNaN(value) && !math.IsInf(value, 0)
+}
+
+// Helper function to calculate an indicator operand, shared with every other rule on the candle
+func (o Operand) line(ctx *strategy.Context) financeFunctions.IndicatorLine {
+	switch o.Name {
+	case "ema":
+		return ctx.EMA(o.Period)
+	case "rsi":
+		return ctx.RSI(o.Period)
+	case "atr":
+		return ctx.ATR(o.Period)
+	}
+	return ctx.SMA(o.Period)
+}
+
+// Reads the tokens of a condition, one operand or comparator at a time
+type conditionParser struct {
+	tokens []string
+	next   int
+}
+
+func (p *conditionParser) done() bool {
+	return p.next >= len(p.tokens)
+}
+
+func (p *conditionParser) peek() string {
+	if p.done() {
+		return ""
+	}
+	return p.tokens[p.next]
+}
+
+func (p *conditionParser) take() string {
+	token := p.peek()
+	p.next++
+	return token
+}
+
+// Helper function to parse `[number *] (number | name | name(period))`
+func (p *conditionParser) operand() (Operand, error) {
+	operand := Operand{Scale: 1}
+	if number, err := strconv.ParseFloat(p.peek(), 64); err == nil {
+		p.take()
+		if p.peek() != "*" {
+			operand.Number = number
+			return operand, nil
+		}
+		p.take()
+		operand.Scale = number
+	}
+
+	name := p.take()
+	takesPeriod, known := operandNames[name]
+	if !known {
+		if name == "" {
+			return Operand{}, fmt.Errorf("condition is missing an operand")
+		}
+		return Operand{}, fmt.Errorf("unknown operand %q", name)
+	}
+	operand.Name = name
+	if !takesPeriod {
+		return operand, nil
+	}
+
+	open, period, closing := p.take(), p.take(), p.take()
+	number, err := strconv.Atoi(period)
+	if open != "(" || closing != ")" || err != nil || number <= 0 {
+		return Operand{}, fmt.Errorf("%s needs a positive period, e.g. %s(14)", name, name)
+	}
+	operand.Period = number
+	return operand, nil
+}
+
+// Helper function to parse a comparator, where "crosses" is followed by "above" or "below"
+func (p *conditionParser) comparator() (Comparator, error) {
+	switch token := p.take(); token {
+	case ">", ">=", "<", "<=":
+		return Comparator(token), nil
+	case "crosses":
+		switch direction := p.take(); direction {
+		case "above", "below":
+			return Comparator(token + " " + direction), nil
+		}
+		return "", fmt.Errorf("\"crosses\" must be followed by \"above\" or \"below\"")
+	default:
+		return "", fmt.Errorf("expected >, >=, <, <=, crosses above or crosses below, got %q", token)
+	}
+}
+
+// Helper function to split a condition into numbers, names, comparators and punctuation
+func tokenize(text string) ([]string, error) {
+	var tokens []string
+	runes := []rune(text)
+	for i := 0; i < len(runes); {
+		r := runes[i]
+		switch {
+		case unicode.IsSpace(r):
+			i++
+		case r == '(' || r == ')' || r == '*':
+			tokens = append(tokens, string(r))
+			i++
+		case r == '>' || r == '<':
+			if i+1 < len(runes) && runes[i+1] == '=' {
+				tokens = append(tokens, string(runes[i:i+2]))
+				i += 2
+			} else {
+				tokens = append(tokens, string(r))
+				i++
+			}
+		case isNumberStart(runes[i:]):
+			number := scanNumber(runes[i:])
+			if _, err := strconv.ParseFloat(number, 64); err != nil {
+				return nil, fmt.Errorf("invalid number %q in condition %q", number, strings.TrimSpace(text))
+			}
+			tokens = append(tokens, number)
+			i += len([]rune(number))
+		case unicode.IsLetter(r):
+			start := i
+			for i < len(runes) && (unicode.IsLetter(runes[i]) || unicode.IsDigit(runes[i])) {
+				i++
+			}
+			tokens = append(tokens, string(runes[start:i]))
+		default:
+			return nil, fmt.Errorf("unexpected %q in condition %q", r, strings.TrimSpace(text))
+		}
+	}
+	return tokens, nil
+}
+
+// Helper function to check whether a number starts here, a sign counting as one as conditions have no subtraction
+func isNumberStart(runes []rune) bool {
+	if len(runes) > 1 && (runes[0] == '-' || runes[0] == '+') {
+		runes = runes[1:]
+	}
+	return unicode.IsDigit(runes[0]) || (runes[0] == '.' && len(runes) > 1 && unicode.IsDigit(runes[1]))
+}
+
+// Helper function to read a number with an optional sign and exponent, e.g. "-1", "0.0091" or "1e-5"
+func scanNumber(runes []rune) string {
+	i := 0
+	if runes[i] == '-' || runes[i] == '+' {
+		i++
+	}
+	for i < len(runes) && (unicode.IsDigit(runes[i]) || runes[i] == '.') {
+		i++
+	}
+	if i < len(runes) && (runes[i] == 'e' || runes[i] == 'E') {
+		exponent := i + 1
+		if exponent < len(runes) && (runes[exponent] == '-' || runes[exponent] == '+') {
+			exponent++
+		}
+		if exponent < len(runes) && unicode.IsDigit(runes[exponent]) {
+			i = exponent
+			for i < len(runes) && unicode.IsDigit(runes[i]) {
+				i++
+			}
+		}
+	}
+	return string(runes[:i])
+}
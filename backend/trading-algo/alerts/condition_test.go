@@ -0,0 +1,36 @@
+package alerts
+
+import "testing"
+
+func TestParseConditionNumbers(t *testing.T) {
+	tests := []struct {
+		text  string
+		right Operand
+	}{
+		{"price > -1", Operand{Scale: 1, Number: -1}},
+		{"price < 1e-5", Operand{Scale: 1, Number: 0.00001}},
+		{"price < 8.5E-3", Operand{Scale: 1, Number: 0.0085}},
+		{"volume > 1e+6", Operand{Scale: 1, Number: 1000000}},
+		{"price > +.5", Operand{Scale: 1, Number: 0.5}},
+		{"close > -2 * low", Operand{Scale: -2, Name: "low"}},
+		{"volume > 1.5e0 * avgVolume(20)", Operand{Scale: 1.5, Name: "avgVolume", Period: 20}},
+	}
+	for _, test := range tests {
+		condition, err := ParseCondition(test.text)
+		if err != nil {
+			t.Errorf("ParseCondition(%q): %v", test.text, err)
+			continue
+		}
+		if condition.Right != test.right {
+			t.Errorf("ParseCondition(%q) compares against %+v, want %+v", test.text, condition.Right, test.right)
+		}
+	}
+}
+
+func TestParseConditionRejectsBadNumbers(t *testing.T) {
+	for _, text := range []string{"price > 1.2.3", "price > -", "price > 1e", "price > 1e-", "price > - 1"} {
+		if condition, err := ParseCondition(text); err == nil {
+			t.Errorf("ParseCondition(%q) = %v, want an error", text, condition)
+		}
+	}
+}
@@ -0,0 +1,142 @@
+package alerts
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/smtp"
+	"strings"
+	"time"
+)
+
+// Notifier delivers alerts over one channel
+type Notifier interface {
+	// Channel names the notifier in rules, e.g. "webhook"
+	Channel() string
+
+	// Notify delivers a single alert, returning an error when it should be retried
+	Notify(ctx context.Context, alert Alert) error
+}
+
+// FuncNotifier delivers alerts by calling a function, e.g. to push them to the WebSocket clients
+type FuncNotifier struct {
+	Name string
+	Send func(alert Alert) error
+}
+
+func (n *FuncNotifier) Channel() string {
+	return n.Name
+}
+
+func (n *FuncNotifier) Notify(ctx context.Context, alert Alert) error {
+	return n.Send(alert)
+}
+
+// Webhook POSTs every alert as JSON to a URL
+// - with a secret, the body is signed with HMAC SHA256 in the X-Alert-Signature header, hex encoded
+// - X-Alert-ID carries the alert's ID, so the receiver can drop redeliveries
+type Webhook struct {
+	URL    string
+	Secret string
+	client *http.Client
+}
+
+// Time allowed for a webhook or mail server to accept an alert
+const deliveryTimeout = 10 * time.Second
+
+// NewWebhook creates a Webhook posting to `url`
+func NewWebhook(url, secret string) *Webhook {
+	return &Webhook{URL: url, Secret: secret, client: &http.Client{Timeout: deliveryTimeout}}
+}
+
+func (w *Webhook) Channel() string {
+	return "webhook"
+}
+
+func (w *Webhook) Notify(ctx context.Context, alert Alert) error {
+	body, err := json.Marshal(alert)
+	if err != nil {
+		return err
+	}
+	request, err := http.NewRequestWithContext(ctx, http.MethodPost, w.URL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	request.Header.Set("Content-Type", "application/json")
+	request.Header.Set("X-Alert-ID", alert.ID)
+	if w.Secret != "" {
+		mac := hmac.New(sha256.New, []byte(w.Secret))
+		mac.Write(body)
+		request.Header.Set("X-Alert-Signature", hex.EncodeToString(mac.Sum(nil)))
+	}
+
+	response, err := w.client.Do(request)
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+	io.Copy(io.Discard, response.Body)
+	if response.StatusCode < 200 || response.StatusCode > 299 {
+		return fmt.Errorf("webhook responded with %s", response.Status)
+	}
+	return nil
+}
+
+// Email sends every alert as a plain text mail over SMTP
+// - Username and Password are optional, and only sent over TLS or to localhost
+type Email struct {
+	Host     string
+	Port     int
+	Username string
+	Password string
+	From     string
+	To       []string
+}
+
+func (e *Email) Channel() string {
+	return "email"
+}
+
+func (e *Email) Notify(ctx context.Context, alert Alert) error {
+	address := net.JoinHostPort(e.Host, fmt.Sprint(e.Port))
+	var auth smtp.Auth
+	if e.Username != "" {
+		auth = smtp.PlainAuth("", e.Username, e.Password, e.Host)
+	}
+
+	// net/smtp cannot be cancelled, so give up waiting on it instead
+	done := make(chan error, 1)
+	go func() {
+		done <- smtp.SendMail(address, auth, e.From, e.To, e.message(alert))
+	}()
+	select {
+	case err := <-done:
+		return err
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-time.After(deliveryTimeout):
+		return fmt.Errorf("mail server at %s did not respond within %s", address, deliveryTimeout)
+	}
+}
+
+// Helper function to write an alert as a mail, headers and all
+func (e *Email) message(alert Alert) []byte {
+	var message strings.Builder
+	fmt.Fprintf(&message, "From: %s\r\n", e.From)
+	fmt.Fprintf(&message, "To: %s\r\n", strings.Join(e.To, ", "))
+	fmt.Fprintf(&message, "Subject: [%s] %s alert: %s\r\n", alert.Symbol, alert.Rule, alert.Condition)
+	fmt.Fprintf(&message, "Date: %s\r\n", time.Now().UTC().Format(time.RFC1123Z))
+	fmt.Fprintf(&message, "X-Alert-ID: %s\r\n", alert.ID)
+	message.WriteString("MIME-Version: 1.0\r\nContent-Type: text/plain; charset=utf-8\r\n\r\n")
+	fmt.Fprintf(&message, "%s\r\n\r\n", alert.Message)
+	fmt.Fprintf(&message, "Rule: %s\r\nSymbol: %s\r\nCondition: %s\r\nPrice: %s\r\nCandle closed: %s\r\n",
+		alert.Rule, alert.Symbol, alert.Condition, formatValue(alert.Price), time.UnixMilli(alert.Time).UTC().Format(time.RFC3339))
+	return []byte(message.String())
+}
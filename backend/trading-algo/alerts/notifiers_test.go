@@ -0,0 +1,191 @@
+package alerts
+
+import (
+	"bufio"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+const testSecret = "webhook-secret"
+
+// A webhook delivery as the receiver saw it
+type delivery struct {
+	id    string
+	alert Alert
+}
+
+// Helper function to start a webhook receiver that checks every body's signature, like cmd/alertReceiver
+// - deliveries with a bad signature are answered 401, and only the others are passed on
+func startWebhookReceiver(t *testing.T) (string, chan delivery) {
+	t.Helper()
+	deliveries := make(chan delivery, 10)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		mac := hmac.New(sha256.New, []byte(testSecret))
+		mac.Write(body)
+		if !hmac.Equal([]byte(hex.EncodeToString(mac.Sum(nil))), []byte(r.Header.Get("X-Alert-Signature"))) {
+			http.Error(w, "bad signature", http.StatusUnauthorized)
+			return
+		}
+		var alert Alert
+		if err := json.Unmarshal(body, &alert); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		deliveries <- delivery{id: r.Header.Get("X-Alert-ID"), alert: alert}
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	t.Cleanup(server.Close)
+	return server.URL, deliveries
+}
+
+// Helper function to start an SMTP server speaking just enough for net/smtp.SendMail, passing on every mail it accepts
+func startSMTPServer(t *testing.T) (int, chan string) {
+	t.Helper()
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("could not start the SMTP server: %v", err)
+	}
+	t.Cleanup(func() { listener.Close() })
+
+	mails := make(chan string, 10)
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go serveSMTP(conn, mails)
+		}
+	}()
+	return listener.Addr().(*net.TCPAddr).Port, mails
+}
+
+func serveSMTP(conn net.Conn, mails chan string) {
+	defer conn.Close()
+	reader := bufio.NewReader(conn)
+	reply := func(line string) {
+		io.WriteString(conn, line+"\r\n")
+	}
+	reply("220 localhost ready")
+	for {
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			return
+		}
+		switch strings.ToUpper(strings.SplitN(strings.TrimSpace(line), " ", 2)[0]) {
+		case "EHLO", "HELO":
+			reply("250 localhost")
+		case "DATA":
+			reply("354 End data with <CR><LF>.<CR><LF>")
+			var mail strings.Builder
+			for {
+				data, err := reader.ReadString('\n')
+				if err != nil {
+					return
+				}
+				if data == ".\r\n" {
+					break
+				}
+				mail.WriteString(data)
+			}
+			mails <- mail.String()
+			reply("250 OK")
+		case "QUIT":
+			reply("221 Bye")
+			return
+		default:
+			reply("250 OK")
+		}
+	}
+}
+
+func testAlert() Alert {
+	return Alert{ID: "oversold-BNBBTC-59999", Rule: "oversold", Symbol: "BNBBTC", Condition: "rsi(14) < 30", Message: "BNBBTC is oversold", Price: 0.0085, Time: 59999}
+}
+
+func TestWebhookSignsAlerts(t *testing.T) {
+	url, deliveries := startWebhookReceiver(t)
+	alert := testAlert()
+
+	if err := NewWebhook(url, testSecret).Notify(context.Background(), alert); err != nil {
+		t.Fatalf("Notify(): %v", err)
+	}
+	got := <-deliveries
+	if got.id != alert.ID || got.alert != alert {
+		t.Errorf("receiver got %+v with X-Alert-ID %q, want %+v with %q", got.alert, got.id, alert, alert.ID)
+	}
+
+	if err := NewWebhook(url, "wrong-secret").Notify(context.Background(), alert); err == nil {
+		t.Errorf("Notify() signed with the wrong secret succeeded, want the receiver's 401 as an error")
+	}
+}
+
+func TestEmailSendsAlerts(t *testing.T) {
+	port, mails := startSMTPServer(t)
+	email := &Email{Host: "127.0.0.1", Port: port, From: "alerts@example.com", To: []string{"you@example.com"}}
+	alert := testAlert()
+
+	if err := email.Notify(context.Background(), alert); err != nil {
+		t.Fatalf("Notify(): %v", err)
+	}
+	mail := <-mails
+	for _, want := range []string{"X-Alert-ID: " + alert.ID, "Subject: [BNBBTC] oversold alert: rsi(14) < 30", "BNBBTC is oversold"} {
+		if !strings.Contains(mail, want) {
+			t.Errorf("mail does not contain %q:\n%s", want, mail)
+		}
+	}
+}
+
+func TestEngineDeliversToEveryChannel(t *testing.T) {
+	url, deliveries := startWebhookReceiver(t)
+	port, mails := startSMTPServer(t)
+	engine, err := NewEngine(
+		[]Rule{{Name: "above", Condition: "price > 10"}},
+		NewWebhook(url, testSecret),
+		&Email{Host: "127.0.0.1", Port: port, From: "alerts@example.com", To: []string{"you@example.com"}},
+	)
+	if err != nil {
+		t.Fatalf("NewEngine(): %v", err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go engine.Run(ctx)
+
+	fired := engine.OnCandle("BNBBTC", minuteCandles(9, 11))
+	if len(fired) != 1 {
+		t.Fatalf("OnCandle() = %d alerts, want 1", len(fired))
+	}
+
+	// Every channel gets the same alert ID, so receivers can tell redeliveries apart from new alerts
+	select {
+	case got := <-deliveries:
+		if got.id != fired[0].ID {
+			t.Errorf("webhook got X-Alert-ID %q, want %q", got.id, fired[0].ID)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatalf("webhook got no alert")
+	}
+	select {
+	case mail := <-mails:
+		if !strings.Contains(mail, "X-Alert-ID: "+fired[0].ID) {
+			t.Errorf("mail does not carry X-Alert-ID %q:\n%s", fired[0].ID, mail)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatalf("mail server got no alert")
+	}
+}
@@ -0,0 +1,101 @@
+package backtest
+
+import (
+	"math"
+
+	"github.com/neozhixuan/project-visualgo-backend/trading-algo/strategy"
+)
+
+// The simulated account, holding cash and at most one position
+type account struct {
+	config   Config
+	cash     float64
+	quantity float64 // positive when long, negative when short
+	fees     float64
+	open     *Trade // the position being held, if any
+	trades   []Trade
+}
+
+// Value of the account, marking the position at `price`
+func (a *account) equity(price float64) float64 {
+	return a.cash + a.quantity*price
+}
+
+// Move to a long (1), short (-1) or no (0) position, filling at `price` before slippage
+func (a *account) moveTo(direction float64, price float64, time int64, reason string) {
+	if direction == sign(a.quantity) {
+		return
+	}
+	if a.quantity != 0 {
+		a.close(price, time, reason)
+	}
+	if direction != 0 {
+		a.openPosition(direction, price, time, reason)
+	}
+}
+
+// Helper function to open a position worth the configured fraction of equity
+// - only called when flat, so all equity is cash
+func (a *account) openPosition(direction float64, price float64, time int64, reason string) {
+	fill := a.fillPrice(price, direction)
+	quantity := a.cash * a.config.PositionSize / (fill * (1 + a.config.FeeRate))
+	fee := a.fill(direction*quantity, fill)
+
+	side := strategy.Buy
+	if direction < 0 {
+		side = strategy.Sell
+	}
+	a.open = &Trade{
+		Side:        side,
+		Quantity:    quantity,
+		EntryTime:   time,
+		EntryPrice:  fill,
+		EntryReason: reason,
+		Fees:        fee,
+	}
+}
+
+// Helper function to close the position, recording it as a trade
+func (a *account) close(price float64, time int64, reason string) {
+	direction := sign(a.quantity)
+	fill := a.fillPrice(price, -direction)
+	fee := a.fill(-a.quantity, fill)
+
+	trade := *a.open
+	trade.ExitTime = time
+	trade.ExitPrice = fill
+	trade.ExitReason = reason
+	trade.Fees += fee
+	trade.PnL = direction*(trade.ExitPrice-trade.EntryPrice)*trade.Quantity - trade.Fees
+	trade.ReturnPct = trade.PnL / (trade.EntryPrice * trade.Quantity) * 100
+	a.trades = append(a.trades, trade)
+	a.open = nil
+}
+
+// Helper function to trade `quantity` (negative to sell) at `fill`, returning the fee paid
+func (a *account) fill(quantity float64, fill float64) float64 {
+	fee := math.Abs(quantity) * fill * a.config.FeeRate
+	a.cash -= quantity*fill + fee
+	a.quantity += quantity
+	a.fees += fee
+	if math.Abs(a.quantity) < 1e-12 {
+		a.quantity = 0
+	}
+	return fee
+}
+
+// Helper function to apply slippage, buying (direction 1) higher and selling (direction -1) lower
+func (a *account) fillPrice(price float64, direction float64) float64 {
+	return price * (1 + direction*a.config.SlippageRate)
+}
+
+// Helper function to get the sign of a quantity
+func sign(x float64) float64 {
+	switch {
+	case x > 0:
+		return 1
+	case x < 0:
+		return -1
+	}
+	return 0
+}
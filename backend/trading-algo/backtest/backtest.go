@@ -0,0 +1,187 @@
+package backtest
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/neozhixuan/project-visualgo-backend/trading-algo/financeFunctions"
+	"github.com/neozhixuan/project-visualgo-backend/trading-algo/strategy"
+)
+
+// Config describes the simulated account and how orders are filled
+type Config struct {
+	Symbol       string  `json:"symbol"`
+	InitialCash  float64 `json:"initialCash"`
+	FeeRate      float64 `json:"feeRate"`      // fraction of every fill's notional paid as fee, e.g. 0.001 for 0.1%
+	SlippageRate float64 `json:"slippageRate"` // fraction every fill's price moves against us, e.g. 0.0005 for 0.05%
+	PositionSize float64 `json:"positionSize"` // fraction of equity committed to each position, e.g. 1 for all in
+	AllowShort   bool    `json:"allowShort"`   // whether a sell signal opens a short position, or only closes a long one
+	WarmUp       int     `json:"warmUp"`       // candles at the start that strategies see, but that are not traded or reported on
+}
+
+// DefaultConfig returns a 10,000 account paying Binance's spot taker fee
+func DefaultConfig() Config {
+	return Config{
+		Symbol:       "BNBBTC",
+		InitialCash:  10000,
+		FeeRate:      0.001,
+		SlippageRate: 0.0005,
+		PositionSize: 1,
+	}
+}
+
+// Trade is one position, from the fill that opened it to the fill that closed it
+type Trade struct {
+	Side        strategy.Action `json:"side"` // buy for a long position, sell for a short one
+	Quantity    float64         `json:"quantity"`
+	EntryTime   int64           `json:"entryTime"`
+	EntryPrice  float64         `json:"entryPrice"`
+	EntryReason string          `json:"entryReason"`
+	ExitTime    int64           `json:"exitTime"`
+	ExitPrice   float64         `json:"exitPrice"`
+	ExitReason  string          `json:"exitReason"`
+	Fees        float64         `json:"fees"`
+	PnL         float64         `json:"pnl"`       // after fees
+	ReturnPct   float64         `json:"returnPct"` // PnL as a percentage of the entry notional
+}
+
+// EquityPoint is the account's value at a candle's close
+type EquityPoint struct {
+	Time   int64   `json:"time"`
+	Equity float64 `json:"equity"`
+}
+
+// Report is the outcome of a backtest
+// - ratios (TotalReturn, CAGR, MaxDrawdown, WinRate, Exposure) are fractions, e.g. 0.25 for 25%
+// - Sharpe and Sortino are annualised from the returns between candles, with no risk free rate
+type Report struct {
+	Strategy      string        `json:"strategy"`
+	Config        Config        `json:"config"`
+	Start         int64         `json:"start"`
+	End           int64         `json:"end"`
+	Candles       int           `json:"candles"`
+	InitialEquity float64       `json:"initialEquity"`
+	FinalEquity   float64       `json:"finalEquity"`
+	TotalReturn   float64       `json:"totalReturn"`
+	CAGR          float64       `json:"cagr"`
+	Sharpe        float64       `json:"sharpe"`
+	Sortino       float64       `json:"sortino"`
+	MaxDrawdown   float64       `json:"maxDrawdown"`
+	WinRate       float64       `json:"winRate"`
+	Exposure      float64       `json:"exposure"` // fraction of candles closed while holding a position
+	TotalFees     float64       `json:"totalFees"`
+	Trades        []Trade       `json:"trades"`
+	EquityCurve   []EquityPoint `json:"equityCurve"`
+}
+
+// Run replays `candles` (oldest first) through a strategy, exactly as the live gRPC client would
+// - a signal on a candle's close is filled at the next candle's open, as the live path cannot act any sooner
+// - a position still open after the last candle is closed at its close
+// - the last signal during the warm-up is acted on at the first traded candle, as if the strategy had been running all along
+func Run(candles []financeFunctions.Candlestick, factory strategy.Factory, config Config) (Report, error) {
+	if err := validate(candles, config); err != nil {
+		return Report{}, err
+	}
+
+	runner := strategy.NewRunner(factory)
+	series := strategy.NewSeries(config.Symbol, candles)
+	account := &account{config: config, cash: config.InitialCash}
+	report := Report{
+		Strategy:      factory().Name(),
+		Config:        config,
+		Start:         candles[config.WarmUp].OpenTime,
+		End:           candles[len(candles)-1].CloseTime,
+		Candles:       len(candles) - config.WarmUp,
+		InitialEquity: config.InitialCash,
+		Trades:        []Trade{},
+		EquityCurve:   make([]EquityPoint, 0, len(candles)-config.WarmUp),
+	}
+
+	var pending *strategy.Signal
+	var lastPrice float64
+	exposed := 0
+	for i, candle := range candles {
+		// Act on the previous candle's signal at this candle's open
+		// - a candle with no usable open (e.g. missing data) delays the fill to the next one
+		if pending != nil && i >= config.WarmUp && validPrice(candle.Open) {
+			account.moveTo(target(pending.Action, config), candle.Open, candle.OpenTime, pending.Reason)
+			pending = nil
+		}
+
+		for _, signal := range runner.OnContext(series.At(i)) {
+			pending = &signal
+		}
+
+		if validPrice(candle.Close) {
+			lastPrice = candle.Close
+		}
+		if i < config.WarmUp {
+			continue
+		}
+		if account.quantity != 0 {
+			exposed++
+		}
+		report.EquityCurve = append(report.EquityCurve, EquityPoint{Time: candle.CloseTime, Equity: account.equity(lastPrice)})
+	}
+
+	// Close whatever is still open, so every trade in the report is complete
+	if account.quantity != 0 && lastPrice > 0 {
+		account.moveTo(0, lastPrice, report.End, "end of data")
+		report.EquityCurve[len(report.EquityCurve)-1].Equity = account.equity(lastPrice)
+	}
+
+	report.Trades = append(report.Trades, account.trades...)
+	report.FinalEquity = report.EquityCurve[len(report.EquityCurve)-1].Equity
+	report.TotalReturn = report.FinalEquity/report.InitialEquity - 1
+	report.TotalFees = account.fees
+	report.Exposure = float64(exposed) / float64(report.Candles)
+	report.CAGR = cagr(report.InitialEquity, report.FinalEquity, report.End-report.Start)
+	report.MaxDrawdown = maxDrawdown(report.EquityCurve)
+	report.WinRate = winRate(account.trades)
+
+	periodsPerYear := periodsPerYear(candles[config.WarmUp:])
+	returns := equityReturns(report.EquityCurve)
+	report.Sharpe = sharpe(returns, periodsPerYear)
+	report.Sortino = sortino(returns, periodsPerYear)
+	return report, nil
+}
+
+// Helper function to reject configurations and data a backtest cannot run on
+func validate(candles []financeFunctions.Candlestick, config Config) error {
+	switch {
+	case config.WarmUp < 0:
+		return fmt.Errorf("warm up cannot be negative, got %d", config.WarmUp)
+	case len(candles) <= config.WarmUp:
+		return fmt.Errorf("no candles to backtest after the %d candle warm up", config.WarmUp)
+	case !(config.InitialCash > 0):
+		return fmt.Errorf("initial cash must be positive, got %v", config.InitialCash)
+	case !(config.FeeRate >= 0 && config.FeeRate < 1):
+		return fmt.Errorf("fee rate must be in [0, 1), got %v", config.FeeRate)
+	case !(config.SlippageRate >= 0 && config.SlippageRate < 1):
+		return fmt.Errorf("slippage rate must be in [0, 1), got %v", config.SlippageRate)
+	case !(config.PositionSize > 0 && config.PositionSize <= 1):
+		return fmt.Errorf("position size must be in (0, 1], got %v", config.PositionSize)
+	}
+	for i := 1; i < len(candles); i++ {
+		if candles[i].OpenTime <= candles[i-1].OpenTime {
+			return fmt.Errorf("candles must be sorted by open time without duplicates, candle %d is not", i)
+		}
+	}
+	return nil
+}
+
+// Helper function to turn an action into the direction of the position to hold
+func target(action strategy.Action, config Config) float64 {
+	switch {
+	case action == strategy.Buy:
+		return 1
+	case action == strategy.Sell && config.AllowShort:
+		return -1
+	}
+	return 0
+}
+
+// Helper function to check a price can be traded at
+func validPrice(price float64) bool {
+	return price > 0 && !math.IsInf(price, 0)
+}
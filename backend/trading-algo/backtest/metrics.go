@@ -0,0 +1,124 @@
+package backtest
+
+import (
+	"math"
+	"sort"
+
+	"github.com/neozhixuan/project-visualgo-backend/trading-algo/financeFunctions"
+)
+
+const millisecondsPerYear = 365.25 * 24 * 60 * 60 * 1000
+
+// Helper function to annualise the total return over `duration` milliseconds
+func cagr(initial, final float64, duration int64) float64 {
+	if duration <= 0 {
+		return 0
+	}
+	if final <= 0 {
+		return -1
+	}
+	// Annualising a few hours of returns easily overflows, and JSON has no infinity
+	return math.Min(math.Pow(final/initial, millisecondsPerYear/float64(duration))-1, math.MaxFloat64)
+}
+
+// Helper function to find the largest fall from a peak of the equity curve, as a fraction of the peak
+func maxDrawdown(curve []EquityPoint) float64 {
+	peak, drawdown := math.Inf(-1), 0.0
+	for _, point := range curve {
+		peak = math.Max(peak, point.Equity)
+		if peak > 0 {
+			drawdown = math.Max(drawdown, (peak-point.Equity)/peak)
+		}
+	}
+	return drawdown
+}
+
+// Helper function to get the fraction of trades that made money after fees
+func winRate(trades []Trade) float64 {
+	if len(trades) == 0 {
+		return 0
+	}
+	wins := 0
+	for _, trade := range trades {
+		if trade.PnL > 0 {
+			wins++
+		}
+	}
+	return float64(wins) / float64(len(trades))
+}
+
+// Helper function to get the return of the equity curve from each candle to the next
+func equityReturns(curve []EquityPoint) []float64 {
+	returns := make([]float64, 0, len(curve))
+	previous := curve[0].Equity
+	for _, point := range curve[1:] {
+		if previous != 0 {
+			returns = append(returns, point.Equity/previous-1)
+		}
+		previous = point.Equity
+	}
+	return returns
+}
+
+// Helper function to get how many candles make a year, from the usual spacing between candles
+// - the median is used so that gaps in the data do not skew it
+func periodsPerYear(candles []financeFunctions.Candlestick) float64 {
+	if len(candles) < 2 {
+		return 0
+	}
+	spacings := make([]int64, 0, len(candles)-1)
+	for i := 1; i < len(candles); i++ {
+		spacings = append(spacings, candles[i].OpenTime-candles[i-1].OpenTime)
+	}
+	sort.Slice(spacings, func(i, j int) bool { return spacings[i] < spacings[j] })
+	median := spacings[len(spacings)/2]
+	if median <= 0 {
+		return 0
+	}
+	return millisecondsPerYear / float64(median)
+}
+
+// Helper function to get the annualised Sharpe ratio, 0 when returns never vary
+func sharpe(returns []float64, periodsPerYear float64) float64 {
+	if len(returns) < 2 {
+		return 0
+	}
+	mean := average(returns)
+	variance := 0.0
+	for _, r := range returns {
+		variance += (r - mean) * (r - mean)
+	}
+	deviation := math.Sqrt(variance / float64(len(returns)-1))
+	if deviation == 0 {
+		return 0
+	}
+	return mean / deviation * math.Sqrt(periodsPerYear)
+}
+
+// Helper function to get the annualised Sortino ratio, 0 when returns never fall
+// - only returns below zero count towards the deviation
+func sortino(returns []float64, periodsPerYear float64) float64 {
+	if len(returns) < 2 {
+		return 0
+	}
+	downside := 0.0
+	for _, r := range returns {
+		if r < 0 {
+			downside += r * r
+		}
+	}
+	deviation := math.Sqrt(downside / float64(len(returns)))
+	if deviation == 0 {
+		return 0
+	}
+	return average(returns) / deviation * math.Sqrt(periodsPerYear)
+}
+
+// Helper function to get the mean of values
+func average(values []float64) float64 {
+	sum := 0.0
+	for _, value := range values {
+		sum += value
+	}
+	return sum / float64(len(values))
+}
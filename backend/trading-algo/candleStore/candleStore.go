@@ -0,0 +1,260 @@
+package candleStore
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/neozhixuan/project-visualgo-backend/trading-algo/financeFunctions"
+)
+
+// Symbols become file names, so only plain symbols such as "BNBBTC" are accepted
+var validSymbol = regexp.MustCompile(`^[A-Z0-9_-]+$`)
+
+// Returned by lockFile when another process holds the lock
+var errLocked = errors.New("locked by another process")
+
+// Store keeps closed candles on disk, one JSON lines file per symbol
+// - the live gRPC client appends to it, the backtester and REST API read from it
+// - every history loaded is kept parsed in memory, and only read again once its file changes other than by Append
+// - a symbol's history has one writer at a time, across processes: the first Append locks it for as long as the store is open,
+// and Import locks it while it rewrites the history, so an import fails rather than lose candles while the service is appending
+type Store struct {
+	dir string
+
+	mu     sync.Mutex
+	loaded map[string]*history // parsed history of every symbol loaded, by file path
+	locks  map[string]*os.File // lock file of every symbol this store appends to, by file path
+}
+
+// A symbol's parsed history, with the size and modification time of its file when parsed
+type history struct {
+	size     int64
+	modified time.Time
+	candles  []financeFunctions.Candlestick
+}
+
+// New opens (creating if needed) a candle store in `dir`
+func New(dir string) (*Store, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("creating candle store: %w", err)
+	}
+	return &Store{dir: dir, loaded: map[string]*history{}, locks: map[string]*os.File{}}, nil
+}
+
+// Append stores one closed candle at the end of `symbol`'s history
+func (s *Store) Append(symbol string, candle financeFunctions.Candlestick) error {
+	path, err := s.path(symbol)
+	if err != nil {
+		return err
+	}
+	line, err := json.Marshal(candle)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, err := s.lock(path); err != nil {
+		return err
+	}
+
+	// Keep the parsed history, if it is current, by adding the candle to it rather than reading the file again
+	cached := s.current(path)
+	delete(s.loaded, path)
+
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o644)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	if _, err := file.Write(append(line, '\n')); err != nil {
+		return err
+	}
+	if cached != nil {
+		if info, err := file.Stat(); err == nil {
+			s.loaded[path] = &history{size: info.Size(), modified: info.ModTime(), candles: append(cached.candles, candle)}
+		}
+	}
+	return nil
+}
+
+// Load reads every stored candle of `symbol`, oldest first
+// - a symbol with nothing stored has no candles rather than an error
+// - the candles are shared with later calls, so they must not be modified
+func (s *Store) Load(symbol string) ([]financeFunctions.Candlestick, error) {
+	path, err := s.path(symbol)
+	if err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.load(path)
+}
+
+// Helper function to read every candle of a history file, with the store locked
+func (s *Store) load(path string) ([]financeFunctions.Candlestick, error) {
+	if cached := s.current(path); cached != nil {
+		return cached.candles[:len(cached.candles):len(cached.candles)], nil
+	}
+	delete(s.loaded, path)
+	file, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	info, err := file.Stat()
+	if err != nil {
+		return nil, err
+	}
+
+	var candles []financeFunctions.Candlestick
+	scanner := bufio.NewScanner(file)
+	for line := 1; scanner.Scan(); line++ {
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+		var candle financeFunctions.Candlestick
+		if err := json.Unmarshal(scanner.Bytes(), &candle); err != nil {
+			return nil, fmt.Errorf("%s line %d: %w", path, line, err)
+		}
+		candles = append(candles, candle)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	s.loaded[path] = &history{size: info.Size(), modified: info.ModTime(), candles: candles}
+	return candles[:len(candles):len(candles)], nil
+}
+
+// Helper function to find the parsed history of a file, or nil if it was never loaded or the file has changed since, with the store locked
+func (s *Store) current(path string) *history {
+	cached, ok := s.loaded[path]
+	if !ok {
+		return nil
+	}
+	info, err := os.Stat(path)
+	if err != nil || info.Size() != cached.size || !info.ModTime().Equal(cached.modified) {
+		return nil
+	}
+	return cached
+}
+
+// Import merges `candles` into `symbol`'s history
+// - candles are keyed by open time, so importing the same data twice changes nothing
+// - imported candles replace stored ones with the same open time
+// - the history is locked from reading it to replacing it, so no candle appended in between is lost,
+// and the import fails if another process, e.g. the live service, is appending to it
+func (s *Store) Import(symbol string, candles []financeFunctions.Candlestick) error {
+	path, err := s.path(symbol)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	acquired, err := s.lock(path)
+	if err != nil {
+		return err
+	}
+	if acquired {
+		// Only a store that appends keeps the lock, so other processes may import again once this one is done
+		defer s.unlock(path)
+	}
+	existing, err := s.load(path)
+	if err != nil {
+		return err
+	}
+
+	byOpenTime := map[int64]financeFunctions.Candlestick{}
+	for _, candle := range existing {
+		byOpenTime[candle.OpenTime] = candle
+	}
+	for _, candle := range candles {
+		byOpenTime[candle.OpenTime] = candle
+	}
+	merged := make([]financeFunctions.Candlestick, 0, len(byOpenTime))
+	for _, candle := range byOpenTime {
+		merged = append(merged, candle)
+	}
+	sort.Slice(merged, func(i, j int) bool { return merged[i].OpenTime < merged[j].OpenTime })
+	delete(s.loaded, path)
+
+	// Write to a temporary file first, so a failed import never leaves a half written history
+	temporary := path + ".tmp"
+	file, err := os.Create(temporary)
+	if err != nil {
+		return err
+	}
+	writer := bufio.NewWriter(file)
+	encoder := json.NewEncoder(writer)
+	for _, candle := range merged {
+		if err := encoder.Encode(candle); err != nil {
+			file.Close()
+			return err
+		}
+	}
+	if err := writer.Flush(); err != nil {
+		file.Close()
+		return err
+	}
+	if err := file.Close(); err != nil {
+		return err
+	}
+	return os.Rename(temporary, path)
+}
+
+// Helper function to lock a history file against writers in other processes, with the store locked
+// - returns true if the lock was taken now, and false if the store already held it
+func (s *Store) lock(path string) (bool, error) {
+	if _, ok := s.locks[path]; ok {
+		return false, nil
+	}
+	file, err := os.OpenFile(path+".lock", os.O_CREATE|os.O_RDWR, 0o644)
+	if err != nil {
+		return false, err
+	}
+	if err := lockFile(file); err != nil {
+		file.Close()
+		if errors.Is(err, errLocked) {
+			return false, fmt.Errorf("%s is being written by another process, e.g. the live service appending to it, which must be stopped first", path)
+		}
+		return false, err
+	}
+	s.locks[path] = file
+	return true, nil
+}
+
+// Helper function to release the lock of a history file, with the store locked
+func (s *Store) unlock(path string) {
+	if file, ok := s.locks[path]; ok {
+		file.Close()
+		delete(s.locks, path)
+	}
+}
+
+// ValidSymbol reports whether `symbol` can have candles stored, whatever its case
+func ValidSymbol(symbol string) bool {
+	return validSymbol.MatchString(strings.ToUpper(symbol))
+}
+
+// Helper function to find the file holding `symbol`'s candles
+func (s *Store) path(symbol string) (string, error) {
+	symbol = strings.ToUpper(symbol)
+	if !ValidSymbol(symbol) {
+		return "", fmt.Errorf("invalid symbol %q", symbol)
+	}
+	return filepath.Join(s.dir, symbol+".jsonl"), nil
+}
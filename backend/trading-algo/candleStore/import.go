@@ -0,0 +1,77 @@
+package candleStore
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/neozhixuan/project-visualgo-backend/trading-algo/financeFunctions"
+)
+
+// ReadCSV reads candles in Binance's kline CSV format (as downloaded from data.binance.vision)
+// - columns are open time, open, high, low, close, volume, close time, and any further columns are ignored
+// - a header row is skipped if present
+// - times in microseconds (used by newer Binance dumps) are converted to milliseconds
+func ReadCSV(r io.Reader) ([]financeFunctions.Candlestick, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+
+	var candles []financeFunctions.Candlestick
+	for row := 1; ; row++ {
+		record, err := reader.Read()
+		if err == io.EOF {
+			return candles, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		if len(record) < 7 {
+			return nil, fmt.Errorf("row %d: expected at least 7 columns, got %d", row, len(record))
+		}
+
+		numbers := make([]float64, 7)
+		for i := range numbers {
+			numbers[i], err = strconv.ParseFloat(strings.TrimSpace(record[i]), 64)
+			if err != nil {
+				break
+			}
+		}
+		if err != nil {
+			if row == 1 {
+				continue // header
+			}
+			return nil, fmt.Errorf("row %d: %w", row, err)
+		}
+
+		candles = append(candles, financeFunctions.Candlestick{
+			OpenTime:  toMilliseconds(int64(numbers[0])),
+			Open:      numbers[1],
+			High:      numbers[2],
+			Low:       numbers[3],
+			Close:     numbers[4],
+			Volume:    numbers[5],
+			CloseTime: toMilliseconds(int64(numbers[6])),
+		})
+	}
+}
+
+// ReadJSON reads candles from a JSON array, in the same format the candle store and WebSocket server use
+func ReadJSON(r io.Reader) ([]financeFunctions.Candlestick, error) {
+	var candles []financeFunctions.Candlestick
+	if err := json.NewDecoder(r).Decode(&candles); err != nil {
+		return nil, err
+	}
+	return candles, nil
+}
+
+// Helper function to convert a microsecond timestamp to milliseconds, leaving millisecond ones alone
+// - no millisecond timestamp reaches 1e14 until the year 5138
+func toMilliseconds(timestamp int64) int64 {
+	if timestamp >= 1e14 {
+		return timestamp / 1000
+	}
+	return timestamp
+}
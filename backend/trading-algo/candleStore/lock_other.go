@@ -0,0 +1,11 @@
+//go:build !unix
+
+package candleStore
+
+import "os"
+
+// Helper function to take the lock of an open lock file
+// - only Unix systems lock files between processes, so elsewhere nothing stops an import while the service is appending
+func lockFile(file *os.File) error {
+	return nil
+}
@@ -0,0 +1,19 @@
+//go:build unix
+
+package candleStore
+
+import (
+	"errors"
+	"os"
+	"syscall"
+)
+
+// Helper function to take the lock of an open lock file without waiting, returning errLocked if another process holds it
+// - the lock is released when the file is closed, including when the process exits
+func lockFile(file *os.File) error {
+	err := syscall.Flock(int(file.Fd()), syscall.LOCK_EX|syscall.LOCK_NB)
+	if errors.Is(err, syscall.EWOULDBLOCK) {
+		return errLocked
+	}
+	return err
+}
@@ -0,0 +1,92 @@
+package candleStore
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/neozhixuan/project-visualgo-backend/trading-algo/financeFunctions"
+)
+
+// Source is where a command line tool reads its candles from: a CSV or JSON file, or the candle store
+type Source struct {
+	Symbol    string
+	StoreDir  string
+	CSVFile   string
+	JSONFile  string
+	Save      bool   // whether to import the file's candles into the candle store
+	Timeframe string // resample the candles to this timeframe, e.g. "15m", if set
+}
+
+// SourceFlags registers the flags that choose a Source, shared by the backtest and optimize commands
+func SourceFlags(flags *flag.FlagSet) *Source {
+	source := &Source{}
+	flags.StringVar(&source.Symbol, "symbol", "BNBBTC", "symbol of the candles")
+	flags.StringVar(&source.StoreDir, "store", "data", "candle store directory, read when no file is given")
+	flags.StringVar(&source.CSVFile, "csv", "", "Binance kline CSV file to read instead of the candle store")
+	flags.StringVar(&source.JSONFile, "json", "", "JSON file of candles to read instead of the candle store")
+	flags.BoolVar(&source.Save, "save", false, "import the -csv or -json candles into the candle store")
+	flags.StringVar(&source.Timeframe, "timeframe", "", "resample the candles to this timeframe first, e.g. 15m")
+	return source
+}
+
+// Load reads the candles from the file if one is given, or from the candle store otherwise, oldest first
+func (s *Source) Load() ([]financeFunctions.Candlestick, error) {
+	s.Symbol = strings.ToUpper(s.Symbol)
+	candles, err := s.read()
+	if err != nil {
+		return nil, err
+	}
+	if s.Timeframe != "" {
+		timeframe, err := financeFunctions.ParseTimeframe(s.Timeframe)
+		if err != nil {
+			return nil, err
+		}
+		candles = financeFunctions.Resample(candles, timeframe)
+	}
+	return candles, nil
+}
+
+// Helper function to read the candles before resampling
+func (s *Source) read() ([]financeFunctions.Candlestick, error) {
+	store, err := New(s.StoreDir)
+	if err != nil {
+		return nil, err
+	}
+
+	var read func(io.Reader) ([]financeFunctions.Candlestick, error)
+	path := s.CSVFile
+	switch {
+	case s.CSVFile != "" && s.JSONFile != "":
+		return nil, fmt.Errorf("give either -csv or -json, not both")
+	case s.CSVFile != "":
+		read = ReadCSV
+	case s.JSONFile != "":
+		read, path = ReadJSON, s.JSONFile
+	default:
+		return store.Load(s.Symbol)
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	candles, err := read(file)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	sort.Slice(candles, func(i, j int) bool { return candles[i].OpenTime < candles[j].OpenTime })
+
+	if s.Save {
+		if err := store.Import(s.Symbol, candles); err != nil {
+			return nil, err
+		}
+		log.Printf("Imported %d candles of %s into %s", len(candles), s.Symbol, s.StoreDir)
+	}
+	return candles, nil
+}
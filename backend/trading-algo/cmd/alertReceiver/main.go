@@ -0,0 +1,136 @@
+// AlertReceiver serves a local webhook and SMTP server that print every alert they receive, to try alert rules without real endpoints
+//
+//	go run ./cmd/alertReceiver -secret webhook-secret
+//
+// Point the trading-algo at it with ALERT_WEBHOOK_URL=http://localhost:8092/alerts, ALERT_WEBHOOK_SECRET=webhook-secret,
+// SMTP_HOST=localhost, SMTP_PORT=2525 and ALERT_EMAIL_TO=you@example.com.
+package main
+
+import (
+	"bufio"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"flag"
+	"io"
+	"log"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+)
+
+func main() {
+	httpAddr := flag.String("http", ":8092", "address the webhook listens on, at /alerts")
+	smtpAddr := flag.String("smtp", ":2525", "address the SMTP server listens on")
+	secret := flag.String("secret", "", "secret webhook bodies must be signed with, when set")
+	flag.Parse()
+
+	go serveSMTP(*smtpAddr)
+
+	// Webhook deliveries are retried, so the same alert ID can arrive more than once
+	var mu sync.Mutex
+	seen := map[string]bool{}
+	http.HandleFunc("POST /alerts", func(w http.ResponseWriter, r *http.Request) {
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if *secret != "" {
+			mac := hmac.New(sha256.New, []byte(*secret))
+			mac.Write(body)
+			if !hmac.Equal([]byte(hex.EncodeToString(mac.Sum(nil))), []byte(r.Header.Get("X-Alert-Signature"))) {
+				log.Printf("Webhook: rejected alert %s with a bad signature", r.Header.Get("X-Alert-ID"))
+				http.Error(w, "bad signature", http.StatusUnauthorized)
+				return
+			}
+		}
+
+		id := r.Header.Get("X-Alert-ID")
+		mu.Lock()
+		duplicate := seen[id]
+		seen[id] = true
+		mu.Unlock()
+		if duplicate {
+			log.Printf("Webhook: duplicate alert %s", id)
+		} else {
+			log.Printf("Webhook: %s", body)
+		}
+		w.WriteHeader(http.StatusNoContent)
+	})
+
+	log.Printf("Starting alert webhook on %s", *httpAddr)
+	log.Fatal(http.ListenAndServe(*httpAddr, nil))
+}
+
+// Helper function to accept mail on `addr`, printing every message instead of delivering it
+func serveSMTP(addr string) {
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		log.Fatalf("Could not start SMTP server: %v", err)
+	}
+	log.Printf("Starting SMTP server on %s", addr)
+	for {
+		conn, err := listener.Accept()
+		if err != nil {
+			log.Printf("Error accepting SMTP connection: %v", err)
+			continue
+		}
+		go handleSMTP(conn)
+	}
+}
+
+// Helper function to speak just enough SMTP for net/smtp.SendMail
+func handleSMTP(conn net.Conn) {
+	defer conn.Close()
+	reader := bufio.NewReader(conn)
+	reply := func(line string) {
+		io.WriteString(conn, line+"\r\n")
+	}
+
+	reply("220 localhost alertReceiver ready")
+	var from string
+	var to []string
+	for {
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			return
+		}
+		line = strings.TrimRight(line, "\r\n")
+		command := strings.ToUpper(strings.SplitN(line, " ", 2)[0])
+
+		switch command {
+		case "EHLO", "HELO":
+			reply("250 localhost")
+		case "MAIL":
+			from, to = strings.TrimPrefix(line[4:], " FROM:"), nil
+			reply("250 OK")
+		case "RCPT":
+			to = append(to, strings.TrimPrefix(line[4:], " TO:"))
+			reply("250 OK")
+		case "DATA":
+			reply("354 End data with <CR><LF>.<CR><LF>")
+			var message strings.Builder
+			for {
+				data, err := reader.ReadString('\n')
+				if err != nil {
+					return
+				}
+				if data == ".\r\n" || data == ".\n" {
+					break
+				}
+				message.WriteString(strings.TrimPrefix(data, "."))
+			}
+			log.Printf("SMTP: mail from %s to %s\n%s", from, strings.Join(to, ", "), message.String())
+			reply("250 OK")
+		case "RSET", "NOOP":
+			reply("250 OK")
+		case "QUIT":
+			reply("221 Bye")
+			return
+		default:
+			reply("502 Command not implemented")
+		}
+	}
+}
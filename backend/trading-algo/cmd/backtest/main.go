@@ -0,0 +1,88 @@
+// Backtest runs strategies over historical candles and prints a JSON report for each
+//
+// Candles come from the candle store the live service writes to, or from a Binance kline CSV / JSON file:
+//
+//	go run ./cmd/backtest -symbol BNBBTC
+//	go run ./cmd/backtest -csv BNBBTC-1m-2024-03.csv -strategies "emaCrossover:fast=12,slow=26" -timeframe 15m
+//	go run ./cmd/backtest -symbol BNBBTC -scripts examples/scripts -strategies zscoreReversion
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"io"
+	"log"
+	"os"
+	"strings"
+
+	"github.com/neozhixuan/project-visualgo-backend/trading-algo/backtest"
+	"github.com/neozhixuan/project-visualgo-backend/trading-algo/candleStore"
+	"github.com/neozhixuan/project-visualgo-backend/trading-algo/scripting"
+	"github.com/neozhixuan/project-visualgo-backend/trading-algo/strategy"
+)
+
+func main() {
+	defaults := backtest.DefaultConfig()
+	source := candleStore.SourceFlags(flag.CommandLine)
+	strategies := flag.String("strategies", "", "strategies to backtest, e.g. \"emaCrossover:fast=9,slow=21;rsiMeanReversion\" (default every strategy)")
+	scripts := flag.String("scripts", "", "directory of Starlark scripts whose strategies can be backtested too")
+	cash := flag.Float64("cash", defaults.InitialCash, "initial cash")
+	fee := flag.Float64("fee", defaults.FeeRate, "fee per fill, as a fraction of its notional")
+	slippage := flag.Float64("slippage", defaults.SlippageRate, "slippage per fill, as a fraction of its price")
+	size := flag.Float64("size", defaults.PositionSize, "fraction of equity committed to each position")
+	short := flag.Bool("short", defaults.AllowShort, "open short positions on sell signals")
+	out := flag.String("out", "", "write the report to this file instead of stdout")
+	flag.Parse()
+
+	if *scripts != "" {
+		if _, err := scripting.RegisterDir(*scripts, scripting.DefaultLimits()); err != nil {
+			log.Fatalf("Invalid -scripts: %v", err)
+		}
+	}
+	if *strategies == "" {
+		*strategies = strings.Join(strategy.Names(), ";")
+	}
+	factories, err := strategy.ParseConfig(*strategies)
+	if err != nil {
+		log.Fatalf("Invalid -strategies: %v", err)
+	}
+
+	candles, err := source.Load()
+	if err != nil {
+		log.Fatalf("Could not load candles: %v", err)
+	}
+	log.Printf("Backtesting %d candles of %s", len(candles), source.Symbol)
+
+	config := backtest.Config{
+		Symbol:       source.Symbol,
+		InitialCash:  *cash,
+		FeeRate:      *fee,
+		SlippageRate: *slippage,
+		PositionSize: *size,
+		AllowShort:   *short,
+	}
+	reports := make([]backtest.Report, 0, len(factories))
+	for _, factory := range factories {
+		report, err := backtest.Run(candles, factory, config)
+		if err != nil {
+			log.Fatalf("Backtest failed: %v", err)
+		}
+		log.Printf("%s: return %.2f%%, max drawdown %.2f%%, %d trades", report.Strategy, report.TotalReturn*100, report.MaxDrawdown*100, len(report.Trades))
+		reports = append(reports, report)
+	}
+
+	var output io.Writer = os.Stdout
+	if *out != "" {
+		file, err := os.Create(*out)
+		if err != nil {
+			log.Fatalf("Could not create %s: %v", *out, err)
+		}
+		defer file.Close()
+		output = file
+	}
+	encoder := json.NewEncoder(output)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(reports); err != nil {
+		log.Fatalf("Could not write report: %v", err)
+	}
+}
@@ -0,0 +1,45 @@
+// MockExchange serves a local stand-in for Binance's spot REST API, to try order execution without real funds
+//
+//	go run ./cmd/mockExchange -prices BNBBTC=0.0085
+//	curl -X POST "localhost:8091/mock/price?symbol=BNBBTC&price=0.0086"
+//
+// Point the trading-algo at it with EXCHANGE_BASE_URL=http://localhost:8091 and the same API key and secret.
+package main
+
+import (
+	"flag"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/neozhixuan/project-visualgo-backend/trading-algo/mockExchange"
+)
+
+func main() {
+	addr := flag.String("addr", ":8091", "address to listen on")
+	apiKey := flag.String("key", "mock-key", "API key requests must carry")
+	secret := flag.String("secret", "mock-secret", "secret requests must be signed with")
+	prices := flag.String("prices", "BNBBTC=0.0085", "starting prices, e.g. \"BNBBTC=0.0085,ETHBTC=0.05\"")
+	weightLimit := flag.Int("weight-limit", 6000, "request weight allowed per minute")
+	orderLimit := flag.Int("order-limit", 100, "orders allowed per 10 seconds")
+	flag.Parse()
+
+	server := mockExchange.NewServer(mockExchange.Config{
+		APIKey:      *apiKey,
+		Secret:      *secret,
+		WeightLimit: *weightLimit,
+		OrderLimit:  *orderLimit,
+	})
+	for _, entry := range strings.Split(*prices, ",") {
+		symbol, value, _ := strings.Cut(strings.TrimSpace(entry), "=")
+		price, err := strconv.ParseFloat(value, 64)
+		if err != nil || symbol == "" {
+			log.Fatalf("Invalid -prices entry %q: expected SYMBOL=price", entry)
+		}
+		server.SetPrice(symbol, price)
+	}
+
+	log.Printf("Starting mock exchange on %s", *addr)
+	log.Fatal(http.ListenAndServe(*addr, server))
+}
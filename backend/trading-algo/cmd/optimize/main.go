@@ -0,0 +1,104 @@
+// Optimize searches a strategy's parameters over historical candles on every CPU core, and prints the ranking as JSON
+//
+//	go run ./cmd/optimize -csv BNBBTC-1m-2024-03.csv -strategy emaCrossover -params "fast=5:20:1,slow=20:60:5"
+//	go run ./cmd/optimize -symbol BNBBTC -strategy rsiMeanReversion -params "period=7:28:1,oversold=20:35:5" -method random -samples 200 -walk-forward 4
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"io"
+	"log"
+	"os"
+
+	"github.com/neozhixuan/project-visualgo-backend/trading-algo/backtest"
+	"github.com/neozhixuan/project-visualgo-backend/trading-algo/candleStore"
+	"github.com/neozhixuan/project-visualgo-backend/trading-algo/optimize"
+	"github.com/neozhixuan/project-visualgo-backend/trading-algo/scripting"
+)
+
+func main() {
+	defaults := backtest.DefaultConfig()
+	source := candleStore.SourceFlags(flag.CommandLine)
+	strategyName := flag.String("strategy", "emaCrossover", "strategy to optimize")
+	scripts := flag.String("scripts", "", "directory of Starlark scripts whose strategies can be optimized too")
+	params := flag.String("params", "fast=5:20:1,slow=20:60:5", "parameters to search, as name=min:max:step, or name=value to fix one")
+	method := flag.String("method", "grid", "grid (every combination) or random")
+	samples := flag.Int("samples", 100, "combinations tried by a random search")
+	seed := flag.Int64("seed", 1, "seed of a random search")
+	metric := flag.String("metric", "sharpe", "metric to rank by: sharpe, sortino, totalReturn, cagr, maxDrawdown or winRate")
+	top := flag.Int("top", 20, "results kept in the ranking, 0 for all")
+	workers := flag.Int("workers", 0, "backtests run in parallel, 0 for one per CPU core")
+	windows := flag.Int("walk-forward", 0, "walk-forward windows, 0 to search all candles only")
+	inSample := flag.Float64("in-sample", 0.75, "share of each walk-forward window that is in-sample")
+	anchored := flag.Bool("anchored", false, "start every walk-forward window at the first candle")
+	cash := flag.Float64("cash", defaults.InitialCash, "initial cash")
+	fee := flag.Float64("fee", defaults.FeeRate, "fee per fill, as a fraction of its notional")
+	slippage := flag.Float64("slippage", defaults.SlippageRate, "slippage per fill, as a fraction of its price")
+	size := flag.Float64("size", defaults.PositionSize, "fraction of equity committed to each position")
+	short := flag.Bool("short", defaults.AllowShort, "open short positions on sell signals")
+	out := flag.String("out", "", "write the outcome to this file instead of stdout")
+	flag.Parse()
+
+	if *scripts != "" {
+		if _, err := scripting.RegisterDir(*scripts, scripting.DefaultLimits()); err != nil {
+			log.Fatalf("Invalid -scripts: %v", err)
+		}
+	}
+	space, err := optimize.ParseSpace(*params)
+	if err != nil {
+		log.Fatalf("Invalid -params: %v", err)
+	}
+	rankBy, err := optimize.ParseMetric(*metric)
+	if err != nil {
+		log.Fatalf("Invalid -metric: %v", err)
+	}
+	candles, err := source.Load()
+	if err != nil {
+		log.Fatalf("Could not load candles: %v", err)
+	}
+	log.Printf("Optimizing %s on %d candles of %s", *strategyName, len(candles), source.Symbol)
+
+	outcome, err := optimize.Run(candles, optimize.Config{
+		Strategy: *strategyName,
+		Space:    space,
+		Method:   optimize.Method(*method),
+		Samples:  *samples,
+		Seed:     *seed,
+		Metric:   rankBy,
+		Backtest: backtest.Config{
+			Symbol:       source.Symbol,
+			InitialCash:  *cash,
+			FeeRate:      *fee,
+			SlippageRate: *slippage,
+			PositionSize: *size,
+			AllowShort:   *short,
+		},
+		Workers:     *workers,
+		Top:         *top,
+		WalkForward: optimize.WalkForward{Windows: *windows, InSampleRatio: *inSample, Anchored: *anchored},
+	})
+	if err != nil {
+		log.Fatalf("Optimization failed: %v", err)
+	}
+	best := outcome.Results[0]
+	log.Printf("Best of %d combinations: %v with %s %.4f", outcome.Combinations, best.Params, outcome.Metric, best.Score)
+	for _, warning := range outcome.Warnings {
+		log.Printf("Warning: %s", warning)
+	}
+
+	var output io.Writer = os.Stdout
+	if *out != "" {
+		file, err := os.Create(*out)
+		if err != nil {
+			log.Fatalf("Could not create %s: %v", *out, err)
+		}
+		defer file.Close()
+		output = file
+	}
+	encoder := json.NewEncoder(output)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(outcome); err != nil {
+		log.Fatalf("Could not write outcome: %v", err)
+	}
+}
@@ -0,0 +1,257 @@
+package exchange
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// Config of a Binance spot REST client
+type Config struct {
+	BaseURL    string // e.g. "https://api.binance.com", or the mock exchange's "http://localhost:8091"
+	APIKey     string
+	Secret     string
+	Account    string        // name given to the account in order updates, e.g. "live"
+	RecvWindow time.Duration // how long after signing Binance still accepts a request
+	Timeout    time.Duration // how long to wait for an answer, after which the request's outcome is unknown
+	MaxRetries int           // attempts after the first, for requests that failed without a definite answer
+	Backoff    time.Duration // wait before the first retry, doubling after each
+	// Pause once this share of a rate limit is used, until its window resets
+	RateLimitHeadroom float64
+	WeightLimit       int // request weight allowed per minute
+	OrderLimit        int // orders allowed per 10 seconds
+}
+
+// DefaultConfig returns the limits of Binance's spot API
+func DefaultConfig() Config {
+	return Config{
+		BaseURL:           "https://api.binance.com",
+		Account:           "live",
+		RecvWindow:        5 * time.Second,
+		Timeout:           10 * time.Second,
+		MaxRetries:        3,
+		Backoff:           250 * time.Millisecond,
+		RateLimitHeadroom: 0.9,
+		WeightLimit:       6000,
+		OrderLimit:        100,
+	}
+}
+
+// APIError is an error answer from the exchange, e.g. {"code": -2010, "msg": "Account has insufficient balance for requested action."}
+type APIError struct {
+	Status  int    `json:"-"`
+	Code    int    `json:"code"`
+	Message string `json:"msg"`
+}
+
+func (e *APIError) Error() string {
+	return fmt.Sprintf("exchange returned %d: %s (code %d)", e.Status, e.Message, e.Code)
+}
+
+// Binance error codes the client reacts to
+const (
+	codeUnknownOrder   = -2013 // "Order does not exist."
+	codeRejectedOrder  = -2010 // "Duplicate order sent." among other rejections
+	duplicateOrderText = "Duplicate order sent."
+)
+
+// Client signs and sends requests to Binance's spot REST API
+// - requests that fail without a definite answer (network errors, 5xx) are retried with backoff
+// - the rate limit headers of every answer are tracked, and requests pause before a limit is hit
+type Client struct {
+	config Config
+	http   *http.Client
+
+	mu          sync.Mutex
+	pausedUntil time.Time
+}
+
+// NewClient creates a client for the exchange at config.BaseURL
+func NewClient(config Config) *Client {
+	return &Client{config: config, http: &http.Client{Timeout: config.Timeout}}
+}
+
+// Helper function to send a request, returning the body of a successful answer
+// - signed requests carry the API key, a timestamp and an HMAC-SHA256 signature of the parameters
+// - requests that are not idempotent are only retried when rate limited, other failures are left to the caller to check
+func (c *Client) do(ctx context.Context, method, path string, params url.Values, signed, idempotent bool) ([]byte, error) {
+	var lastErr error
+	for attempt := 0; attempt <= c.config.MaxRetries; attempt++ {
+		if attempt > 0 {
+			if err := sleep(ctx, c.config.Backoff<<(attempt-1)); err != nil {
+				return nil, err
+			}
+		}
+		if err := c.waitForRateLimit(ctx); err != nil {
+			return nil, err
+		}
+
+		body, err := c.send(ctx, method, path, params, signed)
+		if err == nil {
+			return body, nil
+		}
+		lastErr = err
+		if !retryable(err, idempotent) {
+			return nil, err
+		}
+		if attempt < c.config.MaxRetries {
+			log.Printf("Retrying %s %s after error: %v", method, path, err)
+		}
+	}
+	return nil, lastErr
+}
+
+// Helper function to send a request once
+func (c *Client) send(ctx context.Context, method, path string, params url.Values, signed bool) ([]byte, error) {
+	query := url.Values{}
+	for key, values := range params {
+		query[key] = values
+	}
+	if signed {
+		query.Set("timestamp", strconv.FormatInt(time.Now().UnixMilli(), 10))
+		query.Set("recvWindow", strconv.FormatInt(c.config.RecvWindow.Milliseconds(), 10))
+	}
+	encoded := query.Encode()
+	if signed {
+		encoded += "&signature=" + Sign(c.config.Secret, encoded)
+	}
+
+	request, err := http.NewRequestWithContext(ctx, method, c.config.BaseURL+path+"?"+encoded, nil)
+	if err != nil {
+		return nil, err
+	}
+	request.Header.Set("X-MBX-APIKEY", c.config.APIKey)
+
+	response, err := c.http.Do(request)
+	if err != nil {
+		return nil, &unknownOutcomeError{err}
+	}
+	defer response.Body.Close()
+	c.trackRateLimits(response)
+
+	body, err := io.ReadAll(response.Body)
+	if err != nil {
+		return nil, &unknownOutcomeError{err}
+	}
+	if response.StatusCode >= 500 {
+		return nil, &unknownOutcomeError{fmt.Errorf("exchange returned %d: %s", response.StatusCode, body)}
+	}
+	if response.StatusCode != http.StatusOK {
+		apiErr := &APIError{Status: response.StatusCode}
+		if json.Unmarshal(body, apiErr) != nil || apiErr.Message == "" {
+			apiErr.Message = string(body)
+		}
+		return nil, apiErr
+	}
+	return body, nil
+}
+
+// Sign returns the hex encoded HMAC-SHA256 of a request's parameters, as Binance expects in `signature`
+func Sign(secret, payload string) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(payload))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// Helper function to pause requests once the exchange reports a limit is nearly used up
+// - 429 and 418 answers carry Retry-After, which is always respected
+func (c *Client) trackRateLimits(response *http.Response) {
+	now := time.Now()
+	var until time.Time
+
+	if used, err := strconv.Atoi(response.Header.Get("X-MBX-USED-WEIGHT-1M")); err == nil && c.config.WeightLimit > 0 &&
+		float64(used) >= float64(c.config.WeightLimit)*c.config.RateLimitHeadroom {
+		until = now.Truncate(time.Minute).Add(time.Minute)
+	}
+	if used, err := strconv.Atoi(response.Header.Get("X-MBX-ORDER-COUNT-10S")); err == nil && c.config.OrderLimit > 0 &&
+		float64(used) >= float64(c.config.OrderLimit)*c.config.RateLimitHeadroom {
+		until = later(until, now.Truncate(10*time.Second).Add(10*time.Second))
+	}
+	if response.StatusCode == http.StatusTooManyRequests || response.StatusCode == http.StatusTeapot {
+		seconds, err := strconv.Atoi(response.Header.Get("Retry-After"))
+		if err != nil {
+			seconds = 60
+		}
+		until = later(until, now.Add(time.Duration(seconds)*time.Second))
+	}
+
+	if !until.IsZero() {
+		c.mu.Lock()
+		if until.After(c.pausedUntil) {
+			log.Printf("Exchange rate limit reached, pausing requests until %s", until.Format(time.RFC3339))
+			c.pausedUntil = until
+		}
+		c.mu.Unlock()
+	}
+}
+
+// Helper function to wait out a rate limit pause
+func (c *Client) waitForRateLimit(ctx context.Context) error {
+	c.mu.Lock()
+	wait := time.Until(c.pausedUntil)
+	c.mu.Unlock()
+	if wait <= 0 {
+		return nil
+	}
+	return sleep(ctx, wait)
+}
+
+// unknownOutcomeError is a failure after which the exchange may or may not have acted on the request
+type unknownOutcomeError struct {
+	err error
+}
+
+func (e *unknownOutcomeError) Error() string {
+	return e.err.Error()
+}
+
+func (e *unknownOutcomeError) Unwrap() error {
+	return e.err
+}
+
+// Helper function to decide whether a failed request is worth sending again
+// - rate limited requests were never processed, and failures without an answer are only resent where that is safe
+func retryable(err error, idempotent bool) bool {
+	var apiErr *APIError
+	if errors.As(err, &apiErr) {
+		return apiErr.Status == http.StatusTooManyRequests
+	}
+	return idempotent && isUnknownOutcome(err)
+}
+
+// Helper function to check whether a request failed without a definite answer
+func isUnknownOutcome(err error) bool {
+	var unknown *unknownOutcomeError
+	return errors.As(err, &unknown)
+}
+
+// Helper function to sleep unless the context ends first
+func sleep(ctx context.Context, duration time.Duration) error {
+	timer := time.NewTimer(duration)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// Helper function to get the later of two times
+func later(a, b time.Time) time.Time {
+	if b.After(a) {
+		return b
+	}
+	return a
+}
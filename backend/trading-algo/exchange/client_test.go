@@ -0,0 +1,235 @@
+package exchange_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/neozhixuan/project-visualgo-backend/trading-algo/exchange"
+	"github.com/neozhixuan/project-visualgo-backend/trading-algo/mockExchange"
+	"github.com/neozhixuan/project-visualgo-backend/trading-algo/orders"
+)
+
+const (
+	testKey    = "test-key"
+	testSecret = "test-secret"
+	testSymbol = "BNBBTC"
+	testPrice  = 0.0085
+)
+
+// Sits in front of the mock exchange, recording the requests that reach it, and letting a test answer some of them itself
+type exchangeProxy struct {
+	mock *mockExchange.Server
+
+	mu        sync.Mutex
+	forwarded map[string][]time.Time // times of the requests passed on to the mock, by method and path
+	intercept func(w http.ResponseWriter, r *http.Request, forward func(w http.ResponseWriter)) bool
+}
+
+func newExchangeProxy(config mockExchange.Config) *exchangeProxy {
+	mock := mockExchange.NewServer(config)
+	mock.SetPrice(testSymbol, testPrice)
+	return &exchangeProxy{mock: mock, forwarded: map[string][]time.Time{}}
+}
+
+func (p *exchangeProxy) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	forward := func(w http.ResponseWriter) {
+		p.mu.Lock()
+		key := r.Method + " " + r.URL.Path
+		p.forwarded[key] = append(p.forwarded[key], time.Now())
+		p.mu.Unlock()
+		p.mock.ServeHTTP(w, r)
+	}
+	p.mu.Lock()
+	intercept := p.intercept
+	p.mu.Unlock()
+	if intercept != nil && intercept(w, r, forward) {
+		return
+	}
+	forward(w)
+}
+
+// Helper function to get the times of the requests to an endpoint that reached the mock
+func (p *exchangeProxy) requests(key string) []time.Time {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return append([]time.Time(nil), p.forwarded[key]...)
+}
+
+// Helper function to start a server for `handler`, and a client of it signing with `secret`
+func newTestClient(t *testing.T, handler http.Handler, secret string) *exchange.Client {
+	t.Helper()
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+
+	config := exchange.DefaultConfig()
+	config.BaseURL = server.URL
+	config.APIKey = testKey
+	config.Secret = secret
+	config.Timeout = 200 * time.Millisecond
+	config.Backoff = 10 * time.Millisecond
+	return exchange.NewClient(config)
+}
+
+func marketBuy(clientOrderID string) orders.Request {
+	return orders.Request{ClientOrderID: clientOrderID, Symbol: testSymbol, Side: orders.Buy, Type: orders.Market, Quantity: 1}
+}
+
+func TestSignMatchesBinanceExample(t *testing.T) {
+	// The example of Binance's "SIGNED endpoint security" documentation
+	secret := "NhqPtmdSJYdKjVHjA7PZj4Mge3R5YNiP1e3UZjInClVN65XAbvqqM6A7H5fATj0j"
+	payload := "symbol=LTCBTC&side=BUY&type=LIMIT&timeInForce=GTC&quantity=1&price=0.1&recvWindow=5000&timestamp=1499827319559"
+	want := "c8db56825ae71d6d79447849e617115f4a920fa2acdcab2b053c4b2838bd6b71"
+	if got := exchange.Sign(secret, payload); got != want {
+		t.Errorf("Sign() = %s, want %s", got, want)
+	}
+}
+
+func TestClientSignsRequests(t *testing.T) {
+	proxy := newExchangeProxy(mockExchange.Config{APIKey: testKey, Secret: testSecret})
+	ctx := context.Background()
+
+	placed, err := newTestClient(t, proxy, testSecret).Place(ctx, marketBuy("signed"))
+	if err != nil {
+		t.Fatalf("Place() with the right secret: %v", err)
+	}
+	if len(placed) != 1 || placed[0].Status != orders.Filled || placed[0].FilledQuantity != 1 || placed[0].AveragePrice != testPrice {
+		t.Errorf("Place() = %+v, want one order filled for 1 at %v", placed, testPrice)
+	}
+
+	_, err = newTestClient(t, proxy, "wrong-secret").Place(ctx, marketBuy("badly-signed"))
+	var apiErr *exchange.APIError
+	if !errors.As(err, &apiErr) || apiErr.Code != -1022 {
+		t.Fatalf("Place() with the wrong secret = %v, want the exchange's invalid signature error (code -1022)", err)
+	}
+	if got := len(proxy.requests("POST /api/v3/order")); got != 2 {
+		t.Errorf("placements sent = %d, want 2, as a rejected signature is not retried", got)
+	}
+}
+
+func TestPlaceRetriesWithoutDuplicateOrders(t *testing.T) {
+	tests := []struct {
+		name string
+		// Answers the first placement, passing it on to the exchange with `forward` or not
+		fail func(proxy *exchangeProxy, w http.ResponseWriter, r *http.Request, forward func(w http.ResponseWriter))
+	}{
+		{
+			name: "placed, but the answer times out",
+			fail: func(_ *exchangeProxy, _ http.ResponseWriter, r *http.Request, forward func(w http.ResponseWriter)) {
+				forward(httptest.NewRecorder())
+				<-r.Context().Done()
+			},
+		},
+		{
+			name: "placed, but answered with a 503",
+			fail: func(proxy *exchangeProxy, w http.ResponseWriter, _ *http.Request, forward func(w http.ResponseWriter)) {
+				proxy.mock.FailNext(1)
+				forward(w)
+			},
+		},
+		{
+			name: "timed out before reaching the exchange",
+			fail: func(_ *exchangeProxy, _ http.ResponseWriter, r *http.Request, _ func(w http.ResponseWriter)) {
+				<-r.Context().Done()
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			proxy := newExchangeProxy(mockExchange.Config{APIKey: testKey, Secret: testSecret})
+			var failed atomic.Bool
+			proxy.intercept = func(w http.ResponseWriter, r *http.Request, forward func(w http.ResponseWriter)) bool {
+				if r.Method != http.MethodPost || !failed.CompareAndSwap(false, true) {
+					return false
+				}
+				test.fail(proxy, w, r, forward)
+				return true
+			}
+
+			placed, err := newTestClient(t, proxy, testSecret).Place(context.Background(), marketBuy("retried"))
+			if err != nil {
+				t.Fatalf("Place() = %v, want the order placed after retrying", err)
+			}
+			if len(placed) != 1 || placed[0].ClientOrderID != "retried" || placed[0].Status != orders.Filled {
+				t.Errorf("Place() = %+v, want the filled order with client order id \"retried\"", placed)
+			}
+			if got := len(proxy.requests("POST /api/v3/order")); got != 1 {
+				t.Errorf("placements reaching the exchange = %d, want 1", got)
+			}
+			if got := len(proxy.requests("GET /api/v3/order")); got == 0 {
+				t.Errorf("lookups by client order id = 0, want the order looked up before it is sent again")
+			}
+		})
+	}
+}
+
+func TestClientPausesWhenRateLimited(t *testing.T) {
+	proxy := newExchangeProxy(mockExchange.Config{APIKey: testKey, Secret: testSecret})
+	var limited atomic.Bool
+	limitedAt := make(chan time.Time, 1)
+	proxy.intercept = func(w http.ResponseWriter, r *http.Request, _ func(w http.ResponseWriter)) bool {
+		if !limited.CompareAndSwap(false, true) {
+			return false
+		}
+		limitedAt <- time.Now()
+		w.Header().Set("Retry-After", "1")
+		w.WriteHeader(http.StatusTooManyRequests)
+		w.Write([]byte(`{"code": -1003, "msg": "Too many requests."}`))
+		return true
+	}
+
+	placed, err := newTestClient(t, proxy, testSecret).Place(context.Background(), marketBuy("rate-limited"))
+	if err != nil {
+		t.Fatalf("Place() = %v, want the order placed once the rate limit has passed", err)
+	}
+	if len(placed) != 1 || placed[0].Status != orders.Filled {
+		t.Errorf("Place() = %+v, want one filled order", placed)
+	}
+	requests := proxy.requests("POST /api/v3/order")
+	if len(requests) != 1 {
+		t.Fatalf("placements reaching the exchange = %d, want 1", len(requests))
+	}
+	if waited := requests[0].Sub(<-limitedAt); waited < time.Second {
+		t.Errorf("placement was sent again after %v, want it paused for the 1 second of Retry-After", waited)
+	}
+}
+
+func TestClientPausesBeforeTheLimit(t *testing.T) {
+	proxy := newExchangeProxy(mockExchange.Config{APIKey: testKey, Secret: testSecret})
+	proxy.intercept = func(w http.ResponseWriter, r *http.Request, forward func(w http.ResponseWriter)) bool {
+		// Report most of the minute's weight as used, without the exchange answering 429
+		recorder := httptest.NewRecorder()
+		forward(recorder)
+		for key, values := range recorder.Header() {
+			w.Header()[key] = values
+		}
+		w.Header().Set("X-MBX-USED-WEIGHT-1M", "5500")
+		w.WriteHeader(recorder.Code)
+		w.Write(recorder.Body.Bytes())
+		return true
+	}
+
+	if time.Until(time.Now().Truncate(time.Minute).Add(time.Minute)) < time.Second {
+		t.Skip("too close to the end of the minute to tell a pause from a request")
+	}
+	client := newTestClient(t, proxy, testSecret)
+	if _, err := client.Query(context.Background(), testSymbol, "unknown"); err == nil {
+		t.Fatalf("Query() of an unknown order succeeded")
+	}
+
+	// The next request waits for the minute to end, so it is still waiting when the context ends
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if _, err := client.Place(ctx, marketBuy("paused")); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Place() = %v, want it paused until the weight resets", err)
+	}
+	if got := len(proxy.requests("POST /api/v3/order")); got != 0 {
+		t.Errorf("placements sent while paused = %d, want 0", got)
+	}
+}
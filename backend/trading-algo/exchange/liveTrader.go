@@ -0,0 +1,182 @@
+package exchange
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"math"
+	"time"
+
+	"github.com/neozhixuan/project-visualgo-backend/trading-algo/orders"
+	"github.com/neozhixuan/project-visualgo-backend/trading-algo/risk"
+	"github.com/neozhixuan/project-visualgo-backend/trading-algo/strategy"
+)
+
+// LiveConfig decides which strategy trades on the exchange, and how much
+type LiveConfig struct {
+	Strategy   string  // the one strategy whose signals are traded, as several would fight over the same account
+	Quantity   float64 // size of a position, in the base asset
+	AllowShort bool    // whether sell signals open short positions, or only close long ones
+}
+
+// LiveTrader sends a strategy's signals to the exchange as market orders
+// - signals are queued and executed one at a time, so a slow exchange never holds up the candle stream
+// - the position is tracked from the fills of its own orders, so assets already in the account are left alone
+type LiveTrader struct {
+	client   *Client
+	config   LiveConfig
+	listener func(order orders.Order)
+	signals  chan strategy.Signal
+	risk     *risk.Manager // checks every order before it is sent, if set
+
+	// Only touched by Run
+	positions     map[string]float64
+	cash          float64 // quote asset spent (negative) and received (positive) by the orders
+	day           int64   // UTC day the daily profit is counted from, in days since the Unix epoch
+	dayStartValue float64 // value of the positions and cash at the start of that day
+}
+
+// NewLiveTrader creates a LiveTrader sending every order it places to `listener`
+func NewLiveTrader(client *Client, config LiveConfig, listener func(order orders.Order)) *LiveTrader {
+	if listener == nil {
+		listener = func(orders.Order) {}
+	}
+	return &LiveTrader{
+		client:    client,
+		config:    config,
+		listener:  listener,
+		signals:   make(chan strategy.Signal, 100),
+		positions: map[string]float64{},
+	}
+}
+
+// SetRisk makes every order pass the risk checks of `manager` before it is sent
+// - it must be called before Run
+func (t *LiveTrader) SetRisk(manager *risk.Manager) {
+	t.risk = manager
+}
+
+// Submit queues a signal, ignoring those of other strategies
+func (t *LiveTrader) Submit(signal strategy.Signal) {
+	if signal.Strategy != t.config.Strategy {
+		return
+	}
+	select {
+	case t.signals <- signal:
+	default:
+		log.Printf("Warning: live trading queue is full, dropping %s signal on %s", signal.Action, signal.Symbol)
+	}
+}
+
+// Run executes queued signals until the context ends
+// - the daily profit is counted from the value of the positions when Run starts, and again from every UTC midnight
+func (t *LiveTrader) Run(ctx context.Context) {
+	t.startDay(time.Now())
+	midnight := time.NewTimer(time.Until(nextDay(t.day)))
+	defer midnight.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-midnight.C:
+			t.startDay(now)
+			midnight.Reset(time.Until(nextDay(t.day)))
+		case signal := <-t.signals:
+			if err := t.execute(ctx, signal); err != nil {
+				log.Printf("Error while live trading %s signal on %s: %v", signal.Action, signal.Symbol, err)
+			}
+		}
+	}
+}
+
+// Helper function to place the market order that moves the position to what the signal asks for
+func (t *LiveTrader) execute(ctx context.Context, signal strategy.Signal) error {
+	direction := 0.0
+	switch {
+	case signal.Action == strategy.Buy:
+		direction = 1
+	case signal.Action == strategy.Sell && t.config.AllowShort:
+		direction = -1
+	}
+
+	change := direction*t.config.Quantity - t.positions[signal.Symbol]
+	if change == 0 {
+		return nil
+	}
+	side := orders.Buy
+	if change < 0 {
+		side = orders.Sell
+	}
+
+	// The client order id comes from the signal, so a signal is never traded twice, even across retries
+	// - it holds the symbol, as a strategy may signal on several symbols at the same candle, and stays within Binance's 36 characters
+	request := orders.Request{
+		ClientOrderID: fmt.Sprintf("%.10s-%.8s-%d", signal.Strategy, signal.Symbol, signal.Time),
+		Symbol:        signal.Symbol,
+		Side:          side,
+		Type:          orders.Market,
+		Quantity:      math.Abs(change),
+		Reason:        signal.Reason,
+	}
+	if t.risk != nil {
+		exposure := risk.Exposure{Position: t.positions[signal.Symbol], DailyPnL: t.dailyPnL()}
+		if err := t.risk.Check(t.client.config.Account, request, exposure); err != nil {
+			return err
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, time.Minute)
+	defer cancel()
+	placed, err := t.client.Place(ctx, request)
+	if err != nil {
+		return err
+	}
+
+	for _, order := range placed {
+		t.positions[signal.Symbol] += order.Side.Sign() * order.FilledQuantity
+		t.cash -= order.Side.Sign() * order.FilledQuantity * order.AveragePrice
+		log.Printf("Live %s order %s for %v %s is %s", order.Side, order.ClientOrderID, order.Quantity, order.Symbol, order.Status)
+		t.listener(order)
+	}
+	return nil
+}
+
+// Helper function to get the profit of the orders since the start of the UTC day, valuing positions at the last close
+// - market orders fill straight away, so no orders are left open to count
+// - the day normally starts from Run's midnight timer, and only starts here if a signal gets in first
+func (t *LiveTrader) dailyPnL() float64 {
+	if now := time.Now(); utcDay(now) != t.day {
+		t.startDay(now)
+	}
+	return t.value() - t.dayStartValue
+}
+
+// Helper function to start counting the daily profit from the value of the positions at `now`
+func (t *LiveTrader) startDay(now time.Time) {
+	t.day, t.dayStartValue = utcDay(now), t.value()
+}
+
+// Helper function to value the positions at their last close, plus the quote asset the orders spent and received
+// - positions are only valued with a risk manager, which is the only one to need the daily profit
+func (t *LiveTrader) value() float64 {
+	value := t.cash
+	if t.risk == nil {
+		return value
+	}
+	for symbol, position := range t.positions {
+		if close, ok := t.risk.LastClose(symbol); ok {
+			value += position * close
+		}
+	}
+	return value
+}
+
+// Helper function to get the UTC day of a time, in days since the Unix epoch
+func utcDay(now time.Time) int64 {
+	return now.UnixMilli() / (24 * 60 * 60 * 1000)
+}
+
+// Helper function to find when the UTC day after `day` starts
+func nextDay(day int64) time.Time {
+	return time.UnixMilli((day + 1) * 24 * 60 * 60 * 1000)
+}
@@ -0,0 +1,62 @@
+package exchange_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/neozhixuan/project-visualgo-backend/trading-algo/exchange"
+	"github.com/neozhixuan/project-visualgo-backend/trading-algo/mockExchange"
+	"github.com/neozhixuan/project-visualgo-backend/trading-algo/orders"
+	"github.com/neozhixuan/project-visualgo-backend/trading-algo/strategy"
+)
+
+func TestLiveTraderTradesEverySignalOnce(t *testing.T) {
+	proxy := newExchangeProxy(mockExchange.Config{APIKey: testKey, Secret: testSecret})
+	proxy.mock.SetPrice("ETHBTC", 0.05)
+	placed := make(chan orders.Order, 10)
+	trader := exchange.NewLiveTrader(newTestClient(t, proxy, testSecret), exchange.LiveConfig{Strategy: "trend", Quantity: 1}, func(order orders.Order) {
+		placed <- order
+	})
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go trader.Run(ctx)
+
+	next := func() orders.Order {
+		t.Helper()
+		select {
+		case order := <-placed:
+			return order
+		case <-time.After(5 * time.Second):
+			t.Fatalf("no order was placed")
+			return orders.Order{}
+		}
+	}
+	signal := func(symbol string, action strategy.Action, at int64) strategy.Signal {
+		return strategy.Signal{Strategy: "trend", Symbol: symbol, Action: action, Price: testPrice, Time: at}
+	}
+
+	// The first placement goes through but is answered with a 503, so it is looked up rather than sent again
+	proxy.mock.FailNext(1)
+	trader.Submit(strategy.Signal{Strategy: "other", Symbol: testSymbol, Action: strategy.Buy, Time: 1})
+	trader.Submit(signal(testSymbol, strategy.Buy, 1))
+	if order := next(); order.ClientOrderID != "trend-BNBBTC-1" || order.Side != orders.Buy || order.Status != orders.Filled || order.FilledQuantity != 1 {
+		t.Errorf("order of the buy signal = %+v, want trend-BNBBTC-1 to buy 1 and be filled", order)
+	}
+
+	// A signal on another symbol at the same candle is a different order, not a duplicate
+	trader.Submit(signal("ETHBTC", strategy.Buy, 1))
+	if order := next(); order.ClientOrderID != "trend-ETHBTC-1" || order.Symbol != "ETHBTC" || order.Status != orders.Filled {
+		t.Errorf("order of the ETHBTC buy signal = %+v, want trend-ETHBTC-1 to be filled", order)
+	}
+
+	// Signals are executed in order, so the sell signal's order comes next, the second buy signal having nothing to change
+	trader.Submit(signal(testSymbol, strategy.Buy, 2))
+	trader.Submit(signal(testSymbol, strategy.Sell, 3))
+	if order := next(); order.ClientOrderID != "trend-BNBBTC-3" || order.Side != orders.Sell || order.FilledQuantity != 1 {
+		t.Errorf("order of the sell signal = %+v, want trend-BNBBTC-3 to sell the 1 bought", order)
+	}
+	if got := len(proxy.requests("POST /api/v3/order")); got != 3 {
+		t.Errorf("placements reaching the exchange = %d, want 3", got)
+	}
+}
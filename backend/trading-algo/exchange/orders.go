@@ -0,0 +1,389 @@
+package exchange
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/neozhixuan/project-visualgo-backend/trading-algo/orders"
+)
+
+// OrderResponse is an order as Binance's spot API returns it, with numbers as strings
+type OrderResponse struct {
+	Symbol              string `json:"symbol"`
+	OrderID             int64  `json:"orderId"`
+	OrderListID         int64  `json:"orderListId"` // -1 unless the order is a leg of an OCO order
+	ClientOrderID       string `json:"clientOrderId"`
+	Price               string `json:"price"`
+	OrigQty             string `json:"origQty"`
+	ExecutedQty         string `json:"executedQty"`
+	CummulativeQuoteQty string `json:"cummulativeQuoteQty"`
+	Status              string `json:"status"`
+	TimeInForce         string `json:"timeInForce"`
+	Type                string `json:"type"`
+	Side                string `json:"side"`
+	StopPrice           string `json:"stopPrice,omitempty"`
+	TrailingDelta       int64  `json:"trailingDelta,omitempty"` // in basis points
+	TransactTime        int64  `json:"transactTime,omitempty"`
+	Time                int64  `json:"time,omitempty"`
+	UpdateTime          int64  `json:"updateTime,omitempty"`
+	Fills               []Fill `json:"fills,omitempty"`
+}
+
+// Fill is one trade of an order
+type Fill struct {
+	Price           string `json:"price"`
+	Qty             string `json:"qty"`
+	Commission      string `json:"commission"`
+	CommissionAsset string `json:"commissionAsset"`
+}
+
+// OCOResponse is an OCO order as Binance's spot API returns it
+type OCOResponse struct {
+	OrderListID       int64           `json:"orderListId"`
+	ListClientOrderID string          `json:"listClientOrderId"`
+	Symbol            string          `json:"symbol"`
+	OrderReports      []OrderResponse `json:"orderReports"`
+}
+
+// Place sends an order to the exchange, returning the orders created (two for an OCO order)
+// - every order carries a client order id (generated if not given), so a placement that failed without an answer is looked up before it is sent again
+func (c *Client) Place(ctx context.Context, request orders.Request) ([]orders.Order, error) {
+	if err := request.Validate(); err != nil {
+		return nil, err
+	}
+	if request.ClientOrderID == "" {
+		request.ClientOrderID = NewClientOrderID()
+	}
+	path, params, err := orderParams(request)
+	if err != nil {
+		return nil, err
+	}
+
+	for attempt := 0; ; attempt++ {
+		body, err := c.do(ctx, http.MethodPost, path, params, true, false)
+		if err == nil {
+			return c.parsePlaced(request, body)
+		}
+		if !isUnknownOutcome(err) && !isDuplicate(err) {
+			return nil, err
+		}
+
+		// The order may have reached the exchange, so look for it before sending it again
+		placed, found, lookupErr := c.findPlaced(ctx, request)
+		switch {
+		case found:
+			return placed, nil
+		case lookupErr != nil:
+			return nil, fmt.Errorf("%v, and could not check whether the order was placed: %w", err, lookupErr)
+		case isDuplicate(err) || attempt >= c.config.MaxRetries:
+			return nil, err
+		}
+		if err := sleep(ctx, c.config.Backoff<<attempt); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// Cancel cancels an open order by its client order id, which cancels both legs of an OCO order
+func (c *Client) Cancel(ctx context.Context, symbol, clientOrderID string) (orders.Order, error) {
+	params := url.Values{"symbol": {symbol}, "origClientOrderId": {clientOrderID}}
+	body, err := c.do(ctx, http.MethodDelete, "/api/v3/order", params, true, true)
+	if err != nil {
+		return orders.Order{}, err
+	}
+	return c.parseOrder(body)
+}
+
+// Query returns an order by its client order id
+func (c *Client) Query(ctx context.Context, symbol, clientOrderID string) (orders.Order, error) {
+	params := url.Values{"symbol": {symbol}, "origClientOrderId": {clientOrderID}}
+	body, err := c.do(ctx, http.MethodGet, "/api/v3/order", params, true, true)
+	if err != nil {
+		return orders.Order{}, err
+	}
+	return c.parseOrder(body)
+}
+
+// OpenOrders returns every open order of `symbol`
+func (c *Client) OpenOrders(ctx context.Context, symbol string) ([]orders.Order, error) {
+	body, err := c.do(ctx, http.MethodGet, "/api/v3/openOrders", url.Values{"symbol": {symbol}}, true, true)
+	if err != nil {
+		return nil, err
+	}
+	var responses []OrderResponse
+	if err := json.Unmarshal(body, &responses); err != nil {
+		return nil, err
+	}
+	open := make([]orders.Order, 0, len(responses))
+	for _, response := range responses {
+		open = append(open, response.ToOrder(c.config.Account))
+	}
+	return open, nil
+}
+
+// NewClientOrderID returns a random client order id, within Binance's limit of 36 characters
+func NewClientOrderID() string {
+	random := make([]byte, 12)
+	rand.Read(random)
+	return "va-" + hex.EncodeToString(random)
+}
+
+// ToOrder converts an exchange order to the order type shared with paper trading
+func (r OrderResponse) ToOrder(account string) orders.Order {
+	order := orders.Order{
+		Request: orders.Request{
+			ClientOrderID: r.ClientOrderID,
+			Symbol:        r.Symbol,
+			Side:          orders.Side(strings.ToLower(r.Side)),
+			Quantity:      parseNumber(r.OrigQty),
+			StopPrice:     parseNumber(r.StopPrice),
+			TrailingDelta: float64(r.TrailingDelta) / 10000,
+		},
+		ID:             strconv.FormatInt(r.OrderID, 10),
+		Account:        account,
+		Status:         statusFromBinance[r.Status],
+		FilledQuantity: parseNumber(r.ExecutedQty),
+		CreatedAt:      firstNonZero(r.Time, r.TransactTime),
+		UpdatedAt:      firstNonZero(r.UpdateTime, r.TransactTime, r.Time),
+	}
+	if r.OrderListID >= 0 {
+		order.OCOGroup = strconv.FormatInt(r.OrderListID, 10)
+	}
+
+	switch r.Type {
+	case "MARKET":
+		order.Type = orders.Market
+	case "LIMIT", "LIMIT_MAKER":
+		order.Type = orders.Limit
+	case "STOP_LOSS":
+		order.Type = orders.Stop
+		if r.TrailingDelta > 0 {
+			order.Type = orders.TrailingStop
+		}
+	case "STOP_LOSS_LIMIT":
+		order.Type = orders.StopLimit
+	default:
+		order.Type = orders.Type(strings.ToLower(r.Type))
+	}
+	if order.Type == orders.Limit || order.Type == orders.StopLimit {
+		order.LimitPrice = parseNumber(r.Price)
+	}
+
+	if order.FilledQuantity > 0 {
+		order.AveragePrice = parseNumber(r.CummulativeQuoteQty) / order.FilledQuantity
+	}
+	for _, fill := range r.Fills {
+		order.Fee += parseNumber(fill.Commission)
+	}
+	return order
+}
+
+// StatusToBinance is how Binance names the status of an order
+var StatusToBinance = map[orders.Status]string{
+	orders.Open:            "NEW",
+	orders.Triggered:       "NEW",
+	orders.PartiallyFilled: "PARTIALLY_FILLED",
+	orders.Filled:          "FILLED",
+	orders.Canceled:        "CANCELED",
+	orders.Rejected:        "REJECTED",
+}
+
+var statusFromBinance = map[string]orders.Status{
+	"NEW":              orders.Open,
+	"PARTIALLY_FILLED": orders.PartiallyFilled,
+	"FILLED":           orders.Filled,
+	"CANCELED":         orders.Canceled,
+	"PENDING_CANCEL":   orders.Canceled,
+	"EXPIRED":          orders.Canceled,
+	"REJECTED":         orders.Rejected,
+}
+
+// Helper function to turn an order into the endpoint and parameters that place it on Binance
+func orderParams(request orders.Request) (string, url.Values, error) {
+	if request.Type == orders.OCO {
+		return ocoParams(request)
+	}
+
+	params := url.Values{
+		"symbol":           {request.Symbol},
+		"side":             {strings.ToUpper(string(request.Side))},
+		"quantity":         {formatNumber(request.Quantity)},
+		"newClientOrderId": {request.ClientOrderID},
+		"newOrderRespType": {"FULL"},
+	}
+	switch request.Type {
+	case orders.Market:
+		params.Set("type", "MARKET")
+	case orders.Limit:
+		params.Set("type", "LIMIT")
+		params.Set("timeInForce", "GTC")
+		params.Set("price", formatNumber(request.LimitPrice))
+	case orders.Stop:
+		params.Set("type", "STOP_LOSS")
+		params.Set("stopPrice", formatNumber(request.StopPrice))
+	case orders.StopLimit:
+		params.Set("type", "STOP_LOSS_LIMIT")
+		params.Set("timeInForce", "GTC")
+		params.Set("price", formatNumber(request.LimitPrice))
+		params.Set("stopPrice", formatNumber(request.StopPrice))
+	case orders.TrailingStop:
+		delta, err := trailingDeltaBips(request.TrailingDelta)
+		if err != nil {
+			return "", nil, err
+		}
+		params.Set("type", "STOP_LOSS")
+		params.Set("trailingDelta", delta)
+	}
+	return "/api/v3/order", params, nil
+}
+
+// Helper function to turn an OCO order into Binance's parameters, which need a limit leg and a stop leg
+func ocoParams(request orders.Request) (string, url.Values, error) {
+	limit, stop := request.Legs[0], request.Legs[1]
+	if limit.Type != orders.Limit {
+		limit, stop = stop, limit
+	}
+	if limit.Type != orders.Limit || stop.Type == orders.Limit {
+		return "", nil, fmt.Errorf("exchange oco orders need one limit leg and one stop leg")
+	}
+	if limit.Quantity != stop.Quantity {
+		return "", nil, fmt.Errorf("exchange oco orders need both legs to have the same quantity")
+	}
+
+	params := url.Values{
+		"symbol":             {limit.Symbol},
+		"side":               {strings.ToUpper(string(limit.Side))},
+		"quantity":           {formatNumber(limit.Quantity)},
+		"price":              {formatNumber(limit.LimitPrice)},
+		"listClientOrderId":  {request.ClientOrderID},
+		"limitClientOrderId": {legClientOrderID(request, limit, 1)},
+		"stopClientOrderId":  {legClientOrderID(request, stop, 2)},
+		"newOrderRespType":   {"FULL"},
+	}
+	switch stop.Type {
+	case orders.Stop:
+		params.Set("stopPrice", formatNumber(stop.StopPrice))
+	case orders.StopLimit:
+		params.Set("stopPrice", formatNumber(stop.StopPrice))
+		params.Set("stopLimitPrice", formatNumber(stop.LimitPrice))
+		params.Set("stopLimitTimeInForce", "GTC")
+	case orders.TrailingStop:
+		delta, err := trailingDeltaBips(stop.TrailingDelta)
+		if err != nil {
+			return "", nil, err
+		}
+		params.Set("trailingDelta", delta)
+	}
+	return "/api/v3/order/oco", params, nil
+}
+
+// Helper function to read the answer to a placement
+func (c *Client) parsePlaced(request orders.Request, body []byte) ([]orders.Order, error) {
+	var placed []orders.Order
+	if request.Type == orders.OCO {
+		var response OCOResponse
+		if err := json.Unmarshal(body, &response); err != nil {
+			return nil, err
+		}
+		for _, report := range response.OrderReports {
+			placed = append(placed, report.ToOrder(c.config.Account))
+		}
+	} else {
+		order, err := c.parseOrder(body)
+		if err != nil {
+			return nil, err
+		}
+		placed = append(placed, order)
+	}
+
+	for i := range placed {
+		placed[i].Reason = request.Reason
+	}
+	return placed, nil
+}
+
+// Helper function to read a single order answer
+func (c *Client) parseOrder(body []byte) (orders.Order, error) {
+	var response OrderResponse
+	if err := json.Unmarshal(body, &response); err != nil {
+		return orders.Order{}, err
+	}
+	return response.ToOrder(c.config.Account), nil
+}
+
+// Helper function to look up the orders of a placement, reporting whether the exchange has them
+func (c *Client) findPlaced(ctx context.Context, request orders.Request) ([]orders.Order, bool, error) {
+	symbol, clientOrderIDs := request.Symbol, []string{request.ClientOrderID}
+	if request.Type == orders.OCO {
+		symbol = request.Legs[0].Symbol
+		clientOrderIDs = []string{legClientOrderID(request, request.Legs[0], 1), legClientOrderID(request, request.Legs[1], 2)}
+		if request.Legs[0].Type != orders.Limit {
+			clientOrderIDs[0], clientOrderIDs[1] = legClientOrderID(request, request.Legs[1], 1), legClientOrderID(request, request.Legs[0], 2)
+		}
+	}
+
+	var placed []orders.Order
+	for _, clientOrderID := range clientOrderIDs {
+		order, err := c.Query(ctx, symbol, clientOrderID)
+		var apiErr *APIError
+		if errors.As(err, &apiErr) && apiErr.Code == codeUnknownOrder {
+			return nil, false, nil
+		}
+		if err != nil {
+			return nil, false, err
+		}
+		order.Reason = request.Reason
+		placed = append(placed, order)
+	}
+	return placed, true, nil
+}
+
+// Helper function to name an OCO leg, "<list id>-1" for the limit leg and "<list id>-2" for the stop leg unless given
+func legClientOrderID(request orders.Request, leg orders.Request, number int) string {
+	if leg.ClientOrderID != "" {
+		return leg.ClientOrderID
+	}
+	return fmt.Sprintf("%s-%d", request.ClientOrderID, number)
+}
+
+// Helper function to check whether the exchange already has an order with the same client order id
+func isDuplicate(err error) bool {
+	var apiErr *APIError
+	return errors.As(err, &apiErr) && apiErr.Code == codeRejectedOrder && apiErr.Message == duplicateOrderText
+}
+
+// Helper function to convert a trailing delta fraction to the basis points Binance expects (10 to 2000)
+func trailingDeltaBips(delta float64) (string, error) {
+	bips := int(math.Round(delta * 10000))
+	if bips < 10 || bips > 2000 {
+		return "", fmt.Errorf("exchange trailing stops need a trailing delta between 0.001 and 0.2, got %v", delta)
+	}
+	return strconv.Itoa(bips), nil
+}
+
+func formatNumber(x float64) string {
+	return strconv.FormatFloat(x, 'f', -1, 64)
+}
+
+func parseNumber(s string) float64 {
+	x, _ := strconv.ParseFloat(s, 64)
+	return x
+}
+
+func firstNonZero(values ...int64) int64 {
+	for _, value := range values {
+		if value != 0 {
+			return value
+		}
+	}
+	return 0
+}
@@ -1,37 +1,90 @@
 package financeFunctions
 
+import "math"
+
 type Candlestick struct {
-	Open, High, Low, Close float64
-	Volume                 float64
+	Open      float64 `json:"open"`
+	High      float64 `json:"high"`
+	Low       float64 `json:"low"`
+	Close     float64 `json:"close"`
+	Volume    float64 `json:"volume"`
+	OpenTime  int64   `json:"openTime"`  // Unix milliseconds, as sent by data-ingest
+	CloseTime int64   `json:"closeTime"` // Unix milliseconds, as sent by data-ingest
 }
 
+// EMASeed chooses how the first value of an EMA is produced
+type EMASeed int
+
+const (
+	SeedFirstClose EMASeed = iota // start from the first close
+	SeedSMA                       // start from the simple average of the first `period` closes
+)
+
 // CalculateEMA calculates the Exponential Moving Average (EMA)
-func CalculateEMA(candlesticks []Candlestick, period int) []float64 {
-	k := 2 / float64(period+1)
-	prices := extractClosingPrices(candlesticks)
-	ema := make([]float64, len(prices))
-	ema[0] = prices[0]
+// - with either seed, values are only valid once `period` candles have been seen
+func CalculateEMA(candlesticks []Candlestick, period int, seed EMASeed) IndicatorLine {
+	candlesticks, usable := sanitizeCandlesticks(candlesticks)
+	ema := emaOf(extractClosingPrices(candlesticks), period, seed)
+	return newLine("ema", ema, warmedUp(usable, period-1), 0)
+}
 
-	for i := 1; i < len(prices); i++ {
-		ema[i] = prices[i]*k + ema[i-1]*(1-k)
+// CalculateSMA calculates the Simple Moving Average (SMA) of closes
+// - values are valid once `period` candles have been seen
+func CalculateSMA(candlesticks []Candlestick, period int) IndicatorLine {
+	candlesticks, usable := sanitizeCandlesticks(candlesticks)
+	sma := nanSlice(len(candlesticks))
+	if period <= 0 {
+		return newLine("sma", sma, usable, 0)
 	}
-	return ema
+
+	sum := 0.0
+	for i, candle := range candlesticks {
+		sum += candle.Close
+		if i >= period {
+			sum -= candlesticks[i-period].Close
+		}
+		if i >= period-1 {
+			sma[i] = sum / float64(period)
+		}
+	}
+	return newLine("sma", sma, warmedUp(usable, period-1), 0)
 }
 
-// CalculateVWAP calculates the Volume Weighted Average Price (VWAP)
-func CalculateVWAP(candlesticks []Candlestick) []float64 {
-	vwap := make([]float64, len(candlesticks))
-	cumulativePriceVolume := 0.0
-	cumulativeVolume := 0.0
+// CalculateVWAP calculates the Volume Weighted Average Price (VWAP) from the first candle
+// - values are NaN until some volume has traded
+// - use CalculateSessionVWAP or CalculateAnchoredVWAP to reset the accumulation
+func CalculateVWAP(candlesticks []Candlestick) IndicatorLine {
+	return accumulateVWAP(candlesticks, 0, func(Candlestick) bool { return false }, nil)[0]
+}
 
-	for i, candle := range candlesticks {
-		typicalPrice := (candle.High + candle.Low + candle.Close) / 3
-		priceVolume := typicalPrice * candle.Volume
-		cumulativePriceVolume += priceVolume
-		cumulativeVolume += candle.Volume
-		vwap[i] = cumulativePriceVolume / cumulativeVolume
+// Helper function to calculate the EMA of a price series
+func emaOf(prices []float64, period int, seed EMASeed) []float64 {
+	ema := nanSlice(len(prices))
+	if period <= 0 || len(prices) == 0 {
+		return ema
+	}
+	k := 2 / float64(period+1)
+
+	start := 0
+	switch seed {
+	case SeedSMA:
+		if len(prices) < period {
+			return ema
+		}
+		sum := 0.0
+		for _, price := range prices[:period] {
+			sum += price
+		}
+		start = period - 1
+		ema[start] = sum / float64(period)
+	default:
+		ema[0] = prices[0]
 	}
-	return vwap
+
+	for i := start + 1; i < len(prices); i++ {
+		ema[i] = prices[i]*k + ema[i-1]*(1-k)
+	}
+	return ema
 }
 
 // Helper function to extract closing prices from candlesticks
@@ -42,3 +95,64 @@ func extractClosingPrices(candlesticks []Candlestick) []float64 {
 	}
 	return prices
 }
+
+// Helper function to replace unusable candlesticks, so calculations never see NaN or missing prices
+// - a bad candle becomes a flat, zero volume candle at the last good close
+// - bad candles before the first good one become flat at its open
+// - the returned mask is false for every replaced candle
+func sanitizeCandlesticks(candlesticks []Candlestick) ([]Candlestick, []bool) {
+	clean := make([]Candlestick, len(candlesticks))
+	usable := make([]bool, len(candlesticks))
+	last := -1
+
+	for i, candle := range candlesticks {
+		if isUsable(candle) {
+			if last < 0 {
+				for j := 0; j < i; j++ {
+					clean[j] = flatCandlestick(candlesticks[j], candle.Open)
+				}
+			}
+			clean[i] = candle
+			usable[i] = true
+			last = i
+			continue
+		}
+		if last >= 0 {
+			clean[i] = flatCandlestick(candle, clean[last].Close)
+		}
+	}
+
+	// With no good candle at all, the leading replacements above never ran
+	if last < 0 {
+		for i, candle := range candlesticks {
+			clean[i] = flatCandlestick(candle, math.NaN())
+		}
+	}
+	return clean, usable
+}
+
+// Helper function to check that a candlestick holds real, consistent prices
+// - data-ingest sends 0 when it cannot parse a price, so non-positive prices are rejected too
+func isUsable(candle Candlestick) bool {
+	for _, price := range []float64{candle.Open, candle.High, candle.Low, candle.Close} {
+		if math.IsNaN(price) || math.IsInf(price, 0) || price <= 0 {
+			return false
+		}
+	}
+	if math.IsNaN(candle.Volume) || math.IsInf(candle.Volume, 0) || candle.Volume < 0 {
+		return false
+	}
+	return candle.High >= candle.Low
+}
+
+// Helper function to create a zero volume candlestick where every price is `price`
+func flatCandlestick(candle Candlestick, price float64) Candlestick {
+	return Candlestick{
+		Open:      price,
+		High:      price,
+		Low:       price,
+		Close:     price,
+		OpenTime:  candle.OpenTime,
+		CloseTime: candle.CloseTime,
+	}
+}
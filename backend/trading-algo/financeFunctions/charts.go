@@ -0,0 +1,390 @@
+package financeFunctions
+
+import "math"
+
+// Tick is a single traded price
+type Tick struct {
+	Price    float64 `json:"price"`
+	Quantity float64 `json:"quantity,omitempty"` // only needed for volume profiles
+	Time     int64   `json:"time"`               // Unix milliseconds
+}
+
+// CalculateHeikinAshi converts candlesticks into Heikin-Ashi candlesticks
+// - bad candles are replaced by flat candles at the last good close
+func CalculateHeikinAshi(candlesticks []Candlestick) []Candlestick {
+	candlesticks, _ = sanitizeCandlesticks(candlesticks)
+	builder := &HeikinAshiBuilder{}
+	heikinAshi := make([]Candlestick, 0, len(candlesticks))
+	for _, candle := range candlesticks {
+		if ha, ok := builder.Add(candle); ok {
+			heikinAshi = append(heikinAshi, ha)
+		}
+	}
+	return heikinAshi
+}
+
+// HeikinAshiBuilder converts a stream of closed candlesticks into Heikin-Ashi candlesticks
+type HeikinAshiBuilder struct {
+	previous Candlestick
+	started  bool
+}
+
+// Add converts the next closed candlestick, returning false if it has bad data
+func (b *HeikinAshiBuilder) Add(candle Candlestick) (Candlestick, bool) {
+	if !isUsable(candle) {
+		return Candlestick{}, false
+	}
+
+	ha := Candlestick{
+		Close:     (candle.Open + candle.High + candle.Low + candle.Close) / 4,
+		Volume:    candle.Volume,
+		OpenTime:  candle.OpenTime,
+		CloseTime: candle.CloseTime,
+	}
+	if b.started {
+		ha.Open = (b.previous.Open + b.previous.Close) / 2
+	} else {
+		ha.Open = (candle.Open + candle.Close) / 2
+	}
+	ha.High = math.Max(candle.High, math.Max(ha.Open, ha.Close))
+	ha.Low = math.Min(candle.Low, math.Min(ha.Open, ha.Close))
+
+	b.previous = ha
+	b.started = true
+	return ha, true
+}
+
+// RenkoBrick is one brick of a Renko chart
+type RenkoBrick struct {
+	Open      float64 `json:"open"`
+	Close     float64 `json:"close"`
+	Rising    bool    `json:"rising"`
+	OpenTime  int64   `json:"openTime"`  // when the previous brick completed
+	CloseTime int64   `json:"closeTime"` // when the price completed this brick
+}
+
+// RenkoBoxSizeFromATR returns the latest valid ATR, to size Renko bricks (or Point & Figure boxes) to volatility
+// - returns 0 when there are not enough candles yet
+func RenkoBoxSizeFromATR(candlesticks []Candlestick, period int) float64 {
+	atr := CalculateATR(candlesticks, period)
+	for i := len(atr.Values) - 1; i >= 0; i-- {
+		if atr.Valid[i] {
+			return atr.Values[i]
+		}
+	}
+	return 0
+}
+
+// CalculateRenko builds Renko bricks of `boxSize` from closing prices
+func CalculateRenko(candlesticks []Candlestick, boxSize float64) []RenkoBrick {
+	return RenkoFromTicks(ticksFromCloses(candlesticks), boxSize)
+}
+
+// RenkoFromTicks builds Renko bricks of `boxSize` from traded prices
+func RenkoFromTicks(ticks []Tick, boxSize float64) []RenkoBrick {
+	builder := NewRenkoBuilder(boxSize)
+	var bricks []RenkoBrick
+	for _, tick := range ticks {
+		bricks = append(bricks, builder.Add(tick)...)
+	}
+	return bricks
+}
+
+// RenkoBuilder builds Renko bricks from a stream of prices
+// - a brick is added each time price moves one box beyond the last brick
+// - reversing direction takes a two box move, since the new brick starts from the last brick's open
+type RenkoBuilder struct {
+	boxSize   float64
+	base      float64 // close of the last brick, or the first price
+	direction int     // 1 after a rising brick, -1 after a falling one, 0 before the first brick
+	lastTime  int64
+	started   bool
+}
+
+// NewRenkoBuilder creates a RenkoBuilder with fixed bricks of `boxSize`
+func NewRenkoBuilder(boxSize float64) *RenkoBuilder {
+	return &RenkoBuilder{boxSize: boxSize}
+}
+
+// Add feeds the next price in, returning the bricks it completes
+func (b *RenkoBuilder) Add(tick Tick) []RenkoBrick {
+	if !usablePrice(tick.Price) || b.boxSize <= 0 {
+		return nil
+	}
+	if !b.started {
+		b.base, b.lastTime, b.started = tick.Price, tick.Time, true
+		return nil
+	}
+
+	var bricks []RenkoBrick
+	for {
+		var open, close float64
+		switch box := b.boxSize; {
+		case b.direction >= 0 && tick.Price >= b.base+box:
+			open, close = b.base, b.base+box
+		case b.direction <= 0 && tick.Price <= b.base-box:
+			open, close = b.base, b.base-box
+		case b.direction > 0 && tick.Price <= b.base-2*box:
+			open, close = b.base-box, b.base-2*box
+		case b.direction < 0 && tick.Price >= b.base+2*box:
+			open, close = b.base+box, b.base+2*box
+		default:
+			return bricks
+		}
+
+		brick := RenkoBrick{Open: open, Close: close, Rising: close > open, OpenTime: b.lastTime, CloseTime: tick.Time}
+		bricks = append(bricks, brick)
+		b.base, b.lastTime = close, tick.Time
+		b.direction = 1
+		if !brick.Rising {
+			b.direction = -1
+		}
+	}
+}
+
+// KagiLine is one vertical line of a Kagi chart
+// - Thick (yang) lines start when price breaks above the previous shoulder, thin (yin) lines when it breaks below the previous waist
+type KagiLine struct {
+	Start     float64 `json:"start"`
+	End       float64 `json:"end"`
+	Rising    bool    `json:"rising"`
+	Thick     bool    `json:"thick"`
+	StartTime int64   `json:"startTime"`
+	EndTime   int64   `json:"endTime"`
+}
+
+// CalculateKagi builds a Kagi chart from closing prices, turning whenever price reverses by `reversal`
+func CalculateKagi(candlesticks []Candlestick, reversal float64) []KagiLine {
+	return KagiFromTicks(ticksFromCloses(candlesticks), reversal)
+}
+
+// KagiFromTicks builds a Kagi chart from traded prices, turning whenever price reverses by `reversal`
+func KagiFromTicks(ticks []Tick, reversal float64) []KagiLine {
+	builder := NewKagiBuilder(reversal)
+	for _, tick := range ticks {
+		builder.Add(tick)
+	}
+	return builder.Lines()
+}
+
+// KagiBuilder builds a Kagi chart from a stream of prices
+type KagiBuilder struct {
+	reversal float64
+	lines    []KagiLine
+	first    Tick
+	started  bool
+}
+
+// NewKagiBuilder creates a KagiBuilder that turns whenever price reverses by `reversal`
+func NewKagiBuilder(reversal float64) *KagiBuilder {
+	return &KagiBuilder{reversal: reversal}
+}
+
+// Add feeds the next price in, returning true if the chart changed
+func (b *KagiBuilder) Add(tick Tick) bool {
+	if !usablePrice(tick.Price) || b.reversal <= 0 {
+		return false
+	}
+	if !b.started {
+		b.first, b.started = tick, true
+		return false
+	}
+
+	// The first line is drawn once price has moved far enough from the first price
+	if len(b.lines) == 0 {
+		if math.Abs(tick.Price-b.first.Price) < b.reversal {
+			return false
+		}
+		rising := tick.Price > b.first.Price
+		b.lines = append(b.lines, KagiLine{
+			Start:     b.first.Price,
+			End:       tick.Price,
+			Rising:    rising,
+			Thick:     rising,
+			StartTime: b.first.Time,
+			EndTime:   tick.Time,
+		})
+		return true
+	}
+
+	last := &b.lines[len(b.lines)-1]
+	switch {
+	case last.Rising && tick.Price > last.End, !last.Rising && tick.Price < last.End:
+		// Price carries on in the same direction, so the line gets longer
+		last.End, last.EndTime = tick.Price, tick.Time
+	case last.Rising && last.End-tick.Price >= b.reversal, !last.Rising && tick.Price-last.End >= b.reversal:
+		// Price has reversed far enough to turn the line
+		b.lines = append(b.lines, KagiLine{
+			Start:     last.End,
+			End:       tick.Price,
+			Rising:    !last.Rising,
+			Thick:     last.Thick,
+			StartTime: last.EndTime,
+			EndTime:   tick.Time,
+		})
+	default:
+		return false
+	}
+
+	b.updateThickness()
+	return true
+}
+
+// Lines returns every line drawn so far
+func (b *KagiBuilder) Lines() []KagiLine {
+	return append([]KagiLine(nil), b.lines...)
+}
+
+// Trim drops all but the last `keep` lines, so a builder fed for a long time holds a bounded chart
+func (b *KagiBuilder) Trim(keep int) {
+	b.lines = b.lines[max(len(b.lines)-max(keep, 2), 0):]
+}
+
+// Helper function to switch the last line between yang and yin
+// - the line before the last one starts at the previous shoulder (for a rising line) or waist (for a falling line)
+func (b *KagiBuilder) updateThickness() {
+	if len(b.lines) < 2 {
+		return
+	}
+	last := &b.lines[len(b.lines)-1]
+	previous := b.lines[len(b.lines)-2]
+	if last.Rising && last.End > previous.Start {
+		last.Thick = true
+	}
+	if !last.Rising && last.End < previous.Start {
+		last.Thick = false
+	}
+}
+
+// PointAndFigureColumn is one column of X's (rising) or O's (falling) in a Point & Figure chart
+// - High and Low are the price levels of the top and bottom boxes
+type PointAndFigureColumn struct {
+	Rising    bool    `json:"rising"`
+	High      float64 `json:"high"`
+	Low       float64 `json:"low"`
+	Boxes     int     `json:"boxes"`
+	StartTime int64   `json:"startTime"`
+	EndTime   int64   `json:"endTime"`
+}
+
+// CalculatePointAndFigure builds a Point & Figure chart from closing prices
+// - a new column starts after a reversal of `reversalBoxes` boxes
+func CalculatePointAndFigure(candlesticks []Candlestick, boxSize float64, reversalBoxes int) []PointAndFigureColumn {
+	return PointAndFigureFromTicks(ticksFromCloses(candlesticks), boxSize, reversalBoxes)
+}
+
+// PointAndFigureFromTicks builds a Point & Figure chart from traded prices
+// - a new column starts after a reversal of `reversalBoxes` boxes
+func PointAndFigureFromTicks(ticks []Tick, boxSize float64, reversalBoxes int) []PointAndFigureColumn {
+	builder := NewPointAndFigureBuilder(boxSize, reversalBoxes)
+	for _, tick := range ticks {
+		builder.Add(tick)
+	}
+	return builder.Columns()
+}
+
+// PointAndFigureBuilder builds a Point & Figure chart from a stream of prices
+type PointAndFigureBuilder struct {
+	boxSize       float64
+	reversalBoxes int
+	columns       []PointAndFigureColumn
+	anchor        Tick // first price, snapped to the box grid
+	started       bool
+}
+
+// NewPointAndFigureBuilder creates a PointAndFigureBuilder with boxes of `boxSize` and a `reversalBoxes` box reversal
+func NewPointAndFigureBuilder(boxSize float64, reversalBoxes int) *PointAndFigureBuilder {
+	if reversalBoxes < 1 {
+		reversalBoxes = 1
+	}
+	return &PointAndFigureBuilder{boxSize: boxSize, reversalBoxes: reversalBoxes}
+}
+
+// Add feeds the next price in, returning true if the chart changed
+func (b *PointAndFigureBuilder) Add(tick Tick) bool {
+	if !usablePrice(tick.Price) || b.boxSize <= 0 {
+		return false
+	}
+	box := b.boxSize
+	if !b.started {
+		b.anchor = Tick{Price: b.boxBelow(tick.Price), Time: tick.Time}
+		b.started = true
+		return false
+	}
+
+	// The first column is drawn once price has moved a whole box from the first price
+	if len(b.columns) == 0 {
+		switch {
+		case tick.Price >= b.anchor.Price+box:
+			b.columns = append(b.columns, PointAndFigureColumn{Rising: true, Low: b.anchor.Price, High: b.boxBelow(tick.Price), StartTime: b.anchor.Time})
+		case tick.Price <= b.anchor.Price-box:
+			b.columns = append(b.columns, PointAndFigureColumn{Rising: false, High: b.anchor.Price, Low: b.boxAbove(tick.Price), StartTime: b.anchor.Time})
+		default:
+			return false
+		}
+		b.finishColumn(tick)
+		return true
+	}
+
+	last := &b.columns[len(b.columns)-1]
+	reversal := float64(b.reversalBoxes) * box
+	switch {
+	case last.Rising && tick.Price >= last.High+box:
+		last.High = b.boxBelow(tick.Price)
+	case !last.Rising && tick.Price <= last.Low-box:
+		last.Low = b.boxAbove(tick.Price)
+	case last.Rising && tick.Price <= last.High-reversal:
+		// A column of O's starts one box below the top X
+		b.columns = append(b.columns, PointAndFigureColumn{Rising: false, High: last.High - box, Low: b.boxAbove(tick.Price), StartTime: tick.Time})
+	case !last.Rising && tick.Price >= last.Low+reversal:
+		// A column of X's starts one box above the bottom O
+		b.columns = append(b.columns, PointAndFigureColumn{Rising: true, Low: last.Low + box, High: b.boxBelow(tick.Price), StartTime: tick.Time})
+	default:
+		return false
+	}
+	b.finishColumn(tick)
+	return true
+}
+
+// Columns returns every column drawn so far
+func (b *PointAndFigureBuilder) Columns() []PointAndFigureColumn {
+	return append([]PointAndFigureColumn(nil), b.columns...)
+}
+
+// Trim drops all but the last `keep` columns, so a builder fed for a long time holds a bounded chart
+func (b *PointAndFigureBuilder) Trim(keep int) {
+	b.columns = b.columns[max(len(b.columns)-max(keep, 1), 0):]
+}
+
+// Helper function to update the box count and end time of the last column
+func (b *PointAndFigureBuilder) finishColumn(tick Tick) {
+	last := &b.columns[len(b.columns)-1]
+	last.Boxes = int(math.Round((last.High-last.Low)/b.boxSize)) + 1
+	last.EndTime = tick.Time
+}
+
+// Helper functions to snap a price down or up to the box grid
+// - the small tolerance stops prices sitting exactly on a box from being pushed over by rounding error
+func (b *PointAndFigureBuilder) boxBelow(price float64) float64 {
+	return math.Floor(price/b.boxSize+1e-9) * b.boxSize
+}
+
+func (b *PointAndFigureBuilder) boxAbove(price float64) float64 {
+	return math.Ceil(price/b.boxSize-1e-9) * b.boxSize
+}
+
+// Helper function to turn closing prices into ticks, skipping candles with bad data
+func ticksFromCloses(candlesticks []Candlestick) []Tick {
+	ticks := make([]Tick, 0, len(candlesticks))
+	for _, candle := range candlesticks {
+		if isUsable(candle) {
+			ticks = append(ticks, Tick{Price: candle.Close, Time: candle.CloseTime})
+		}
+	}
+	return ticks
+}
+
+// Helper function to check that a price is real and positive
+func usablePrice(price float64) bool {
+	return !math.IsNaN(price) && !math.IsInf(price, 0) && price > 0
+}
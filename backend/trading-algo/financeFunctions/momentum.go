@@ -0,0 +1,39 @@
+package financeFunctions
+
+// CalculateRSI calculates the Relative Strength Index (RSI) using Wilder's smoothing
+// - values are valid from candle `period` onwards, once `period` price changes have been seen
+func CalculateRSI(candlesticks []Candlestick, period int) IndicatorLine {
+	candlesticks, usable := sanitizeCandlesticks(candlesticks)
+	rsi := nanSlice(len(candlesticks))
+	if period <= 0 || len(candlesticks) <= period {
+		return newLine("rsi", rsi, usable, 0)
+	}
+
+	averageGain, averageLoss := 0.0, 0.0
+	for i := 1; i < len(candlesticks); i++ {
+		change := candlesticks[i].Close - candlesticks[i-1].Close
+		gain, loss := max(change, 0), max(-change, 0)
+
+		if i <= period {
+			// The first average is a simple average of the first `period` changes
+			averageGain += gain / float64(period)
+			averageLoss += loss / float64(period)
+			if i < period {
+				continue
+			}
+		} else {
+			averageGain = (averageGain*float64(period-1) + gain) / float64(period)
+			averageLoss = (averageLoss*float64(period-1) + loss) / float64(period)
+		}
+
+		switch {
+		case averageLoss == 0 && averageGain == 0:
+			rsi[i] = 50
+		case averageLoss == 0:
+			rsi[i] = 100
+		default:
+			rsi[i] = 100 - 100/(1+averageGain/averageLoss)
+		}
+	}
+	return newLine("rsi", rsi, usable, 0)
+}
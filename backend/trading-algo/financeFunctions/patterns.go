@@ -0,0 +1,249 @@
+package financeFunctions
+
+import "math"
+
+// PatternOptions holds the body and wick thresholds used to recognise candlestick patterns
+// - ratios are relative to the candle's range (high - low) unless stated otherwise
+type PatternOptions struct {
+	DojiBodyRatio  float64 // a body at most this is a doji
+	SmallBodyRatio float64 // a body at most this is small (hammer, star, harami)
+	LongBodyRatio  float64 // a body at least this is long (engulfed candles, stars, soldiers)
+	LongWickRatio  float64 // a hammer's lower wick is at least this many times its body
+	ShortWickRatio float64 // a hammer's upper wick, and a soldier's closing wick, is at most this
+	TrendLookback  int     // number of candles used to decide the trend before a hammer or hanging man
+}
+
+// DefaultPatternOptions returns commonly used thresholds
+func DefaultPatternOptions() PatternOptions {
+	return PatternOptions{
+		DojiBodyRatio:  0.1,
+		SmallBodyRatio: 0.3,
+		LongBodyRatio:  0.6,
+		LongWickRatio:  2,
+		ShortWickRatio: 0.1,
+		TrendLookback:  5,
+	}
+}
+
+// PatternDirection is what a pattern suggests about the next move
+type PatternDirection string
+
+const (
+	Bullish PatternDirection = "bullish"
+	Bearish PatternDirection = "bearish"
+	Neutral PatternDirection = "neutral"
+)
+
+// Pattern is a candlestick pattern found in a series
+type Pattern struct {
+	Name      string           `json:"name"`
+	Direction PatternDirection `json:"direction"`
+	Index     int              `json:"index"`     // index of the last candle in the pattern
+	Candles   int              `json:"candles"`   // number of candles the pattern spans
+	OpenTime  int64            `json:"openTime"`  // open time of the first candle in the pattern
+	CloseTime int64            `json:"closeTime"` // close time of the last candle in the pattern
+}
+
+// DetectPatterns finds every pattern in `candlesticks`
+func DetectPatterns(candlesticks []Candlestick, options PatternOptions) []Pattern {
+	var patterns []Pattern
+	for i := range candlesticks {
+		patterns = append(patterns, DetectPatternsAt(candlesticks, i, options)...)
+	}
+	return patterns
+}
+
+// DetectPatternsAt finds the patterns that complete on candle `i`
+// - call it with the latest index to detect patterns on a live stream
+// - patterns that include a candle with bad data are never reported
+func DetectPatternsAt(candlesticks []Candlestick, i int, options PatternOptions) []Pattern {
+	if i < 0 || i >= len(candlesticks) || !isUsable(candlesticks[i]) {
+		return nil
+	}
+
+	var patterns []Pattern
+	found := func(name string, direction PatternDirection, candles int) {
+		patterns = append(patterns, Pattern{
+			Name:      name,
+			Direction: direction,
+			Index:     i,
+			Candles:   candles,
+			OpenTime:  candlesticks[i-candles+1].OpenTime,
+			CloseTime: candlesticks[i].CloseTime,
+		})
+	}
+
+	c := shapeOf(candlesticks[i])
+
+	// Single candle patterns
+	if c.bodyRatio() <= options.DojiBodyRatio {
+		found("doji", Neutral, 1)
+	}
+	// A candle with no range, like a four price doji, has no wicks, so it is never a hammer
+	if c.rng > 0 && c.lowerWick > 0 &&
+		c.bodyRatio() <= options.SmallBodyRatio &&
+		c.lowerWick >= options.LongWickRatio*c.body &&
+		c.upperWick <= options.ShortWickRatio*c.rng {
+		switch trendBefore(candlesticks, i, options.TrendLookback) {
+		case Bearish:
+			found("hammer", Bullish, 1)
+		case Bullish:
+			found("hangingMan", Bearish, 1)
+		}
+	}
+
+	// Two candle patterns
+	if !usableWindow(candlesticks, i, 2) {
+		return patterns
+	}
+	p := shapeOf(candlesticks[i-1])
+	prev, curr := candlesticks[i-1], candlesticks[i]
+
+	if p.bearish() && c.bullish() && curr.Open <= prev.Close && curr.Close >= prev.Open && c.body > p.body {
+		found("bullishEngulfing", Bullish, 2)
+	}
+	if p.bullish() && c.bearish() && curr.Open >= prev.Close && curr.Close <= prev.Open && c.body > p.body {
+		found("bearishEngulfing", Bearish, 2)
+	}
+	if p.bodyRatio() >= options.LongBodyRatio && c.body < p.body && c.bodyTop() <= p.bodyTop() && c.bodyBottom() >= p.bodyBottom() {
+		if p.bearish() && c.bullish() {
+			found("bullishHarami", Bullish, 2)
+		}
+		if p.bullish() && c.bearish() {
+			found("bearishHarami", Bearish, 2)
+		}
+	}
+	if curr.High < prev.High && curr.Low > prev.Low {
+		found("insideBar", Neutral, 2)
+	}
+	if curr.High > prev.High && curr.Low < prev.Low {
+		switch {
+		case c.bullish():
+			found("outsideBar", Bullish, 2)
+		case c.bearish():
+			found("outsideBar", Bearish, 2)
+		default:
+			found("outsideBar", Neutral, 2)
+		}
+	}
+
+	// Three candle patterns
+	if !usableWindow(candlesticks, i, 3) {
+		return patterns
+	}
+	first, star := shapeOf(candlesticks[i-2]), p
+	firstCandle := candlesticks[i-2]
+	firstMidpoint := (firstCandle.Open + firstCandle.Close) / 2
+
+	// Morning/evening star: a long candle, a small body beyond it, then a strong reversal past the first body's midpoint
+	if first.bearish() && first.bodyRatio() >= options.LongBodyRatio &&
+		star.bodyRatio() <= options.SmallBodyRatio && star.bodyTop() <= firstCandle.Close &&
+		c.bullish() && curr.Close >= firstMidpoint {
+		found("morningStar", Bullish, 3)
+	}
+	if first.bullish() && first.bodyRatio() >= options.LongBodyRatio &&
+		star.bodyRatio() <= options.SmallBodyRatio && star.bodyBottom() >= firstCandle.Close &&
+		c.bearish() && curr.Close <= firstMidpoint {
+		found("eveningStar", Bearish, 3)
+	}
+
+	if isThreeSoldiers(candlesticks[i-2:i+1], options, true) {
+		found("threeWhiteSoldiers", Bullish, 3)
+	}
+	if isThreeSoldiers(candlesticks[i-2:i+1], options, false) {
+		found("threeBlackCrows", Bearish, 3)
+	}
+
+	return patterns
+}
+
+// Helper function to check for three white soldiers (rising) or three black crows (falling)
+// - three long bodies in the same direction, each opening inside the previous body and closing near its extreme
+func isThreeSoldiers(window []Candlestick, options PatternOptions, rising bool) bool {
+	for j, candle := range window {
+		s := shapeOf(candle)
+		if s.bodyRatio() < options.LongBodyRatio {
+			return false
+		}
+		closingWick := s.lowerWick
+		if rising {
+			closingWick = s.upperWick
+		}
+		if (rising && !s.bullish()) || (!rising && !s.bearish()) || closingWick > options.ShortWickRatio*s.rng {
+			return false
+		}
+		if j == 0 {
+			continue
+		}
+
+		prev := shapeOf(window[j-1])
+		if candle.Open < prev.bodyBottom() || candle.Open > prev.bodyTop() {
+			return false
+		}
+		if (rising && candle.Close <= window[j-1].Close) || (!rising && candle.Close >= window[j-1].Close) {
+			return false
+		}
+	}
+	return true
+}
+
+// Helper function to decide the trend leading into candle `i` from the closes of the previous `lookback` candles
+func trendBefore(candlesticks []Candlestick, i, lookback int) PatternDirection {
+	if lookback <= 0 || !usableWindow(candlesticks, i-1, lookback) {
+		return Neutral
+	}
+	start, end := candlesticks[i-lookback].Close, candlesticks[i-1].Close
+	switch {
+	case end > start:
+		return Bullish
+	case end < start:
+		return Bearish
+	}
+	return Neutral
+}
+
+// Helper function to check that the `length` candles ending at `i` all exist and are usable
+func usableWindow(candlesticks []Candlestick, i, length int) bool {
+	if i-length+1 < 0 || i >= len(candlesticks) {
+		return false
+	}
+	for _, candle := range candlesticks[i-length+1 : i+1] {
+		if !isUsable(candle) {
+			return false
+		}
+	}
+	return true
+}
+
+// The measurements of a single candle used by the pattern rules
+type candleShape struct {
+	open, close          float64
+	body, rng            float64
+	upperWick, lowerWick float64
+}
+
+func shapeOf(candle Candlestick) candleShape {
+	top := math.Max(candle.Open, candle.Close)
+	bottom := math.Min(candle.Open, candle.Close)
+	return candleShape{
+		open:      candle.Open,
+		close:     candle.Close,
+		body:      top - bottom,
+		rng:       candle.High - candle.Low,
+		upperWick: candle.High - top,
+		lowerWick: bottom - candle.Low,
+	}
+}
+
+// Body as a fraction of the range, where a candle with no range has a ratio of 0
+func (s candleShape) bodyRatio() float64 {
+	if s.rng == 0 {
+		return 0
+	}
+	return s.body / s.rng
+}
+
+func (s candleShape) bullish() bool       { return s.close > s.open }
+func (s candleShape) bearish() bool       { return s.close < s.open }
+func (s candleShape) bodyTop() float64    { return math.Max(s.open, s.close) }
+func (s candleShape) bodyBottom() float64 { return math.Min(s.open, s.close) }
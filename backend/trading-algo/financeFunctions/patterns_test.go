@@ -0,0 +1,57 @@
+package financeFunctions
+
+import (
+	"reflect"
+	"testing"
+)
+
+// Helper function to make a one minute candle, the i-th opening at minute i
+func minuteCandle(i int, open, high, low, close float64) Candlestick {
+	openTime := int64(i) * 60000
+	return Candlestick{Open: open, High: high, Low: low, Close: close, Volume: 1, OpenTime: openTime, CloseTime: openTime + 59999}
+}
+
+// Helper function to make five candles closing at `start`, then one step further every candle, followed by `last`
+func afterTrend(start, step float64, last Candlestick) []Candlestick {
+	var candles []Candlestick
+	for i := 0; i < 5; i++ {
+		close := start + float64(i)*step
+		candles = append(candles, minuteCandle(i, close-step, close+0.5, close-0.5, close))
+	}
+	last.OpenTime, last.CloseTime = 5*60000, 5*60000+59999
+	return append(candles, last)
+}
+
+// Helper function to get the names of the single candle patterns on the last candle
+func singleCandlePatterns(candles []Candlestick) []string {
+	var names []string
+	for _, pattern := range DetectPatternsAt(candles, len(candles)-1, DefaultPatternOptions()) {
+		if pattern.Candles == 1 {
+			names = append(names, pattern.Name)
+		}
+	}
+	return names
+}
+
+func TestHammers(t *testing.T) {
+	tests := []struct {
+		name    string
+		candles []Candlestick
+		want    []string
+	}{
+		{"hammer after a fall", afterTrend(100, -1, minuteCandle(0, 95.4, 96, 93, 96)), []string{"hammer"}},
+		{"hanging man after a rise", afterTrend(100, 1, minuteCandle(0, 103.4, 104, 101, 104)), []string{"hangingMan"}},
+		{"dragonfly doji after a fall", afterTrend(100, -1, minuteCandle(0, 96, 96, 93, 96)), []string{"doji", "hammer"}},
+		{"four price doji after a fall", afterTrend(100, -1, minuteCandle(0, 96, 96, 96, 96)), []string{"doji"}},
+		{"four price doji after a rise", afterTrend(100, 1, minuteCandle(0, 104, 104, 104, 104)), []string{"doji"}},
+		{"flat candles", afterTrend(100, 0, minuteCandle(0, 100, 100, 100, 100)), []string{"doji"}},
+		{"no lower wick after a fall", afterTrend(100, -1, minuteCandle(0, 96, 99, 96, 96)), []string{"doji"}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := singleCandlePatterns(test.candles); !reflect.DeepEqual(got, test.want) {
+				t.Errorf("patterns = %v, want %v", got, test.want)
+			}
+		})
+	}
+}
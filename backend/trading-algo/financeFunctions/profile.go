@@ -0,0 +1,234 @@
+package financeFunctions
+
+import (
+	"math"
+	"time"
+)
+
+// The most price levels a profile will hold; smaller buckets are widened to fit
+const maxProfileLevels = 2000
+
+// Letters given to each TPO period, in order
+const tpoLetters = "ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz"
+
+// ProfileOptions controls how volume and time are distributed into price buckets
+type ProfileOptions struct {
+	BucketSize       float64       // height of each price level
+	ValueAreaPercent float64       // share of the volume (or TPOs) inside the value area, usually 0.7
+	TPOPeriod        time.Duration // each period of this length gets its own TPO letter, usually 30 minutes
+}
+
+// ProfileLevel is one price bucket of a profile
+type ProfileLevel struct {
+	Price  float64 `json:"price"`  // bottom of the bucket
+	Volume float64 `json:"volume"` // volume traded inside the bucket
+	TPO    string  `json:"tpo"`    // letter of every TPO period that traded inside the bucket
+}
+
+// Profile is a volume profile and market (TPO) profile over the same prices
+// - the point of control is the middle of the busiest level
+// - the value area is the range of levels around it holding ValueAreaPercent of the total
+type Profile struct {
+	BucketSize     float64        `json:"bucketSize"`
+	Levels         []ProfileLevel `json:"levels"` // lowest price first
+	TotalVolume    float64        `json:"totalVolume"`
+	PointOfControl float64        `json:"pointOfControl"`
+	ValueAreaHigh  float64        `json:"valueAreaHigh"`
+	ValueAreaLow   float64        `json:"valueAreaLow"`
+
+	// The same measures, weighted by TPO count instead of volume
+	TPOPointOfControl float64 `json:"tpoPointOfControl"`
+	TPOValueAreaHigh  float64 `json:"tpoValueAreaHigh"`
+	TPOValueAreaLow   float64 `json:"tpoValueAreaLow"`
+
+	StartTime int64 `json:"startTime"`
+	EndTime   int64 `json:"endTime"`
+}
+
+// CalculateProfile builds a volume and market profile from candlesticks
+// - each candle's volume is spread evenly over the part of every bucket its high-low range covers
+// - candles with bad data are skipped
+func CalculateProfile(candlesticks []Candlestick, options ProfileOptions) Profile {
+	pieces := make([]profilePiece, 0, len(candlesticks))
+	for _, candle := range candlesticks {
+		if isUsable(candle) {
+			pieces = append(pieces, profilePiece{low: candle.Low, high: candle.High, volume: candle.Volume, time: candle.OpenTime, endTime: candle.CloseTime})
+		}
+	}
+	return buildProfile(pieces, options)
+}
+
+// ProfileFromTicks builds a volume and market profile from individual trades, using each tick's quantity as its volume
+func ProfileFromTicks(ticks []Tick, options ProfileOptions) Profile {
+	pieces := make([]profilePiece, 0, len(ticks))
+	for _, tick := range ticks {
+		if usablePrice(tick.Price) {
+			pieces = append(pieces, profilePiece{low: tick.Price, high: tick.Price, volume: tick.Quantity, time: tick.Time, endTime: tick.Time})
+		}
+	}
+	return buildProfile(pieces, options)
+}
+
+// AutoBucketSize splits the high-low range of `candlesticks` into `buckets` equal price levels
+func AutoBucketSize(candlesticks []Candlestick, buckets int) float64 {
+	high, low := math.Inf(-1), math.Inf(1)
+	for _, candle := range candlesticks {
+		if isUsable(candle) {
+			high = math.Max(high, candle.High)
+			low = math.Min(low, candle.Low)
+		}
+	}
+	if buckets <= 0 || high <= low {
+		return 0
+	}
+	return (high - low) / float64(buckets)
+}
+
+// SessionCandles returns the trailing candlesticks that belong to the same session as the last one
+func SessionCandles(candlesticks []Candlestick, session VWAPSession, location *time.Location) []Candlestick {
+	if len(candlesticks) == 0 {
+		return nil
+	}
+	if location == nil {
+		location = time.UTC
+	}
+	sessionOf := func(candle Candlestick) time.Time {
+		return sessionStart(time.UnixMilli(candle.OpenTime).In(location), session)
+	}
+
+	current := sessionOf(candlesticks[len(candlesticks)-1])
+	first := len(candlesticks) - 1
+	for first > 0 && sessionOf(candlesticks[first-1]).Equal(current) {
+		first--
+	}
+	return candlesticks[first:]
+}
+
+// A price range that traded some volume at some time, from either a candle or a tick
+type profilePiece struct {
+	low, high     float64
+	volume        float64
+	time, endTime int64
+}
+
+// Helper function to distribute every piece into buckets and find the points of control and value areas
+func buildProfile(pieces []profilePiece, options ProfileOptions) Profile {
+	profile := Profile{BucketSize: options.BucketSize}
+	if len(pieces) == 0 || options.BucketSize <= 0 {
+		return profile
+	}
+
+	high, low := math.Inf(-1), math.Inf(1)
+	profile.StartTime, profile.EndTime = pieces[0].time, pieces[0].endTime
+	for _, piece := range pieces {
+		high = math.Max(high, piece.high)
+		low = math.Min(low, piece.low)
+		profile.StartTime = min(profile.StartTime, piece.time)
+		profile.EndTime = max(profile.EndTime, piece.endTime)
+	}
+
+	// Widen the buckets if they would produce too many levels to draw
+	size := options.BucketSize
+	if (high-low)/size >= maxProfileLevels {
+		size = (high - low) / (maxProfileLevels - 1)
+	}
+	profile.BucketSize = size
+
+	// A range ending exactly on a bucket's bottom edge does not reach into that bucket
+	bucketOf := func(price float64) int { return int(math.Floor(price/size + 1e-9)) }
+	topBucketOf := func(piece profilePiece) int {
+		if piece.high > piece.low {
+			return max(int(math.Ceil(piece.high/size-1e-9))-1, bucketOf(piece.low))
+		}
+		return bucketOf(piece.high)
+	}
+
+	first, last := bucketOf(low), bucketOf(low)
+	for _, piece := range pieces {
+		last = max(last, topBucketOf(piece))
+	}
+	levels := make([]ProfileLevel, last-first+1)
+	for i := range levels {
+		levels[i].Price = float64(first+i) * size
+	}
+
+	// TPO periods are aligned to the period grid, so "A" is the first period of the profile
+	tpoPeriod := options.TPOPeriod.Milliseconds()
+	firstPeriod := int64(0)
+	if tpoPeriod > 0 {
+		firstPeriod = profile.StartTime - mod(profile.StartTime, tpoPeriod)
+	}
+	tpoCounts := make([]float64, len(levels))
+
+	for _, piece := range pieces {
+		from, to := bucketOf(piece.low)-first, topBucketOf(piece)-first
+
+		for b := from; b <= to; b++ {
+			// Share the volume by how much of the piece's range falls inside this bucket
+			share := 1.0
+			if piece.high > piece.low {
+				bottom := math.Max(piece.low, levels[b].Price)
+				top := math.Min(piece.high, levels[b].Price+size)
+				share = math.Max(top-bottom, 0) / (piece.high - piece.low)
+			}
+			levels[b].Volume += piece.volume * share
+			profile.TotalVolume += piece.volume * share
+		}
+
+		if tpoPeriod > 0 {
+			letter := tpoLetters[int((piece.time-firstPeriod)/tpoPeriod)%len(tpoLetters)]
+			for b := from; b <= to; b++ {
+				tpo := levels[b].TPO
+				if len(tpo) == 0 || tpo[len(tpo)-1] != letter {
+					levels[b].TPO += string(letter)
+					tpoCounts[b]++
+				}
+			}
+		}
+	}
+	profile.Levels = levels
+
+	volumes := make([]float64, len(levels))
+	for i, level := range levels {
+		volumes[i] = level.Volume
+	}
+	profile.PointOfControl, profile.ValueAreaLow, profile.ValueAreaHigh = valueArea(levels, volumes, size, options.ValueAreaPercent)
+	if tpoPeriod > 0 {
+		profile.TPOPointOfControl, profile.TPOValueAreaLow, profile.TPOValueAreaHigh = valueArea(levels, tpoCounts, size, options.ValueAreaPercent)
+	}
+	return profile
+}
+
+// Helper function to find the point of control and value area of a profile weighted by `weights`
+// - the value area grows from the point of control towards whichever neighbouring level is heavier
+func valueArea(levels []ProfileLevel, weights []float64, size, percent float64) (float64, float64, float64) {
+	poc := 0
+	total := 0.0
+	for i, weight := range weights {
+		total += weight
+		if weight > weights[poc] {
+			poc = i
+		}
+	}
+
+	lo, hi := poc, poc
+	covered := weights[poc]
+	for covered < percent*total && (lo > 0 || hi < len(weights)-1) {
+		below, above := -1.0, -1.0
+		if lo > 0 {
+			below = weights[lo-1]
+		}
+		if hi < len(weights)-1 {
+			above = weights[hi+1]
+		}
+		if above >= below {
+			hi++
+			covered += above
+		} else {
+			lo--
+			covered += below
+		}
+	}
+
+	return levels[poc].Price + size/2, levels[lo].Price, levels[hi].Price + size
+}
@@ -0,0 +1,139 @@
+package financeFunctions
+
+import (
+	"fmt"
+	"math"
+	"time"
+)
+
+// Timeframe is a candlestick interval, written the way Binance writes it (e.g. "5m", "4h")
+type Timeframe string
+
+const (
+	Timeframe1m  Timeframe = "1m"
+	Timeframe3m  Timeframe = "3m"
+	Timeframe5m  Timeframe = "5m"
+	Timeframe15m Timeframe = "15m"
+	Timeframe1h  Timeframe = "1h"
+	Timeframe4h  Timeframe = "4h"
+	Timeframe1d  Timeframe = "1d"
+)
+
+var timeframeDurations = map[Timeframe]time.Duration{
+	Timeframe1m:  time.Minute,
+	Timeframe3m:  3 * time.Minute,
+	Timeframe5m:  5 * time.Minute,
+	Timeframe15m: 15 * time.Minute,
+	Timeframe1h:  time.Hour,
+	Timeframe4h:  4 * time.Hour,
+	Timeframe1d:  24 * time.Hour,
+}
+
+// ParseTimeframe converts a value such as "15m" into a Timeframe
+func ParseTimeframe(value string) (Timeframe, error) {
+	timeframe := Timeframe(value)
+	if _, ok := timeframeDurations[timeframe]; !ok {
+		return "", fmt.Errorf("unsupported timeframe %q", value)
+	}
+	return timeframe, nil
+}
+
+// Duration is how long one candle of this timeframe lasts
+func (t Timeframe) Duration() time.Duration {
+	return timeframeDurations[t]
+}
+
+// TimeframeOf returns the timeframe a candlestick spans, e.g. "1m" for one closing 59999 milliseconds after it opened
+func TimeframeOf(candle Candlestick) (Timeframe, bool) {
+	duration := time.Duration(candle.CloseTime-candle.OpenTime+1) * time.Millisecond
+	for timeframe, d := range timeframeDurations {
+		if d == duration {
+			return timeframe, true
+		}
+	}
+	return "", false
+}
+
+// Resample builds candlesticks of `timeframe` from finer (usually 1m) candlesticks
+// - candles are aligned to UTC like Binance's, e.g. 4h candles open at 00:00, 04:00, 08:00...
+// - only complete candles are returned; use a Resampler to also see the one in progress
+// - source candles with bad data are skipped
+func Resample(candlesticks []Candlestick, timeframe Timeframe) []Candlestick {
+	resampler := NewResampler(timeframe)
+	var resampled []Candlestick
+	for _, candle := range candlesticks {
+		resampled = append(resampled, resampler.Add(candle)...)
+	}
+	return resampled
+}
+
+// Resampler incrementally builds candlesticks of a higher timeframe from a stream of finer ones
+type Resampler struct {
+	timeframe Timeframe
+	current   Candlestick
+	building  bool
+}
+
+// NewResampler creates a Resampler that builds `timeframe` candlesticks
+func NewResampler(timeframe Timeframe) *Resampler {
+	return &Resampler{timeframe: timeframe}
+}
+
+// Add feeds the next closed source candlestick in, returning any candlesticks it completes
+// - a candle completes when a source candle reaches its close time, or when one arrives for a later candle
+func (r *Resampler) Add(candle Candlestick) []Candlestick {
+	if !isUsable(candle) {
+		return nil
+	}
+
+	var completed []Candlestick
+	openTime, closeTime := r.bounds(candle.OpenTime)
+
+	// A gap in the source data means the candle being built will not receive any more data
+	if r.building && openTime != r.current.OpenTime {
+		completed = append(completed, r.current)
+		r.building = false
+	}
+
+	if !r.building {
+		r.current = Candlestick{
+			Open:      candle.Open,
+			High:      candle.High,
+			Low:       candle.Low,
+			OpenTime:  openTime,
+			CloseTime: closeTime,
+		}
+		r.building = true
+	}
+	r.current.High = math.Max(r.current.High, candle.High)
+	r.current.Low = math.Min(r.current.Low, candle.Low)
+	r.current.Close = candle.Close
+	r.current.Volume += candle.Volume
+
+	if candle.CloseTime >= closeTime {
+		completed = append(completed, r.current)
+		r.building = false
+	}
+	return completed
+}
+
+// Current returns the candlestick still being built, if any
+func (r *Resampler) Current() (Candlestick, bool) {
+	return r.current, r.building
+}
+
+// Helper function to find the open and close time (Unix milliseconds) of the candle containing `timestamp`
+// - the close time is the last millisecond of the candle, matching Binance
+func (r *Resampler) bounds(timestamp int64) (int64, int64) {
+	duration := r.timeframe.Duration().Milliseconds()
+	if duration <= 0 {
+		duration = time.Minute.Milliseconds()
+	}
+	openTime := timestamp - mod(timestamp, duration)
+	return openTime, openTime + duration - 1
+}
+
+// Helper function to calculate a modulo that is never negative, so times before 1970 still align
+func mod(a, b int64) int64 {
+	return (a%b + b) % b
+}
@@ -0,0 +1,60 @@
+package financeFunctions
+
+import (
+	"math"
+	"strconv"
+)
+
+// IndicatorLine is one named output of an indicator
+// - e.g. Ichimoku returns five lines, Keltner Channels returns three
+// - Valid is false while the indicator is warming up, or where the candle behind a value had bad data
+type IndicatorLine struct {
+	Name   string `json:"name"`
+	Values Series `json:"values"`
+	Valid  []bool `json:"valid"`
+}
+
+// Series holds indicator values, where NaN marks a point that has no value
+type Series []float64
+
+// MarshalJSON writes NaN and infinite values as null, since JSON cannot represent them
+func (s Series) MarshalJSON() ([]byte, error) {
+	buf := make([]byte, 0, len(s)*8+2)
+	buf = append(buf, '[')
+	for i, v := range s {
+		if i > 0 {
+			buf = append(buf, ',')
+		}
+		if math.IsNaN(v) || math.IsInf(v, 0) {
+			buf = append(buf, "null"...)
+			continue
+		}
+		buf = strconv.AppendFloat(buf, v, 'g', -1, 64)
+	}
+	return append(buf, ']'), nil
+}
+
+// Helper function to build an indicator line and flag which of its values are valid
+// - a value is valid when it exists and the candle it was calculated on is usable
+// - `shift` is how many periods the line is displaced forward from that candle
+func newLine(name string, values []float64, usable []bool, shift int) IndicatorLine {
+	valid := make([]bool, len(values))
+	for i, v := range values {
+		if math.IsNaN(v) || math.IsInf(v, 0) {
+			continue
+		}
+		source := i - shift
+		valid[i] = source < 0 || source >= len(usable) || usable[source]
+	}
+	return IndicatorLine{Name: name, Values: values, Valid: valid}
+}
+
+// Helper function to mark the first `warmUp` candles as unusable
+func warmedUp(usable []bool, warmUp int) []bool {
+	marked := make([]bool, len(usable))
+	copy(marked, usable)
+	for i := 0; i < warmUp && i < len(marked); i++ {
+		marked[i] = false
+	}
+	return marked
+}
@@ -0,0 +1,276 @@
+package financeFunctions
+
+import "math"
+
+// CalculateATR calculates the Average True Range (ATR) using Wilder's smoothing
+// - values before the first full period are NaN
+func CalculateATR(candlesticks []Candlestick, period int) IndicatorLine {
+	candlesticks, usable := sanitizeCandlesticks(candlesticks)
+	return newLine("atr", atrOf(candlesticks, period), usable, 0)
+}
+
+// CalculateSupertrend calculates the Supertrend overlay
+// - "supertrend" is the active trailing band
+// - "direction" is 1 while the trend is up and -1 while it is down
+func CalculateSupertrend(candlesticks []Candlestick, period int, multiplier float64) []IndicatorLine {
+	candlesticks, usable := sanitizeCandlesticks(candlesticks)
+	n := len(candlesticks)
+	supertrend := nanSlice(n)
+	direction := nanSlice(n)
+	atr := atrOf(candlesticks, period)
+
+	finalUpper, finalLower := 0.0, 0.0
+	started := false
+	for i, candle := range candlesticks {
+		if math.IsNaN(atr[i]) {
+			continue
+		}
+		hl2 := (candle.High + candle.Low) / 2
+		basicUpper := hl2 + multiplier*atr[i]
+		basicLower := hl2 - multiplier*atr[i]
+
+		// The first warmed up candle seeds both bands and assumes an uptrend
+		if !started {
+			finalUpper, finalLower = basicUpper, basicLower
+			direction[i] = 1
+			supertrend[i] = finalLower
+			started = true
+			continue
+		}
+
+		// Bands only ever tighten unless price has closed through them
+		prevClose := candlesticks[i-1].Close
+		if basicUpper < finalUpper || prevClose > finalUpper {
+			finalUpper = basicUpper
+		}
+		if basicLower > finalLower || prevClose < finalLower {
+			finalLower = basicLower
+		}
+
+		switch {
+		case direction[i-1] < 0 && candle.Close > finalUpper:
+			direction[i] = 1
+		case direction[i-1] > 0 && candle.Close < finalLower:
+			direction[i] = -1
+		default:
+			direction[i] = direction[i-1]
+		}
+
+		if direction[i] > 0 {
+			supertrend[i] = finalLower
+		} else {
+			supertrend[i] = finalUpper
+		}
+	}
+
+	return []IndicatorLine{
+		newLine("supertrend", supertrend, usable, 0),
+		newLine("direction", direction, usable, 0),
+	}
+}
+
+// CalculateIchimoku calculates the five lines of the Ichimoku Cloud
+// - every line has len(candlesticks)+displacement values, where index i is candle i
+// - the trailing `displacement` values hold the cloud projected into future periods
+// - senkou spans are shifted forward and the chikou span is shifted back by `displacement`
+func CalculateIchimoku(candlesticks []Candlestick, tenkanPeriod, kijunPeriod, senkouBPeriod, displacement int) []IndicatorLine {
+	candlesticks, usable := sanitizeCandlesticks(candlesticks)
+	n := len(candlesticks)
+	if displacement < 0 {
+		displacement = 0
+	}
+
+	tenkan := nanSlice(n + displacement)
+	kijun := nanSlice(n + displacement)
+	senkouA := nanSlice(n + displacement)
+	senkouB := nanSlice(n + displacement)
+	chikou := nanSlice(n + displacement)
+
+	copy(tenkan, midpoints(candlesticks, tenkanPeriod))
+	copy(kijun, midpoints(candlesticks, kijunPeriod))
+	spanB := midpoints(candlesticks, senkouBPeriod)
+
+	for i := 0; i < n; i++ {
+		senkouA[i+displacement] = (tenkan[i] + kijun[i]) / 2
+		senkouB[i+displacement] = spanB[i]
+		if i >= displacement {
+			chikou[i-displacement] = candlesticks[i].Close
+		}
+	}
+
+	return []IndicatorLine{
+		newLine("tenkanSen", tenkan, usable, 0),
+		newLine("kijunSen", kijun, usable, 0),
+		newLine("senkouSpanA", senkouA, usable, displacement),
+		newLine("senkouSpanB", senkouB, usable, displacement),
+		newLine("chikouSpan", chikou, usable, -displacement),
+	}
+}
+
+// CalculateParabolicSAR calculates Wilder's Parabolic Stop and Reverse
+// - the acceleration factor starts at `step` and grows by `step` on each new extreme, up to `maxStep`
+func CalculateParabolicSAR(candlesticks []Candlestick, step, maxStep float64) []IndicatorLine {
+	candlesticks, usable := sanitizeCandlesticks(candlesticks)
+	n := len(candlesticks)
+	sar := nanSlice(n)
+	if n < 2 {
+		return []IndicatorLine{newLine("sar", sar, usable, 0)}
+	}
+
+	// Seed the trend from the direction of the first two closes
+	rising := candlesticks[1].Close >= candlesticks[0].Close
+	af := step
+	var extreme float64
+	if rising {
+		sar[1] = candlesticks[0].Low
+		extreme = math.Max(candlesticks[0].High, candlesticks[1].High)
+	} else {
+		sar[1] = candlesticks[0].High
+		extreme = math.Min(candlesticks[0].Low, candlesticks[1].Low)
+	}
+
+	for i := 2; i < n; i++ {
+		candle := candlesticks[i]
+		next := sar[i-1] + af*(extreme-sar[i-1])
+
+		if rising {
+			// SAR can never sit above the previous two lows in an uptrend
+			next = math.Min(next, math.Min(candlesticks[i-1].Low, candlesticks[i-2].Low))
+			if candle.Low < next {
+				rising = false
+				next = extreme
+				extreme = candle.Low
+				af = step
+			} else if candle.High > extreme {
+				extreme = candle.High
+				af = math.Min(af+step, maxStep)
+			}
+		} else {
+			// SAR can never sit below the previous two highs in a downtrend
+			next = math.Max(next, math.Max(candlesticks[i-1].High, candlesticks[i-2].High))
+			if candle.High > next {
+				rising = true
+				next = extreme
+				extreme = candle.High
+				af = step
+			} else if candle.Low < extreme {
+				extreme = candle.Low
+				af = math.Min(af+step, maxStep)
+			}
+		}
+		sar[i] = next
+	}
+
+	return []IndicatorLine{newLine("sar", sar, usable, 0)}
+}
+
+// CalculateKeltnerChannels calculates Keltner Channels
+// - the middle line is an EMA of closes, the bands are `multiplier` ATRs away from it
+func CalculateKeltnerChannels(candlesticks []Candlestick, emaPeriod, atrPeriod int, multiplier float64) []IndicatorLine {
+	candlesticks, usable := sanitizeCandlesticks(candlesticks)
+	n := len(candlesticks)
+	middle := emaOf(extractClosingPrices(candlesticks), emaPeriod, SeedSMA)
+	atr := atrOf(candlesticks, atrPeriod)
+	upper := nanSlice(n)
+	lower := nanSlice(n)
+
+	for i := 0; i < n; i++ {
+		upper[i] = middle[i] + multiplier*atr[i]
+		lower[i] = middle[i] - multiplier*atr[i]
+	}
+
+	return []IndicatorLine{
+		newLine("upper", upper, usable, 0),
+		newLine("middle", middle, usable, 0),
+		newLine("lower", lower, usable, 0),
+	}
+}
+
+// CalculateDonchianChannels calculates Donchian Channels
+// - the bands are the highest high and lowest low of the last `period` candles
+func CalculateDonchianChannels(candlesticks []Candlestick, period int) []IndicatorLine {
+	candlesticks, usable := sanitizeCandlesticks(candlesticks)
+	n := len(candlesticks)
+	upper := nanSlice(n)
+	lower := nanSlice(n)
+	middle := nanSlice(n)
+
+	for i := range candlesticks {
+		if period <= 0 || i < period-1 {
+			continue
+		}
+		upper[i], lower[i] = highLow(candlesticks[i-period+1 : i+1])
+		middle[i] = (upper[i] + lower[i]) / 2
+	}
+
+	return []IndicatorLine{
+		newLine("upper", upper, usable, 0),
+		newLine("middle", middle, usable, 0),
+		newLine("lower", lower, usable, 0),
+	}
+}
+
+// Helper function to calculate Wilder's ATR of each candlestick
+func atrOf(candlesticks []Candlestick, period int) []float64 {
+	atr := nanSlice(len(candlesticks))
+	if period <= 0 || len(candlesticks) < period {
+		return atr
+	}
+
+	tr := trueRanges(candlesticks)
+	sum := 0.0
+	for i := 0; i < period; i++ {
+		sum += tr[i]
+	}
+	atr[period-1] = sum / float64(period)
+
+	for i := period; i < len(tr); i++ {
+		atr[i] = (atr[i-1]*float64(period-1) + tr[i]) / float64(period)
+	}
+	return atr
+}
+
+// Helper function to calculate the true range of each candlestick
+func trueRanges(candlesticks []Candlestick) []float64 {
+	tr := make([]float64, len(candlesticks))
+	for i, candle := range candlesticks {
+		tr[i] = candle.High - candle.Low
+		if i > 0 {
+			prevClose := candlesticks[i-1].Close
+			tr[i] = math.Max(tr[i], math.Max(math.Abs(candle.High-prevClose), math.Abs(candle.Low-prevClose)))
+		}
+	}
+	return tr
+}
+
+// Helper function to calculate the midpoint of the highest high and lowest low over each window
+func midpoints(candlesticks []Candlestick, period int) []float64 {
+	mid := nanSlice(len(candlesticks))
+	for i := range candlesticks {
+		if period <= 0 || i < period-1 {
+			continue
+		}
+		high, low := highLow(candlesticks[i-period+1 : i+1])
+		mid[i] = (high + low) / 2
+	}
+	return mid
+}
+
+// Helper function to find the highest high and lowest low of a window
+func highLow(window []Candlestick) (float64, float64) {
+	high, low := math.Inf(-1), math.Inf(1)
+	for _, candle := range window {
+		high = math.Max(high, candle.High)
+		low = math.Min(low, candle.Low)
+	}
+	return high, low
+}
+
+// Helper function to create a slice where every value is NaN (not yet available)
+func nanSlice(n int) []float64 {
+	values := make([]float64, n)
+	for i := range values {
+		values[i] = math.NaN()
+	}
+	return values
+}
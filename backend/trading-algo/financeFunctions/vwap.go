@@ -0,0 +1,122 @@
+package financeFunctions
+
+import (
+	"fmt"
+	"math"
+	"time"
+)
+
+// VWAPSession decides when a session VWAP resets its accumulation
+type VWAPSession string
+
+const (
+	SessionDaily  VWAPSession = "daily"  // resets at midnight
+	SessionWeekly VWAPSession = "weekly" // resets at midnight on Monday
+)
+
+// ParseVWAPSession converts a config value such as "daily" into a VWAPSession
+func ParseVWAPSession(value string) (VWAPSession, error) {
+	switch session := VWAPSession(value); session {
+	case SessionDaily, SessionWeekly:
+		return session, nil
+	}
+	return "", fmt.Errorf("unknown VWAP session %q, expected %q or %q", value, SessionDaily, SessionWeekly)
+}
+
+// CalculateSessionVWAP calculates a VWAP that resets at the start of every session
+// - sessions are measured in `location`, so a daily session starts at local midnight
+// - each band multiplier adds an "upperN"/"lowerN" pair N volume weighted standard deviations away
+func CalculateSessionVWAP(candlesticks []Candlestick, session VWAPSession, location *time.Location, bandMultipliers ...float64) []IndicatorLine {
+	if location == nil {
+		location = time.UTC
+	}
+
+	var current time.Time
+	resets := func(candle Candlestick) bool {
+		start := sessionStart(time.UnixMilli(candle.OpenTime).In(location), session)
+		if start.Equal(current) {
+			return false
+		}
+		current = start
+		return true
+	}
+	return accumulateVWAP(candlesticks, 0, resets, bandMultipliers)
+}
+
+// CalculateAnchoredVWAP calculates a VWAP that starts accumulating at `anchor` (Unix milliseconds)
+// - candles that open before the anchor have no value
+// - each band multiplier adds an "upperN"/"lowerN" pair N volume weighted standard deviations away
+func CalculateAnchoredVWAP(candlesticks []Candlestick, anchor int64, bandMultipliers ...float64) []IndicatorLine {
+	first := len(candlesticks)
+	for i, candle := range candlesticks {
+		if candle.OpenTime >= anchor {
+			first = i
+			break
+		}
+	}
+	return accumulateVWAP(candlesticks, first, func(Candlestick) bool { return false }, bandMultipliers)
+}
+
+// Helper function to accumulate VWAP and its bands from candle `start`, starting over whenever `resets` returns true
+// - bad candles are replaced with zero volume ones, so they never move the average
+func accumulateVWAP(candlesticks []Candlestick, start int, resets func(Candlestick) bool, bandMultipliers []float64) []IndicatorLine {
+	candlesticks, usable := sanitizeCandlesticks(candlesticks)
+	n := len(candlesticks)
+	vwap := nanSlice(n)
+	upper := make([][]float64, len(bandMultipliers))
+	lower := make([][]float64, len(bandMultipliers))
+	for b := range bandMultipliers {
+		upper[b] = nanSlice(n)
+		lower[b] = nanSlice(n)
+	}
+
+	cumulativePriceVolume := 0.0
+	cumulativeSquaredPriceVolume := 0.0
+	cumulativeVolume := 0.0
+
+	for i := start; i < n; i++ {
+		candle := candlesticks[i]
+		if resets(candle) {
+			cumulativePriceVolume, cumulativeSquaredPriceVolume, cumulativeVolume = 0, 0, 0
+		}
+
+		typicalPrice := (candle.High + candle.Low + candle.Close) / 3
+		cumulativePriceVolume += typicalPrice * candle.Volume
+		cumulativeSquaredPriceVolume += typicalPrice * typicalPrice * candle.Volume
+		cumulativeVolume += candle.Volume
+
+		// No volume has traded in this session yet, so there is no average to report
+		if cumulativeVolume == 0 {
+			continue
+		}
+
+		vwap[i] = cumulativePriceVolume / cumulativeVolume
+		// Rounding can push the variance slightly below zero when prices are flat
+		variance := math.Max(cumulativeSquaredPriceVolume/cumulativeVolume-vwap[i]*vwap[i], 0)
+		deviation := math.Sqrt(variance)
+		for b, multiplier := range bandMultipliers {
+			upper[b][i] = vwap[i] + multiplier*deviation
+			lower[b][i] = vwap[i] - multiplier*deviation
+		}
+	}
+
+	lines := []IndicatorLine{newLine("vwap", vwap, usable, 0)}
+	for b, multiplier := range bandMultipliers {
+		lines = append(lines,
+			newLine(fmt.Sprintf("upper%g", multiplier), upper[b], usable, 0),
+			newLine(fmt.Sprintf("lower%g", multiplier), lower[b], usable, 0),
+		)
+	}
+	return lines
+}
+
+// Helper function to find the start of the session containing `t`
+func sessionStart(t time.Time, session VWAPSession) time.Time {
+	day := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+	if session == SessionWeekly {
+		// time.Weekday starts on Sunday, sessions start on Monday
+		daysSinceMonday := (int(day.Weekday()) + 6) % 7
+		day = day.AddDate(0, 0, -daysSinceMonday)
+	}
+	return day
+}
@@ -3,18 +3,25 @@ module github.com/neozhixuan/project-visualgo-backend/trading-algo
 go 1.22.1
 
 require (
+	github.com/golang-jwt/jwt/v5 v5.3.1
+	github.com/gorilla/websocket v1.5.3
+	github.com/joho/godotenv v1.5.1
 	github.com/neozhixuan/project-visualgo-backend/pb v0.0.0
+	github.com/neozhixuan/project-visualgo-backend/streamHub v0.0.0
+	github.com/vmihailenco/msgpack/v5 v5.4.1
+	go.starlark.net v0.0.0-20250623223156-8bf495bf4e9a
 	google.golang.org/grpc v1.63.2
+	google.golang.org/protobuf v1.34.1
 )
 
 require (
-	github.com/gorilla/websocket v1.5.1 // indirect
-	github.com/joho/godotenv v1.5.1 // indirect
+	github.com/vmihailenco/tagparser/v2 v2.0.0 // indirect
 	golang.org/x/net v0.21.0 // indirect
 	golang.org/x/sys v0.17.0 // indirect
 	golang.org/x/text v0.14.0 // indirect
 	google.golang.org/genproto/googleapis/rpc v0.0.0-20240227224415-6ceb2ff114de // indirect
-	google.golang.org/protobuf v1.34.1 // indirect
 )
 
 replace github.com/neozhixuan/project-visualgo-backend/pb => ../pb
+
+replace github.com/neozhixuan/project-visualgo-backend/streamHub => ../streamHub
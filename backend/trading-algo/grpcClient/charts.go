@@ -0,0 +1,112 @@
+package grpcClient
+
+import (
+	"github.com/neozhixuan/project-visualgo-backend/trading-algo/financeFunctions"
+	"github.com/neozhixuan/project-visualgo-backend/trading-algo/websocketServer"
+)
+
+// Streaming chart transforms, each published on its own WebSocket channel
+// - Heikin-Ashi is built from closed candles
+// - Renko, Kagi and Point & Figure are built from every price update, so they react within a candle
+// - Heikin-Ashi candles and Renko bricks never change once built, so only new ones are sent, while the stream keeps the
+// last few hundred for the snapshot
+// - Kagi lines and Point & Figure columns are sent whole, as the last one keeps moving, and only the last few hundred are kept
+// - every symbol has its own charts, and its own box sizes when they follow its ATR
+type chartTransforms struct {
+	config  chartConfig
+	stream  *websocketServer.Stream
+	symbols map[string]*symbolCharts
+}
+
+// The chart builders of one symbol
+type symbolCharts struct {
+	heikinAshi *financeFunctions.HeikinAshiBuilder
+
+	// These are created once their box size or reversal is known, which may need an ATR first
+	renko          *financeFunctions.RenkoBuilder
+	kagi           *financeFunctions.KagiBuilder
+	pointAndFigure *financeFunctions.PointAndFigureBuilder
+}
+
+func newChartTransforms(config chartConfig, stream *websocketServer.Stream) *chartTransforms {
+	return &chartTransforms{config: config, stream: stream, symbols: map[string]*symbolCharts{}}
+}
+
+// Helper function to get the chart builders of `symbol`, creating them the first time it is seen
+func (c *chartTransforms) of(symbol string) *symbolCharts {
+	charts, ok := c.symbols[symbol]
+	if !ok {
+		charts = &symbolCharts{heikinAshi: &financeFunctions.HeikinAshiBuilder{}}
+		c.symbols[symbol] = charts
+	}
+	return charts
+}
+
+// Feed a closed candle of `symbol` in, returning the updates to publish
+func (c *chartTransforms) onCandleClosed(symbol, interval string, candlesticks []financeFunctions.Candlestick) []websocketServer.Message {
+	var updates []websocketServer.Message
+	charts := c.of(symbol)
+
+	if ha, ok := charts.heikinAshi.Add(candlesticks[len(candlesticks)-1]); ok {
+		message := websocketServer.Message{Type: "heikinAshi", Symbol: symbol, Interval: interval, OpenTime: ha.OpenTime}
+		updates = append(updates, c.stream.Chart(message, []interface{}{ha}))
+	}
+
+	// Sizes left unset follow the symbol's volatility, fixed at its ATR when enough of its candles have closed
+	atr := financeFunctions.RenkoBoxSizeFromATR(candlesticks, c.config.atrPeriod)
+	if charts.renko == nil {
+		if size := sizeOrATR(c.config.renkoBoxSize, atr); size > 0 {
+			charts.renko = financeFunctions.NewRenkoBuilder(size)
+		}
+	}
+	if charts.kagi == nil {
+		if size := sizeOrATR(c.config.kagiReversal, atr); size > 0 {
+			charts.kagi = financeFunctions.NewKagiBuilder(size)
+		}
+	}
+	if charts.pointAndFigure == nil {
+		if size := sizeOrATR(c.config.pointAndFigureBoxSize, atr); size > 0 {
+			charts.pointAndFigure = financeFunctions.NewPointAndFigureBuilder(size, c.config.pointAndFigureReversal)
+		}
+	}
+
+	return updates
+}
+
+// Feed the latest traded price of `symbol` in, returning the updates to publish
+// - Kagi and Point & Figure updates carry their chart's full state, so rate limited clients only get the latest,
+// while every Renko update is sent, as it only carries the new bricks
+func (c *chartTransforms) onTick(symbol string, tick financeFunctions.Tick) []websocketServer.Message {
+	var updates []websocketServer.Message
+	charts := c.of(symbol)
+
+	if charts.renko != nil {
+		if bricks := charts.renko.Add(tick); len(bricks) > 0 {
+			items := make([]interface{}, len(bricks))
+			for i, brick := range bricks {
+				items[i] = brick
+			}
+			updates = append(updates, c.stream.Chart(websocketServer.Message{Type: "renko", Symbol: symbol}, items))
+		}
+	}
+	if charts.kagi != nil && charts.kagi.Add(tick) {
+		charts.kagi.Trim(websocketServer.SnapshotPoints)
+		message := websocketServer.Message{Type: "kagi", Symbol: symbol, Data: charts.kagi.Lines(), Conflate: true}
+		updates = append(updates, c.stream.Latest(message))
+	}
+	if charts.pointAndFigure != nil && charts.pointAndFigure.Add(tick) {
+		charts.pointAndFigure.Trim(websocketServer.SnapshotPoints)
+		message := websocketServer.Message{Type: "pointAndFigure", Symbol: symbol, Data: charts.pointAndFigure.Columns(), Conflate: true}
+		updates = append(updates, c.stream.Latest(message))
+	}
+
+	return updates
+}
+
+// Helper function to use a configured size, or the ATR when none was configured
+func sizeOrATR(configured, atr float64) float64 {
+	if configured > 0 {
+		return configured
+	}
+	return atr
+}
@@ -0,0 +1,345 @@
+package grpcClient
+
+import (
+	"log"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/neozhixuan/project-visualgo-backend/trading-algo/alerts"
+	"github.com/neozhixuan/project-visualgo-backend/trading-algo/candleStore"
+	"github.com/neozhixuan/project-visualgo-backend/trading-algo/exchange"
+	"github.com/neozhixuan/project-visualgo-backend/trading-algo/financeFunctions"
+	"github.com/neozhixuan/project-visualgo-backend/trading-algo/paperTrading"
+	"github.com/neozhixuan/project-visualgo-backend/trading-algo/risk"
+	"github.com/neozhixuan/project-visualgo-backend/trading-algo/scripting"
+	"github.com/neozhixuan/project-visualgo-backend/trading-algo/strategy"
+)
+
+// VWAP settings read from the environment
+// - VWAP_SESSION is "daily" (default) or "weekly"
+// - VWAP_TIMEZONE is an IANA zone such as "Asia/Singapore" that decides when sessions start (default UTC)
+// - VWAP_ANCHOR is an optional RFC 3339 timestamp to also publish an anchored VWAP from
+type vwapConfig struct {
+	session  financeFunctions.VWAPSession
+	location *time.Location
+	anchor   *time.Time
+}
+
+// Standard deviation multipliers of the VWAP bands
+var vwapBands = []float64{1, 2}
+
+func loadVWAPConfig() vwapConfig {
+	config := vwapConfig{}
+	config.session, config.location = LoadVWAPSession()
+
+	if value := os.Getenv("VWAP_ANCHOR"); value != "" {
+		anchor, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			log.Fatalf("Invalid VWAP_ANCHOR: %v", err)
+		}
+		config.anchor = &anchor
+	}
+
+	return config
+}
+
+// LoadVWAPSession reads how VWAP sessions are split from VWAP_SESSION and VWAP_TIMEZONE, exiting if either is invalid
+func LoadVWAPSession() (financeFunctions.VWAPSession, *time.Location) {
+	session, location := financeFunctions.SessionDaily, time.UTC
+
+	if value := os.Getenv("VWAP_SESSION"); value != "" {
+		parsed, err := financeFunctions.ParseVWAPSession(value)
+		if err != nil {
+			log.Fatalf("Invalid VWAP_SESSION: %v", err)
+		}
+		session = parsed
+	}
+
+	if value := os.Getenv("VWAP_TIMEZONE"); value != "" {
+		parsed, err := time.LoadLocation(value)
+		if err != nil {
+			log.Fatalf("Invalid VWAP_TIMEZONE: %v", err)
+		}
+		location = parsed
+	}
+	return session, location
+}
+
+// Read the candlestick pattern thresholds from the environment, falling back to the defaults
+// - e.g. PATTERN_DOJI_BODY_RATIO=0.05 only treats bodies under 5% of the range as a doji
+func loadPatternOptions() financeFunctions.PatternOptions {
+	options := financeFunctions.DefaultPatternOptions()
+	options.DojiBodyRatio = envFloat("PATTERN_DOJI_BODY_RATIO", options.DojiBodyRatio)
+	options.SmallBodyRatio = envFloat("PATTERN_SMALL_BODY_RATIO", options.SmallBodyRatio)
+	options.LongBodyRatio = envFloat("PATTERN_LONG_BODY_RATIO", options.LongBodyRatio)
+	options.LongWickRatio = envFloat("PATTERN_LONG_WICK_RATIO", options.LongWickRatio)
+	options.ShortWickRatio = envFloat("PATTERN_SHORT_WICK_RATIO", options.ShortWickRatio)
+	options.TrendLookback = int(envFloat("PATTERN_TREND_LOOKBACK", float64(options.TrendLookback)))
+	return options
+}
+
+// Chart transform settings read from the environment
+// - RENKO_BOX_SIZE, KAGI_REVERSAL and PNF_BOX_SIZE are in price units, and follow the ATR when unset
+// - CHART_ATR_PERIOD is the ATR period used for those (default 14)
+// - PNF_REVERSAL_BOXES is how many boxes it takes to start a new Point & Figure column (default 3)
+type chartConfig struct {
+	renkoBoxSize           float64
+	kagiReversal           float64
+	pointAndFigureBoxSize  float64
+	pointAndFigureReversal int
+	atrPeriod              int
+}
+
+func loadChartConfig() chartConfig {
+	return chartConfig{
+		renkoBoxSize:           envFloat("RENKO_BOX_SIZE", 0),
+		kagiReversal:           envFloat("KAGI_REVERSAL", 0),
+		pointAndFigureBoxSize:  envFloat("PNF_BOX_SIZE", 0),
+		pointAndFigureReversal: int(envFloat("PNF_REVERSAL_BOXES", 3)),
+		atrPeriod:              int(envFloat("CHART_ATR_PERIOD", 14)),
+	}
+}
+
+// Volume profile settings read from the environment
+// - PROFILE_WINDOW is "session" (default, follows VWAP_SESSION and VWAP_TIMEZONE) or a number of recent candles
+// - PROFILE_BUCKET_SIZE is the height of each price level; when unset the window is split into PROFILE_BUCKETS levels (default 30)
+// - PROFILE_VALUE_AREA is the share of volume inside the value area (default 0.7)
+// - PROFILE_TPO_MINUTES is the length of each TPO letter's period (default 30)
+type profileConfig struct {
+	windowCandles int // 0 means the current session
+	bucketSize    float64
+	buckets       int
+	valueArea     float64
+	tpoPeriod     time.Duration
+}
+
+func loadProfileConfig() profileConfig {
+	config := profileConfig{
+		bucketSize: envFloat("PROFILE_BUCKET_SIZE", 0),
+		buckets:    int(envFloat("PROFILE_BUCKETS", 30)),
+		valueArea:  envFloat("PROFILE_VALUE_AREA", 0.7),
+		tpoPeriod:  time.Duration(envFloat("PROFILE_TPO_MINUTES", 30)) * time.Minute,
+	}
+	if value := os.Getenv("PROFILE_WINDOW"); value != "" && value != "session" {
+		candles, err := strconv.Atoi(value)
+		if err != nil || candles <= 0 {
+			log.Fatalf("Invalid PROFILE_WINDOW: expected \"session\" or a number of candles, got %q", value)
+		}
+		config.windowCandles = candles
+	}
+	return config
+}
+
+// Read the strategies to run from STRATEGIES, e.g. "emaCrossover:fast=9,slow=21;rsiMeanReversion"
+// - every built-in strategy runs with its default parameters when STRATEGIES is unset
+func loadStrategies() []strategy.Factory {
+	value, ok := os.LookupEnv("STRATEGIES")
+	if !ok {
+		value = strings.Join(strategy.Names(), ";")
+	}
+	factories, err := strategy.ParseConfig(value)
+	if err != nil {
+		log.Fatalf("Invalid STRATEGIES: %v", err)
+	}
+	return factories
+}
+
+// Load the Starlark scripts in SCRIPTS_DIR and register their strategies, returning nil when it is unset
+// - SCRIPT_MAX_STEPS, SCRIPT_TIMEOUT_MS and SCRIPT_MAX_MEMORY_MB limit every call into a script (default 5000000, 1000 and 64)
+// - SCRIPT_MAX_MEMORY_MB is checked against the whole process's live heap, so it is a best-effort guard rather than a per-call limit
+// - SCRIPT_RELOAD_SECONDS is how often edited scripts are picked up (default 2)
+func loadScripts() (*scripting.Loader, time.Duration) {
+	dir := os.Getenv("SCRIPTS_DIR")
+	if dir == "" {
+		return nil, 0
+	}
+	limits := scripting.DefaultLimits()
+	limits.MaxSteps = uint64(envFloat("SCRIPT_MAX_STEPS", float64(limits.MaxSteps)))
+	limits.Timeout = time.Duration(envFloat("SCRIPT_TIMEOUT_MS", float64(limits.Timeout.Milliseconds()))) * time.Millisecond
+	limits.MaxMemory = uint64(envFloat("SCRIPT_MAX_MEMORY_MB", float64(limits.MaxMemory>>20))) << 20
+
+	loader, err := scripting.RegisterDir(dir, limits)
+	if err != nil {
+		log.Fatalf("Invalid SCRIPTS_DIR: %v", err)
+	}
+	reload := time.Duration(envFloat("SCRIPT_RELOAD_SECONDS", 2) * float64(time.Second))
+	if reload <= 0 {
+		log.Fatalf("Invalid SCRIPT_RELOAD_SECONDS: expected a positive number of seconds, got %v", reload.Seconds())
+	}
+	return loader, reload
+}
+
+// LoadCandleStore opens the store that keeps every closed candle for backtesting, in CANDLE_STORE_DIR (default "data")
+func LoadCandleStore() *candleStore.Store {
+	dir := os.Getenv("CANDLE_STORE_DIR")
+	if dir == "" {
+		dir = "data"
+	}
+	store, err := candleStore.New(dir)
+	if err != nil {
+		log.Fatalf("Invalid CANDLE_STORE_DIR: %v", err)
+	}
+	return store
+}
+
+// Read how many recent closed candles of each symbol are kept to calculate indicators, strategies and alerts on, while the candle store keeps the rest
+// - CANDLE_WINDOW defaults to 10080, a week of 1m candles, which covers weekly VWAP sessions, script lookbacks and every built-in warm-up
+// - the window never holds fewer candles than a PROFILE_WINDOW of candles needs
+func loadCandleWindow(profile profileConfig) int {
+	window := int(envFloat("CANDLE_WINDOW", 10080))
+	if window < 1 {
+		log.Fatalf("Invalid CANDLE_WINDOW: %d is not a positive number of candles", window)
+	}
+	return max(window, profile.windowCandles)
+}
+
+// Read how strategy signals are paper traded, returning false when PAPER_TRADING is "false"
+// - PAPER_CASH is each strategy's starting cash (default 10000), PAPER_SIZE the fraction of equity per position (default 0.95)
+// - PAPER_TAKER_FEE, PAPER_MAKER_FEE and PAPER_SLIPPAGE are fractions of each fill (default 0.001, 0.001 and 0.0005)
+// - PAPER_SHORT lets sell signals open short positions (default false)
+// - PAPER_STOP_LOSS and PAPER_TAKE_PROFIT place protective orders at that fraction from the entry (default none)
+// - PAPER_TRAILING_STOP makes the stop loss trail the best price since entry (default false)
+func loadPaperConfig() (paperTrading.TraderConfig, bool) {
+	config := paperTrading.DefaultTraderConfig()
+	if !envBool("PAPER_TRADING", true) {
+		return config, false
+	}
+	config.Account.InitialCash = envFloat("PAPER_CASH", config.Account.InitialCash)
+	config.Account.TakerFee = envFloat("PAPER_TAKER_FEE", config.Account.TakerFee)
+	config.Account.MakerFee = envFloat("PAPER_MAKER_FEE", config.Account.MakerFee)
+	config.Account.Slippage = envFloat("PAPER_SLIPPAGE", config.Account.Slippage)
+	config.Account.AllowShort = envBool("PAPER_SHORT", config.Account.AllowShort)
+	config.PositionSize = envFloat("PAPER_SIZE", config.PositionSize)
+	config.StopLoss = envFloat("PAPER_STOP_LOSS", config.StopLoss)
+	config.TakeProfit = envFloat("PAPER_TAKE_PROFIT", config.TakeProfit)
+	config.TrailingStop = envBool("PAPER_TRAILING_STOP", config.TrailingStop)
+	if !(config.PositionSize > 0 && config.PositionSize <= 1) {
+		log.Fatalf("Invalid PAPER_SIZE: expected a fraction in (0, 1], got %v", config.PositionSize)
+	}
+	return config, true
+}
+
+// Read how signals are traded on the exchange, returning false unless LIVE_TRADING is "true"
+// - EXCHANGE_BASE_URL is the REST API to trade on (default Binance, or the mock exchange's http://localhost:8091)
+// - EXCHANGE_API_KEY and EXCHANGE_API_SECRET are the account's credentials
+// - LIVE_STRATEGY is the one strategy whose signals are traded, and LIVE_QUANTITY its position size in the base asset
+// - LIVE_SHORT lets sell signals open short positions (default false)
+func loadLiveConfig() (exchange.Config, exchange.LiveConfig, bool) {
+	config, live := exchange.DefaultConfig(), exchange.LiveConfig{}
+	if os.Getenv("LIVE_TRADING") != "true" {
+		return config, live, false
+	}
+
+	if value := os.Getenv("EXCHANGE_BASE_URL"); value != "" {
+		config.BaseURL = strings.TrimSuffix(value, "/")
+	}
+	config.APIKey = os.Getenv("EXCHANGE_API_KEY")
+	config.Secret = os.Getenv("EXCHANGE_API_SECRET")
+	if config.APIKey == "" || config.Secret == "" {
+		log.Fatalf("Invalid live trading config: EXCHANGE_API_KEY and EXCHANGE_API_SECRET are required")
+	}
+
+	live.Strategy = os.Getenv("LIVE_STRATEGY")
+	if _, ok := strategy.Lookup(live.Strategy); !ok {
+		log.Fatalf("Invalid LIVE_STRATEGY: expected one of %s, got %q", strings.Join(strategy.Names(), ", "), live.Strategy)
+	}
+	live.Quantity = envFloat("LIVE_QUANTITY", 0)
+	if !(live.Quantity > 0) {
+		log.Fatalf("Invalid LIVE_QUANTITY: expected a positive quantity, got %v", live.Quantity)
+	}
+	live.AllowShort = envBool("LIVE_SHORT", false)
+	return config, live, true
+}
+
+// Read the pre-trade risk limits, where 0 turns a limit off
+// - RISK_MAX_POSITION is the largest position per symbol in the base asset (default off)
+// - RISK_MAX_NOTIONAL is the largest value of a single order in the quote asset (default off)
+// - RISK_MAX_DAILY_LOSS is the loss in a UTC day after which only reducing orders are accepted (default off)
+// - RISK_MAX_OPEN_ORDERS is the most orders an account can have open (default 50)
+// - RISK_PRICE_COLLAR is the furthest a limit or stop price may be from the last close, as a fraction (default 0.1)
+func loadRiskLimits() risk.Limits {
+	limits := risk.Limits{
+		MaxPosition:   envFloat("RISK_MAX_POSITION", 0),
+		MaxNotional:   envFloat("RISK_MAX_NOTIONAL", 0),
+		MaxDailyLoss:  envFloat("RISK_MAX_DAILY_LOSS", 0),
+		MaxOpenOrders: int(envFloat("RISK_MAX_OPEN_ORDERS", 50)),
+		PriceCollar:   envFloat("RISK_PRICE_COLLAR", 0.1),
+	}
+	if limits.MaxPosition < 0 || limits.MaxNotional < 0 || limits.MaxDailyLoss < 0 || limits.MaxOpenOrders < 0 || limits.PriceCollar < 0 {
+		log.Fatalf("Invalid risk limits: limits cannot be negative, got %+v", limits)
+	}
+	return limits
+}
+
+// Read the alert rules and where their alerts are delivered, returning nil when ALERT_RULES_FILE is unset
+// - ALERT_RULES_FILE is a JSON array of rules, e.g. [{"name": "oversold", "condition": "rsi(14) < 30", "cooldown": "1h"}]
+// - alerts are always pushed to the WebSocket clients, through the "websocket" channel
+// - ALERT_WEBHOOK_URL receives every alert as a JSON POST, signed with ALERT_WEBHOOK_SECRET when set
+// - SMTP_HOST, SMTP_PORT (default 587), SMTP_USERNAME, SMTP_PASSWORD, SMTP_FROM and ALERT_EMAIL_TO (comma separated) mail every alert
+func loadAlertEngine(websocket alerts.Notifier) *alerts.Engine {
+	path := os.Getenv("ALERT_RULES_FILE")
+	if path == "" {
+		return nil
+	}
+	rules, err := alerts.LoadRules(path)
+	if err != nil {
+		log.Fatalf("Invalid ALERT_RULES_FILE: %v", err)
+	}
+
+	notifiers := []alerts.Notifier{websocket}
+	if url := os.Getenv("ALERT_WEBHOOK_URL"); url != "" {
+		notifiers = append(notifiers, alerts.NewWebhook(url, os.Getenv("ALERT_WEBHOOK_SECRET")))
+	}
+	if host := os.Getenv("SMTP_HOST"); host != "" {
+		email := &alerts.Email{
+			Host:     host,
+			Port:     int(envFloat("SMTP_PORT", 587)),
+			Username: os.Getenv("SMTP_USERNAME"),
+			Password: os.Getenv("SMTP_PASSWORD"),
+			From:     os.Getenv("SMTP_FROM"),
+		}
+		for _, to := range strings.Split(os.Getenv("ALERT_EMAIL_TO"), ",") {
+			if to = strings.TrimSpace(to); to != "" {
+				email.To = append(email.To, to)
+			}
+		}
+		if email.From == "" || len(email.To) == 0 {
+			log.Fatalf("Invalid alert email config: SMTP_FROM and ALERT_EMAIL_TO are required with SMTP_HOST")
+		}
+		notifiers = append(notifiers, email)
+	}
+
+	engine, err := alerts.NewEngine(rules, notifiers...)
+	if err != nil {
+		log.Fatalf("Invalid ALERT_RULES_FILE: %v", err)
+	}
+	return engine
+}
+
+// Helper function to read a number from the environment
+func envFloat(name string, fallback float64) float64 {
+	value := os.Getenv(name)
+	if value == "" {
+		return fallback
+	}
+	number, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		log.Fatalf("Invalid %s: %v", name, err)
+	}
+	return number
+}
+
+// Helper function to read a true/false setting from the environment
+func envBool(name string, fallback bool) bool {
+	value := os.Getenv(name)
+	if value == "" {
+		return fallback
+	}
+	enabled, err := strconv.ParseBool(value)
+	if err != nil {
+		log.Fatalf("Invalid %s: %v", name, err)
+	}
+	return enabled
+}
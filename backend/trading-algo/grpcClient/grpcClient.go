@@ -5,25 +5,83 @@ import (
 	"io"
 	"log"
 	"os"
+	"sort"
+	"time"
 
 	pb "github.com/neozhixuan/project-visualgo-backend/pb"
 
-	"github.com/joho/godotenv"
+	"github.com/neozhixuan/project-visualgo-backend/trading-algo/alerts"
+	"github.com/neozhixuan/project-visualgo-backend/trading-algo/candleStore"
+	"github.com/neozhixuan/project-visualgo-backend/trading-algo/exchange"
 	"github.com/neozhixuan/project-visualgo-backend/trading-algo/financeFunctions"
+	"github.com/neozhixuan/project-visualgo-backend/trading-algo/orders"
+	"github.com/neozhixuan/project-visualgo-backend/trading-algo/paperTrading"
+	"github.com/neozhixuan/project-visualgo-backend/trading-algo/risk"
+	"github.com/neozhixuan/project-visualgo-backend/trading-algo/scripting"
+	"github.com/neozhixuan/project-visualgo-backend/trading-algo/strategy"
+	"github.com/neozhixuan/project-visualgo-backend/trading-algo/websocketServer"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/credentials/insecure"
 )
 
-func StartGRPCClient(ema9Channel chan []float64) {
+func StartGRPCClient(updateChannel chan websocketServer.Message, signalChannel chan strategy.Signal, riskManager *risk.Manager, wsStream *websocketServer.Stream, store *candleStore.Store) {
 	log.Println("Hi, trying to start gRPC client")
-	// Load .env file
-	err := godotenv.Load()
-	if err != nil {
-		log.Fatalf("Error loading .env file")
+
+	// Read how the VWAP should be anchored, how patterns are recognised and how the volume profile is built
+	vwap := loadVWAPConfig()
+	patternOptions := loadPatternOptions()
+	profile := loadProfileConfig()
+	window := loadCandleWindow(profile)
+
+	// Set up the Heikin-Ashi, Renko, Kagi and Point & Figure charts
+	charts := newChartTransforms(loadChartConfig(), wsStream)
+
+	// Load the user's scripted strategies and indicators, before the strategies are configured, and keep them up to date with their files
+	scripts, reload := loadScripts()
+	if scripts != nil {
+		log.Printf("Loaded strategy scripts %v and indicator scripts %v", scripts.Strategies(), scripts.Indicators())
+		go scripts.Watch(context.Background(), reload)
+	}
+
+	// Set up the strategies that turn candles into signals
+	runner := strategy.NewRunner(loadStrategies()...)
+
+	// Set up the risk checks every order goes through, and the token guarding the kill switch
+	riskManager.SetLimits(loadRiskLimits())
+	riskManager.SetAdminToken(os.Getenv("ADMIN_TOKEN"))
+
+	// Paper trade the signals, with an account per strategy whose orders and positions are streamed to the WebSocket clients
+	var trader *paperTrading.Trader
+	if paperConfig, enabled := loadPaperConfig(); enabled {
+		trader = paperTrading.NewTrader(paperConfig, func(messageType string, data interface{}) {
+			publish(updateChannel, websocketServer.Message{Type: messageType, Data: data})
+		})
+		trader.SetRisk(riskManager)
+	}
+
+	// Trade one strategy's signals on the exchange, only when explicitly switched on
+	var liveTrader *exchange.LiveTrader
+	if exchangeConfig, liveConfig, enabled := loadLiveConfig(); enabled {
+		log.Printf("LIVE TRADING ENABLED: %s signals are sent to %s", liveConfig.Strategy, exchangeConfig.BaseURL)
+		liveTrader = exchange.NewLiveTrader(exchange.NewClient(exchangeConfig), liveConfig, func(order orders.Order) {
+			publish(updateChannel, websocketServer.Message{Type: "order", Data: order})
+		})
+		liveTrader.SetRisk(riskManager)
+		go liveTrader.Run(context.Background())
 	}
 
-	// Define a candlestick slice to store all candlesticks
-	var candlesticks []financeFunctions.Candlestick
+	// Watch the alert rules, pushing alerts to the WebSocket clients alongside any webhook or mail server
+	alertEngine := loadAlertEngine(&alerts.FuncNotifier{Name: "websocket", Send: func(alert alerts.Alert) error {
+		publish(updateChannel, websocketServer.Message{Type: "alert", Symbol: alert.Symbol, Data: alert})
+		return nil
+	}})
+	if alertEngine != nil {
+		log.Printf("Evaluating %d alert rules", alertEngine.Rules())
+		go alertEngine.Run(context.Background())
+	}
+
+	// Keep the latest `window` candlesticks of every symbol, as every closed candle recalculates over all of its symbol's candles
+	candlesticks := map[string][]financeFunctions.Candlestick{}
 
 	// Production
 	stage := os.Getenv("STAGE")
@@ -77,30 +135,172 @@ func StartGRPCClient(ema9Channel chan []float64) {
 		// Received message
 		log.Printf("Received: %s", tradeData)
 
-		// If we hit the minute candlestick, calculate 9-EMA
+		// Every kline update carries the latest traded price, which drives the tick based charts
+		// - klines have no event time, so the tick is stamped with the time it arrived
+		tick := financeFunctions.Tick{Price: tradeData.ClosePrice, Time: time.Now().UnixMilli()}
+		riskManager.OnKline(tradeData.Symbol, tradeData.ClosePrice)
+		for _, update := range charts.onTick(tradeData.Symbol, tick) {
+			publish(updateChannel, update)
+		}
+		if trader != nil {
+			trader.OnTick(tradeData.Symbol, tick)
+		}
+
+		// If we hit the minute candlestick, calculate the indicators
 		if tradeData.IsKlineClosed {
-			// Create Candlestick struct and append to candlesticks slice
+			// Create Candlestick struct and append it to its symbol's candlesticks
 			candle := financeFunctions.Candlestick{
-				Open:   tradeData.OpenPrice,
-				High:   tradeData.HighPrice,
-				Low:    tradeData.LowPrice,
-				Close:  tradeData.ClosePrice,
-				Volume: tradeData.Volume,
+				Open:      tradeData.OpenPrice,
+				High:      tradeData.HighPrice,
+				Low:       tradeData.LowPrice,
+				Close:     tradeData.ClosePrice,
+				Volume:    tradeData.Volume,
+				OpenTime:  tradeData.OpenTime,
+				CloseTime: tradeData.CloseTime,
+			}
+			candles := append(candlesticks[tradeData.Symbol], candle)
+			candles = candles[max(len(candles)-window, 0):]
+			candlesticks[tradeData.Symbol] = candles
+			if err := store.Append(tradeData.Symbol, candle); err != nil {
+				log.Printf("Error while storing candle: %v", err)
+			}
+
+			// Send the closed candle, then only the points of 9-EMA, VWAP and the trend overlays it added or changed
+			interval, _ := financeFunctions.TimeframeOf(candle)
+			publish(updateChannel, wsStream.Candle(tradeData.Symbol, string(interval), candle))
+			indicators := calculateIndicators(candles, vwap)
+			if scripts != nil {
+				indicators = addScriptIndicators(indicators, scripts, strategy.NewContext(tradeData.Symbol, candles))
 			}
-			candlesticks = append(candlesticks, candle)
-
-			// Calculate 9-EMA
-			ema9 := financeFunctions.CalculateEMA(candlesticks, 9)
-
-			// Send ema9 data to the WebSocket server via channel
-			select {
-			case ema9Channel <- ema9:
-				// Successfully sent to broadcast
-				log.Println("Sent a message to WSS client")
-			default:
-				// Handle when no one is reading from broadcast (could log or handle differently)
-				log.Println("Warning: channel to WSS client is full, dropping message")
+			for _, indicator := range indicators {
+				for _, update := range wsStream.Indicator(tradeData.Symbol, string(interval), indicator.name, indicator.params, candles, indicator.lines) {
+					publish(updateChannel, update)
+				}
 			}
+
+			// Emit any candlestick pattern completed by this candle, so the chart can annotate it
+			patterns := financeFunctions.DetectPatternsAt(candles, len(candles)-1, patternOptions)
+			if len(patterns) > 0 {
+				publish(updateChannel, websocketServer.Message{Type: "patterns", Symbol: tradeData.Symbol, Interval: string(interval), OpenTime: candle.OpenTime, Data: patterns})
+			}
+
+			for _, update := range charts.onCandleClosed(tradeData.Symbol, string(interval), candles) {
+				publish(updateChannel, update)
+			}
+
+			// Send the volume and market profile, for the frontend to draw as a side histogram
+			publish(updateChannel, wsStream.Latest(websocketServer.Message{Type: "profile", Symbol: tradeData.Symbol, Interval: string(interval), Data: calculateProfile(candles, profile, vwap)}))
+
+			// Let every strategy decide on the closed candle, and send out any signal to both the WebSocket and gRPC clients
+			for _, signal := range runner.OnCandle(tradeData.Symbol, candles) {
+				log.Printf("Signal: %s %s %s (%s)", signal.Strategy, signal.Action, signal.Symbol, signal.Reason)
+				publish(updateChannel, websocketServer.Message{Type: "signal", Symbol: signal.Symbol, Interval: string(interval), OpenTime: candle.OpenTime, Data: signal})
+				select {
+				case signalChannel <- signal:
+				default:
+					log.Println("Warning: channel to gRPC signal server is full, dropping signal")
+				}
+				if trader != nil {
+					if err := trader.OnSignal(signal); err != nil {
+						log.Printf("Error while paper trading signal: %v", err)
+					}
+				}
+				if liveTrader != nil {
+					liveTrader.Submit(signal)
+				}
+			}
+			if trader != nil {
+				trader.OnCandleClosed(tradeData.Symbol, candle)
+			}
+
+			// Check the alert rules, whose alerts are delivered in the background
+			if alertEngine != nil {
+				alertEngine.OnCandle(tradeData.Symbol, candles)
+			}
+		}
+	}
+}
+
+// Send an update to the WebSocket server via channel, without blocking the gRPC stream
+func publish(updateChannel chan websocketServer.Message, update websocketServer.Message) {
+	select {
+	case updateChannel <- update:
+		// Successfully sent to broadcast
+		log.Printf("Sent a %s message to WSS client", update.Type)
+	default:
+		// Handle when no one is reading from broadcast (could log or handle differently)
+		log.Printf("Warning: channel to WSS client is full, dropping %s message", update.Type)
+	}
+}
+
+// An indicator streamed to the WebSocket clients, with the parameters it was calculated with and its named output lines
+// - the parameters are in the order they appear in the indicator's channel, e.g. "supertrend:BNBBTC:1m:10:3"
+type indicator struct {
+	name   string
+	params []websocketServer.Param
+	lines  []financeFunctions.IndicatorLine
+}
+
+// Calculate every indicator streamed to the WebSocket clients
+func calculateIndicators(candlesticks []financeFunctions.Candlestick, vwap vwapConfig) []indicator {
+	indicators := []indicator{
+		{"ema", []websocketServer.Param{{Name: "period", Value: 9}}, []financeFunctions.IndicatorLine{financeFunctions.CalculateEMA(candlesticks, 9, financeFunctions.SeedSMA)}},
+		{"vwap", nil, financeFunctions.CalculateSessionVWAP(candlesticks, vwap.session, vwap.location, vwapBands...)},
+		{"supertrend", []websocketServer.Param{{Name: "period", Value: 10}, {Name: "multiplier", Value: 3}}, financeFunctions.CalculateSupertrend(candlesticks, 10, 3)},
+		{"ichimoku", []websocketServer.Param{{Name: "tenkan", Value: 9}, {Name: "kijun", Value: 26}, {Name: "senkouB", Value: 52}, {Name: "displacement", Value: 26}}, financeFunctions.CalculateIchimoku(candlesticks, 9, 26, 52, 26)},
+		{"psar", []websocketServer.Param{{Name: "step", Value: 0.02}, {Name: "maxStep", Value: 0.2}}, financeFunctions.CalculateParabolicSAR(candlesticks, 0.02, 0.2)},
+		{"keltner", []websocketServer.Param{{Name: "emaPeriod", Value: 20}, {Name: "atrPeriod", Value: 10}, {Name: "multiplier", Value: 2}}, financeFunctions.CalculateKeltnerChannels(candlesticks, 20, 10, 2)},
+		{"donchian", []websocketServer.Param{{Name: "period", Value: 20}}, financeFunctions.CalculateDonchianChannels(candlesticks, 20)},
+	}
+
+	if vwap.anchor != nil {
+		indicators = append(indicators, indicator{"anchoredVwap", nil, financeFunctions.CalculateAnchoredVWAP(candlesticks, vwap.anchor.UnixMilli(), vwapBands...)})
+	}
+	return indicators
+}
+
+// Add every indicator script's line, with its default parameters in alphabetical order, unless its name is taken by a built-in indicator
+func addScriptIndicators(indicators []indicator, scripts *scripting.Loader, ctx *strategy.Context) []indicator {
+	taken := map[string]bool{}
+	for _, indicator := range indicators {
+		taken[indicator.name] = true
+	}
+	lines := scripts.Calculate(ctx)
+	for _, name := range scripts.Indicators() {
+		line, ok := lines[name]
+		if !ok {
+			continue
+		}
+		if taken[name] {
+			log.Printf("Warning: indicator script %s has the same name as a built-in indicator, not publishing it", name)
+			continue
+		}
+		script, _ := scripts.Script(name)
+		var params []websocketServer.Param
+		for param, value := range script.Params {
+			params = append(params, websocketServer.Param{Name: param, Value: value})
 		}
+		sort.Slice(params, func(i, j int) bool { return params[i].Name < params[j].Name })
+		indicators = append(indicators, indicator{name, params, []financeFunctions.IndicatorLine{line}})
 	}
+	return indicators
+}
+
+// Calculate the volume and market profile over the configured window
+func calculateProfile(candlesticks []financeFunctions.Candlestick, profile profileConfig, vwap vwapConfig) financeFunctions.Profile {
+	window := financeFunctions.SessionCandles(candlesticks, vwap.session, vwap.location)
+	if profile.windowCandles > 0 {
+		window = candlesticks[max(len(candlesticks)-profile.windowCandles, 0):]
+	}
+
+	bucketSize := profile.bucketSize
+	if bucketSize <= 0 {
+		bucketSize = financeFunctions.AutoBucketSize(window, profile.buckets)
+	}
+
+	return financeFunctions.CalculateProfile(window, financeFunctions.ProfileOptions{
+		BucketSize:       bucketSize,
+		ValueAreaPercent: profile.valueArea,
+		TPOPeriod:        profile.tpoPeriod,
+	})
 }
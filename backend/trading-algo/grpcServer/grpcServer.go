@@ -0,0 +1,96 @@
+package grpcServer
+
+import (
+	"log"
+	"net"
+	"sync"
+
+	"github.com/neozhixuan/project-visualgo-backend/pb"
+	"github.com/neozhixuan/project-visualgo-backend/trading-algo/strategy"
+	"google.golang.org/grpc"
+)
+
+// The gRPC server has this type
+// 1. The Protobuf signal service server
+// 2. Every connected client's own channel, with the symbol it asked for ("" for every symbol)
+type server struct {
+	pb.UnimplementedSignalServiceServer
+
+	mu          sync.Mutex
+	subscribers map[chan *pb.Signal]string
+}
+
+// gRPC method to stream signals to a client until it disconnects
+// - every client gets every signal, instead of clients taking turns reading from one channel
+func (s *server) StreamSignals(req *pb.SignalRequest, stream pb.SignalService_StreamSignalsServer) error {
+	log.Printf("Client requested to start streaming signals for %q", req.Symbol)
+
+	signals := make(chan *pb.Signal, 16)
+	s.mu.Lock()
+	s.subscribers[signals] = req.Symbol
+	s.mu.Unlock()
+
+	defer func() {
+		s.mu.Lock()
+		delete(s.subscribers, signals)
+		s.mu.Unlock()
+	}()
+
+	for {
+		select {
+		case signal := <-signals:
+			if err := stream.Send(signal); err != nil {
+				return err
+			}
+		case <-stream.Context().Done():
+			return nil
+		}
+	}
+}
+
+// Copy each signal from `signalChannel` to every subscribed client
+// - a client that is not keeping up misses signals rather than holding up the others
+func (s *server) broadcast(signalChannel chan strategy.Signal) {
+	for signal := range signalChannel {
+		message := &pb.Signal{
+			Strategy: signal.Strategy,
+			Symbol:   signal.Symbol,
+			Action:   string(signal.Action),
+			Price:    signal.Price,
+			Reason:   signal.Reason,
+			Time:     signal.Time,
+		}
+
+		s.mu.Lock()
+		for subscriber, symbol := range s.subscribers {
+			if symbol != "" && symbol != signal.Symbol {
+				continue
+			}
+			select {
+			case subscriber <- message:
+			default:
+				log.Println("Warning: gRPC signal client is not keeping up, dropping signal")
+			}
+		}
+		s.mu.Unlock()
+	}
+}
+
+// StartGRPCServer serves the strategies' signals to gRPC clients on port 50052
+func StartGRPCServer(signalChannel chan strategy.Signal) {
+	s := grpc.NewServer()
+	signalServer := &server{subscribers: map[chan *pb.Signal]string{}}
+	pb.RegisterSignalServiceServer(s, signalServer)
+
+	go signalServer.broadcast(signalChannel)
+
+	lis, err := net.Listen("tcp", ":50052")
+	if err != nil {
+		log.Fatalf("failed to listen: %v", err)
+	}
+
+	log.Println("gRPC signal server started on port 50052...")
+	if err := s.Serve(lis); err != nil {
+		log.Fatalf("failed to serve: %v", err)
+	}
+}
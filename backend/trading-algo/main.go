@@ -2,14 +2,32 @@ package main
 
 import (
 	"fmt"
+	"log"
 	"net/http"
+	_ "time/tzdata" // Embed the timezone database so VWAP_TIMEZONE works in slim images
 
+	"github.com/joho/godotenv"
+	"github.com/neozhixuan/project-visualgo-backend/streamHub"
 	"github.com/neozhixuan/project-visualgo-backend/trading-algo/grpcClient"
+	"github.com/neozhixuan/project-visualgo-backend/trading-algo/grpcServer"
+	"github.com/neozhixuan/project-visualgo-backend/trading-algo/restApi"
+	"github.com/neozhixuan/project-visualgo-backend/trading-algo/risk"
+	"github.com/neozhixuan/project-visualgo-backend/trading-algo/strategy"
 	"github.com/neozhixuan/project-visualgo-backend/trading-algo/websocketServer"
 )
 
-// Define a global channel to send ema9 data to the WebSocket server
-var ema9Channel = make(chan []float64, 10)
+// Define a global channel to send indicator updates to the WebSocket server
+// - a closed candle sends a message per changed indicator point, so it has room for a few candles' worth
+var updateChannel = make(chan websocketServer.Message, 256)
+
+// Define what has been streamed to WebSocket clients, so new clients start from a snapshot
+var wsStream = websocketServer.NewStream()
+
+// Define a global channel to send strategy signals to the gRPC server
+var signalChannel = make(chan strategy.Signal, 10)
+
+// Define the risk checks every paper and live order goes through, configured by the gRPC client once .env is loaded
+var riskManager = risk.NewManager(risk.Limits{})
 
 func healthCheckHandler(w http.ResponseWriter, r *http.Request) {
 	w.WriteHeader(http.StatusOK)
@@ -17,14 +35,36 @@ func healthCheckHandler(w http.ResponseWriter, r *http.Request) {
 }
 
 func main() {
+	// Load .env file before anything reads the environment, e.g. the WebSocket server's access settings
+	if err := godotenv.Load(); err != nil {
+		log.Fatalf("Error loading .env file: %v", err)
+	}
+
+	// Decide who may use the WebSocket server, its SSE streams and the REST API, and how often
+	// - WS_ALLOWED_ORIGINS, WS_JWT_SECRET and the other WS_ settings, see streamHub.AccessFromEnv
+	access := streamHub.AccessFromEnv()
+
+	// Keep every closed candle on disk, so strategies can be backtested on it later
+	store := grpcClient.LoadCandleStore()
+
 	// Start gRPC client in a separate goroutine
-	go grpcClient.StartGRPCClient(ema9Channel)
+	go grpcClient.StartGRPCClient(updateChannel, signalChannel, riskManager, wsStream, store)
+
+	// Start our own gRPC server on port 50052 to stream signals
+	go grpcServer.StartGRPCServer(signalChannel)
 
 	go http.HandleFunc("/health", healthCheckHandler)
 
+	// Admin endpoint to halt all trading, e.g. curl -X POST -H "Authorization: Bearer $ADMIN_TOKEN" -d '{"enabled": true}' localhost:8090/admin/killSwitch
+	http.Handle("/admin/killSwitch", riskManager.KillSwitchHandler())
+
+	// Serve the stored candles, and the indicators calculated on them, over REST next to the WebSocket server, to the same clients
+	vwapSession, vwapLocation := grpcClient.LoadVWAPSession()
+	http.Handle("/v1/", streamHub.Guard(access, restApi.NewServer(store, vwapSession, vwapLocation)))
+
 	// Start WebSocket server
 	// - this is not a goroutine so the server does not stop
-	websocketServer.StartWebSocketServer(ema9Channel)
+	websocketServer.StartWebSocketServer(updateChannel, wsStream, access)
 
 	// - Alternatively, create a blocking channel that triggers upon closure of client -
 	// done := make(chan bool)
@@ -0,0 +1,142 @@
+package mockExchange
+
+import (
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/neozhixuan/project-visualgo-backend/trading-algo/exchange"
+	"github.com/neozhixuan/project-visualgo-backend/trading-algo/orders"
+)
+
+// Helper function to read a single order from Binance's parameters
+func parseOrder(params url.Values) (orders.Request, error) {
+	request := orders.Request{
+		ClientOrderID: params.Get("newClientOrderId"),
+		Symbol:        params.Get("symbol"),
+		Side:          orders.Side(strings.ToLower(params.Get("side"))),
+		Quantity:      parseNumber(params.Get("quantity")),
+		LimitPrice:    parseNumber(params.Get("price")),
+		StopPrice:     parseNumber(params.Get("stopPrice")),
+		TrailingDelta: parseNumber(params.Get("trailingDelta")) / 10000,
+	}
+	if request.ClientOrderID == "" {
+		request.ClientOrderID = exchange.NewClientOrderID()
+	}
+
+	switch params.Get("type") {
+	case "MARKET":
+		request.Type = orders.Market
+	case "LIMIT":
+		request.Type = orders.Limit
+	case "STOP_LOSS":
+		request.Type = orders.Stop
+		if request.TrailingDelta > 0 && request.StopPrice == 0 {
+			request.Type = orders.TrailingStop
+		}
+	case "STOP_LOSS_LIMIT":
+		request.Type = orders.StopLimit
+	default:
+		return orders.Request{}, fmt.Errorf("unsupported order type %q", params.Get("type"))
+	}
+	return request, request.Validate()
+}
+
+// Helper function to read an OCO order from Binance's parameters, as a limit leg and a stop leg
+func parseOCO(params url.Values) (orders.Request, error) {
+	base := orders.Request{
+		Symbol:   params.Get("symbol"),
+		Side:     orders.Side(strings.ToLower(params.Get("side"))),
+		Quantity: parseNumber(params.Get("quantity")),
+	}
+
+	limit := base
+	limit.Type = orders.Limit
+	limit.ClientOrderID = params.Get("limitClientOrderId")
+	limit.LimitPrice = parseNumber(params.Get("price"))
+
+	stop := base
+	stop.ClientOrderID = params.Get("stopClientOrderId")
+	stop.StopPrice = parseNumber(params.Get("stopPrice"))
+	stop.LimitPrice = parseNumber(params.Get("stopLimitPrice"))
+	stop.TrailingDelta = parseNumber(params.Get("trailingDelta")) / 10000
+	switch {
+	case stop.LimitPrice > 0:
+		stop.Type = orders.StopLimit
+	case stop.StopPrice == 0 && stop.TrailingDelta > 0:
+		stop.Type = orders.TrailingStop
+	default:
+		stop.Type = orders.Stop
+	}
+
+	request := orders.Request{ClientOrderID: params.Get("listClientOrderId"), Type: orders.OCO, Legs: []orders.Request{limit, stop}}
+	if request.ClientOrderID == "" {
+		request.ClientOrderID = exchange.NewClientOrderID()
+	}
+	for i := range request.Legs {
+		if request.Legs[i].ClientOrderID == "" {
+			request.Legs[i].ClientOrderID = fmt.Sprintf("%s-%d", request.ClientOrderID, i+1)
+		}
+	}
+	return request, request.Validate()
+}
+
+// Helper function to write an order the way Binance does
+func toResponse(order orders.Order) exchange.OrderResponse {
+	response := exchange.OrderResponse{
+		Symbol:              order.Symbol,
+		OrderID:             idNumber(order.ID),
+		OrderListID:         -1,
+		ClientOrderID:       order.ClientOrderID,
+		Price:               formatNumber(order.LimitPrice),
+		OrigQty:             formatNumber(order.Quantity),
+		ExecutedQty:         formatNumber(order.FilledQuantity),
+		CummulativeQuoteQty: formatNumber(order.FilledQuantity * order.AveragePrice),
+		Status:              exchange.StatusToBinance[order.Status],
+		Type:                binanceTypes[order.Type],
+		Side:                strings.ToUpper(string(order.Side)),
+		StopPrice:           formatNumber(order.StopPrice),
+		TrailingDelta:       int64(order.TrailingDelta*10000 + 0.5),
+		TransactTime:        order.UpdatedAt,
+		Time:                order.CreatedAt,
+		UpdateTime:          order.UpdatedAt,
+	}
+	if order.OCOGroup != "" {
+		response.OrderListID = idNumber(order.OCOGroup)
+	}
+	if order.Type == orders.Limit || order.Type == orders.StopLimit {
+		response.TimeInForce = "GTC"
+	}
+	if order.Status == orders.Filled {
+		response.Fills = []exchange.Fill{{
+			Price:      formatNumber(order.AveragePrice),
+			Qty:        formatNumber(order.FilledQuantity),
+			Commission: formatNumber(order.Fee),
+		}}
+	}
+	return response
+}
+
+var binanceTypes = map[orders.Type]string{
+	orders.Market:       "MARKET",
+	orders.Limit:        "LIMIT",
+	orders.Stop:         "STOP_LOSS",
+	orders.StopLimit:    "STOP_LOSS_LIMIT",
+	orders.TrailingStop: "STOP_LOSS",
+}
+
+// Helper function to turn an engine id such as "mock-12" into Binance's numeric id
+func idNumber(id string) int64 {
+	number, _ := strconv.ParseInt(strings.TrimPrefix(id, "mock-"), 10, 64)
+	return number
+}
+
+func formatNumber(x float64) string {
+	return strconv.FormatFloat(x, 'f', -1, 64)
+}
+
+func parseNumber(s string) float64 {
+	x, _ := strconv.ParseFloat(s, 64)
+	return x
+}
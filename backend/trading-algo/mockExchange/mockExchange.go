@@ -0,0 +1,381 @@
+package mockExchange
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"math"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/neozhixuan/project-visualgo-backend/trading-algo/exchange"
+	"github.com/neozhixuan/project-visualgo-backend/trading-algo/financeFunctions"
+	"github.com/neozhixuan/project-visualgo-backend/trading-algo/orders"
+	"github.com/neozhixuan/project-visualgo-backend/trading-algo/paperTrading"
+)
+
+// Config of the mock exchange
+type Config struct {
+	APIKey      string
+	Secret      string
+	WeightLimit int // request weight allowed per minute, after which requests are answered with 429
+	OrderLimit  int // orders allowed per 10 seconds
+}
+
+// Server is a local stand-in for the parts of Binance's spot REST API that the exchange client uses
+// - requests must carry the configured API key and a valid signature, like on Binance
+// - orders are matched by a paper trading engine against prices set through POST /mock/price
+// - POST /mock/fail makes the next order placements go through but answer 503, to exercise idempotent retries
+type Server struct {
+	config Config
+	mux    *http.ServeMux
+
+	// Everything below is guarded by mu, which is also held whenever the engine is called
+	mu          sync.Mutex
+	engine      *paperTrading.Engine
+	orders      map[string]orders.Order // latest state of every order, by engine id
+	clientIDs   map[string]string       // engine id of every client order id, by symbol and client order id as on Binance
+	prices      map[string]float64
+	weightReset time.Time
+	weightUsed  int
+	orderReset  time.Time
+	orderCount  int
+	failures    int
+}
+
+// NewServer creates a mock exchange, with no prices until SetPrice is called
+func NewServer(config Config) *Server {
+	s := &Server{
+		config:    config,
+		mux:       http.NewServeMux(),
+		orders:    map[string]orders.Order{},
+		clientIDs: map[string]string{},
+		prices:    map[string]float64{},
+	}
+
+	// A practically unlimited account with Binance's fees, so the mock only rejects malformed orders
+	s.engine = paperTrading.NewEngine("mock", paperTrading.Config{InitialCash: 1e15, TakerFee: 0.001, MakerFee: 0.001, AllowShort: true}, s.onEngineUpdate)
+
+	s.mux.HandleFunc("GET /api/v3/ping", s.public(1, func(w http.ResponseWriter, _ url.Values) { writeJSON(w, struct{}{}) }))
+	s.mux.HandleFunc("GET /api/v3/time", s.public(1, s.handleTime))
+	s.mux.HandleFunc("GET /api/v3/ticker/price", s.public(2, s.handlePrice))
+	s.mux.HandleFunc("POST /api/v3/order", s.signed(1, 1, s.handlePlace))
+	s.mux.HandleFunc("POST /api/v3/order/oco", s.signed(1, 2, s.handlePlaceOCO))
+	s.mux.HandleFunc("DELETE /api/v3/order", s.signed(1, 0, s.handleCancel))
+	s.mux.HandleFunc("GET /api/v3/order", s.signed(4, 0, s.handleQuery))
+	s.mux.HandleFunc("GET /api/v3/openOrders", s.signed(6, 0, s.handleOpenOrders))
+	s.mux.HandleFunc("POST /mock/price", s.handleSetPrice)
+	s.mux.HandleFunc("POST /mock/fail", s.handleFail)
+	return s
+}
+
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mux.ServeHTTP(w, r)
+}
+
+// SetPrice moves the market price of `symbol`, filling every open order it reaches
+func (s *Server) SetPrice(symbol string, price float64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.prices[symbol] = price
+	s.engine.OnTick(symbol, financeFunctions.Tick{Price: price, Time: time.Now().UnixMilli()})
+}
+
+// FailNext makes the next `count` order placements go through on the exchange, but answer with a 503
+func (s *Server) FailNext(count int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.failures = count
+}
+
+// Keep the latest state of every order, as the engine reports it
+// - the engine is only ever called with mu held, so this is too
+func (s *Server) onEngineUpdate(messageType string, data interface{}) {
+	if order, ok := data.(orders.Order); ok {
+		s.orders[order.ID] = order
+		if order.ClientOrderID != "" {
+			s.clientIDs[clientKey(order.Symbol, order.ClientOrderID)] = order.ID
+		}
+	}
+}
+
+func (s *Server) handleTime(w http.ResponseWriter, _ url.Values) {
+	writeJSON(w, map[string]int64{"serverTime": time.Now().UnixMilli()})
+}
+
+func (s *Server) handlePrice(w http.ResponseWriter, params url.Values) {
+	symbol := params.Get("symbol")
+	price, ok := s.prices[symbol]
+	if !ok {
+		writeError(w, http.StatusBadRequest, -1121, "Invalid symbol.")
+		return
+	}
+	writeJSON(w, map[string]string{"symbol": symbol, "price": formatNumber(price)})
+}
+
+func (s *Server) handlePlace(w http.ResponseWriter, params url.Values) {
+	request, err := parseOrder(params)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, -1102, err.Error())
+		return
+	}
+	placed, ok := s.place(w, request)
+	if !ok {
+		return
+	}
+	writeJSON(w, toResponse(placed[0]))
+}
+
+func (s *Server) handlePlaceOCO(w http.ResponseWriter, params url.Values) {
+	request, err := parseOCO(params)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, -1102, err.Error())
+		return
+	}
+	placed, ok := s.place(w, request)
+	if !ok {
+		return
+	}
+
+	response := exchange.OCOResponse{
+		OrderListID:       idNumber(placed[0].OCOGroup),
+		ListClientOrderID: request.ClientOrderID,
+		Symbol:            placed[0].Symbol,
+	}
+	for _, order := range placed {
+		response.OrderReports = append(response.OrderReports, toResponse(order))
+	}
+	writeJSON(w, response)
+}
+
+// Helper function to place an order and match it against the current price, writing any error answer
+func (s *Server) place(w http.ResponseWriter, request orders.Request) ([]orders.Order, bool) {
+	symbol := request.Symbol
+	if request.Type == orders.OCO {
+		symbol = request.Legs[0].Symbol
+	}
+	price, ok := s.prices[symbol]
+	if !ok {
+		writeError(w, http.StatusBadRequest, -1121, "Invalid symbol.")
+		return nil, false
+	}
+	if _, exists := s.clientIDs[clientKey(symbol, request.ClientOrderID)]; exists {
+		writeError(w, http.StatusBadRequest, -2010, "Duplicate order sent.")
+		return nil, false
+	}
+
+	now := time.Now().UnixMilli()
+	placed, err := s.engine.Place(request, now)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, -1013, err.Error())
+		return nil, false
+	}
+	s.engine.OnTick(symbol, financeFunctions.Tick{Price: price, Time: now})
+
+	// Answer with the orders as they are after matching
+	for i := range placed {
+		placed[i] = s.orders[placed[i].ID]
+		if placed[i].Status == orders.Rejected {
+			writeError(w, http.StatusBadRequest, -2010, "Account has insufficient balance for requested action.")
+			return nil, false
+		}
+	}
+	if s.failures > 0 {
+		s.failures--
+		log.Printf("Mock exchange placed order %s but is answering 503 as asked", request.ClientOrderID)
+		http.Error(w, "Service Unavailable", http.StatusServiceUnavailable)
+		return nil, false
+	}
+	return placed, true
+}
+
+func (s *Server) handleCancel(w http.ResponseWriter, params url.Values) {
+	order, ok := s.find(params)
+	if !ok || order.Status.Done() {
+		writeError(w, http.StatusBadRequest, -2011, "Unknown order sent.")
+		return
+	}
+	if err := s.engine.Cancel(order.ID, time.Now().UnixMilli()); err != nil {
+		writeError(w, http.StatusBadRequest, -2011, "Unknown order sent.")
+		return
+	}
+	writeJSON(w, toResponse(s.orders[order.ID]))
+}
+
+func (s *Server) handleQuery(w http.ResponseWriter, params url.Values) {
+	order, ok := s.find(params)
+	if !ok {
+		writeError(w, http.StatusBadRequest, -2013, "Order does not exist.")
+		return
+	}
+	writeJSON(w, toResponse(order))
+}
+
+func (s *Server) handleOpenOrders(w http.ResponseWriter, params url.Values) {
+	symbol := params.Get("symbol")
+	open := []exchange.OrderResponse{}
+	for _, order := range s.orders {
+		if !order.Status.Done() && (symbol == "" || order.Symbol == symbol) {
+			open = append(open, toResponse(order))
+		}
+	}
+	sort.Slice(open, func(i, j int) bool { return open[i].OrderID < open[j].OrderID })
+	writeJSON(w, open)
+}
+
+// Helper function to key a client order id, which only has to be unique within its symbol
+func clientKey(symbol, clientOrderID string) string {
+	return symbol + " " + clientOrderID
+}
+
+// Helper function to find an order of a symbol by origClientOrderId or orderId
+func (s *Server) find(params url.Values) (orders.Order, bool) {
+	id := s.clientIDs[clientKey(params.Get("symbol"), params.Get("origClientOrderId"))]
+	if orderID := params.Get("orderId"); orderID != "" {
+		id = "mock-" + orderID
+	}
+	order, ok := s.orders[id]
+	if !ok || order.Symbol != params.Get("symbol") {
+		return orders.Order{}, false
+	}
+	return order, true
+}
+
+func (s *Server) handleSetPrice(w http.ResponseWriter, r *http.Request) {
+	price, err := strconv.ParseFloat(r.FormValue("price"), 64)
+	if err != nil || !(price > 0) || r.FormValue("symbol") == "" {
+		writeError(w, http.StatusBadRequest, -1100, "Expected a symbol and a positive price.")
+		return
+	}
+	s.SetPrice(r.FormValue("symbol"), price)
+	writeJSON(w, struct{}{})
+}
+
+func (s *Server) handleFail(w http.ResponseWriter, r *http.Request) {
+	count, err := strconv.Atoi(r.FormValue("count"))
+	if err != nil || count < 0 {
+		writeError(w, http.StatusBadRequest, -1100, "Expected a count.")
+		return
+	}
+	s.FailNext(count)
+	writeJSON(w, struct{}{})
+}
+
+// Helper function to wrap an endpoint that needs no signature
+func (s *Server) public(weight int, handle func(http.ResponseWriter, url.Values)) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		if !s.countRequest(w, weight, 0) {
+			return
+		}
+		handle(w, r.URL.Query())
+	}
+}
+
+// Helper function to wrap an endpoint that needs the API key and a valid signature, like Binance's TRADE and USER_DATA endpoints
+func (s *Server) signed(weight, orderCount int, handle func(http.ResponseWriter, url.Values)) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, -1100, "Could not read the request body.")
+			return
+		}
+
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		if !s.countRequest(w, weight, orderCount) {
+			return
+		}
+		if r.Header.Get("X-MBX-APIKEY") != s.config.APIKey {
+			writeError(w, http.StatusUnauthorized, -2014, "API-key format invalid.")
+			return
+		}
+		params, err := s.verify(r.URL.RawQuery, string(body))
+		if err != nil {
+			writeError(w, http.StatusBadRequest, -1022, err.Error())
+			return
+		}
+
+		timestamp, err := strconv.ParseInt(params.Get("timestamp"), 10, 64)
+		recvWindow, windowErr := strconv.ParseInt(params.Get("recvWindow"), 10, 64)
+		if windowErr != nil {
+			recvWindow = 5000
+		}
+		now := time.Now().UnixMilli()
+		if err != nil || timestamp > now+1000 || now-timestamp > recvWindow {
+			writeError(w, http.StatusBadRequest, -1021, "Timestamp for this request is outside of the recvWindow.")
+			return
+		}
+		handle(w, params)
+	}
+}
+
+// Helper function to check a request's signature, which covers the query string followed by the body
+func (s *Server) verify(rawQuery, body string) (url.Values, error) {
+	var signature string
+	var signedParts []string
+	for _, part := range strings.Split(rawQuery, "&") {
+		if value, ok := strings.CutPrefix(part, "signature="); ok {
+			signature = value
+			continue
+		}
+		signedParts = append(signedParts, part)
+	}
+	payload := strings.Join(signedParts, "&") + body
+	if signature == "" || signature != exchange.Sign(s.config.Secret, payload) {
+		return nil, fmt.Errorf("Signature for this request is not valid.")
+	}
+
+	params, err := url.ParseQuery(payload)
+	if err != nil {
+		return nil, err
+	}
+	return params, nil
+}
+
+// Helper function to apply the rate limits, answering 429 once one is used up
+func (s *Server) countRequest(w http.ResponseWriter, weight, orderCount int) bool {
+	now := time.Now()
+	if now.After(s.weightReset) {
+		s.weightReset, s.weightUsed = now.Truncate(time.Minute).Add(time.Minute), 0
+	}
+	if now.After(s.orderReset) {
+		s.orderReset, s.orderCount = now.Truncate(10*time.Second).Add(10*time.Second), 0
+	}
+	s.weightUsed += weight
+	s.orderCount += orderCount
+	w.Header().Set("X-MBX-USED-WEIGHT-1M", strconv.Itoa(s.weightUsed))
+	w.Header().Set("X-MBX-ORDER-COUNT-10S", strconv.Itoa(s.orderCount))
+
+	retryAfter := time.Duration(0)
+	if s.config.WeightLimit > 0 && s.weightUsed > s.config.WeightLimit {
+		retryAfter = time.Until(s.weightReset)
+	}
+	if s.config.OrderLimit > 0 && s.orderCount > s.config.OrderLimit {
+		retryAfter = max(retryAfter, time.Until(s.orderReset))
+	}
+	if retryAfter > 0 {
+		w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(retryAfter.Seconds()))))
+		writeError(w, http.StatusTooManyRequests, -1003, "Too many requests; please use the websocket for live updates.")
+		return false
+	}
+	return true
+}
+
+func writeJSON(w http.ResponseWriter, data interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(data)
+}
+
+func writeError(w http.ResponseWriter, status, code int, message string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(exchange.APIError{Code: code, Message: message})
+}
@@ -0,0 +1,53 @@
+package optimize
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/neozhixuan/project-visualgo-backend/trading-algo/backtest"
+)
+
+// Metric is what results are ranked by, higher scores being better
+type Metric string
+
+const (
+	Sharpe      Metric = "sharpe"
+	Sortino     Metric = "sortino"
+	TotalReturn Metric = "totalReturn"
+	CAGR        Metric = "cagr"
+	MaxDrawdown Metric = "maxDrawdown" // scored negated, so the smallest drawdown ranks first
+	WinRate     Metric = "winRate"
+)
+
+var metrics = []Metric{Sharpe, Sortino, TotalReturn, CAGR, MaxDrawdown, WinRate}
+
+// ParseMetric reads a metric name, e.g. "sharpe"
+func ParseMetric(value string) (Metric, error) {
+	for _, metric := range metrics {
+		if string(metric) == value {
+			return metric, nil
+		}
+	}
+	names := make([]string, len(metrics))
+	for i, metric := range metrics {
+		names[i] = string(metric)
+	}
+	return "", fmt.Errorf("unknown metric %q, expected one of %s", value, strings.Join(names, ", "))
+}
+
+// Score returns how well a backtest did by the metric
+func (m Metric) Score(report backtest.Report) float64 {
+	switch m {
+	case Sortino:
+		return report.Sortino
+	case TotalReturn:
+		return report.TotalReturn
+	case CAGR:
+		return report.CAGR
+	case MaxDrawdown:
+		return -report.MaxDrawdown
+	case WinRate:
+		return report.WinRate
+	}
+	return report.Sharpe
+}
@@ -0,0 +1,274 @@
+package optimize
+
+import (
+	"fmt"
+	"math"
+	"runtime"
+	"sort"
+	"sync"
+
+	"github.com/neozhixuan/project-visualgo-backend/trading-algo/backtest"
+	"github.com/neozhixuan/project-visualgo-backend/trading-algo/financeFunctions"
+	"github.com/neozhixuan/project-visualgo-backend/trading-algo/strategy"
+)
+
+// Method is how combinations are picked from the search space
+type Method string
+
+const (
+	Grid   Method = "grid"   // every combination
+	Random Method = "random" // Samples combinations drawn at random
+)
+
+// Largest grid searched, beyond which a random search is needed
+const maxGridSize = 100000
+
+// Config of an optimization
+type Config struct {
+	Strategy    string
+	Space       Space
+	Method      Method
+	Samples     int   // combinations tried by a random search
+	Seed        int64 // seed of a random search, so it can be repeated
+	Metric      Metric
+	Backtest    backtest.Config
+	Workers     int // backtests run in parallel, 0 for one per CPU core
+	Top         int // results kept in the ranking, 0 for all
+	WalkForward WalkForward
+}
+
+// WalkForward splits the candles into windows that are each optimized in-sample and then tested out-of-sample on the candles right after
+// - rolling windows move the in-sample period along, anchored windows all start at the first candle
+type WalkForward struct {
+	Windows       int     // 0 turns walk-forward analysis off
+	InSampleRatio float64 // share of a rolling window that is in-sample, e.g. 0.75
+	Anchored      bool
+}
+
+// Summary holds the headline numbers of a backtest
+type Summary struct {
+	TotalReturn float64 `json:"totalReturn"`
+	CAGR        float64 `json:"cagr"`
+	Sharpe      float64 `json:"sharpe"`
+	Sortino     float64 `json:"sortino"`
+	MaxDrawdown float64 `json:"maxDrawdown"`
+	WinRate     float64 `json:"winRate"`
+	Exposure    float64 `json:"exposure"`
+	Trades      int     `json:"trades"`
+}
+
+// Result is the backtest of one combination of parameters
+type Result struct {
+	Params  strategy.Params `json:"params"`
+	Score   float64         `json:"score"`
+	Summary Summary         `json:"summary"`
+}
+
+// Period is a span of candles, in Unix milliseconds
+type Period struct {
+	Start int64 `json:"start"`
+	End   int64 `json:"end"`
+}
+
+// Window is one step of a walk-forward analysis
+type Window struct {
+	InSample     Period `json:"inSample"`
+	OutOfSample  Period `json:"outOfSample"`
+	Best         Result `json:"best"`         // the best combination in-sample
+	Tested       Result `json:"tested"`       // the same combination, out-of-sample
+	Combinations int    `json:"combinations"` // combinations backtested in-sample
+}
+
+// Outcome of an optimization
+type Outcome struct {
+	Strategy     string   `json:"strategy"`
+	Metric       Metric   `json:"metric"`
+	Method       Method   `json:"method"`
+	Combinations int      `json:"combinations"` // combinations backtested on all candles
+	Results      []Result `json:"results"`      // best first
+	WalkForward  []Window `json:"walkForward,omitempty"`
+	// Mean in-sample and out-of-sample scores of the walk-forward windows, and their ratio
+	InSampleScore    float64  `json:"inSampleScore,omitempty"`
+	OutOfSampleScore float64  `json:"outOfSampleScore,omitempty"`
+	Efficiency       float64  `json:"efficiency,omitempty"`
+	Warnings         []string `json:"warnings"` // signs that the best result is overfit to the data
+}
+
+// Run backtests combinations of parameters on `candles`, ranks them by the metric, and checks the best for overfitting
+func Run(candles []financeFunctions.Candlestick, config Config) (Outcome, error) {
+	definition, ok := strategy.Lookup(config.Strategy)
+	if !ok {
+		return Outcome{}, fmt.Errorf("unknown strategy %q", config.Strategy)
+	}
+	for name := range config.Space {
+		if _, ok := definition.Defaults[name]; !ok {
+			return Outcome{}, fmt.Errorf("strategy %q has no parameter %q", config.Strategy, name)
+		}
+	}
+	if config.Workers <= 0 {
+		config.Workers = runtime.NumCPU()
+	}
+
+	var combinations []strategy.Params
+	switch config.Method {
+	case Grid:
+		var err error
+		if combinations, err = config.Space.grid(maxGridSize); err != nil {
+			return Outcome{}, err
+		}
+	case Random:
+		combinations = config.Space.random(config.Samples, config.Seed)
+	default:
+		return Outcome{}, fmt.Errorf("unknown method %q, expected grid or random", config.Method)
+	}
+
+	results := evaluate(candles, 0, combinations, config)
+	if len(results) == 0 {
+		return Outcome{}, fmt.Errorf("no combination could be backtested, check the parameters are valid for %s", config.Strategy)
+	}
+	outcome := Outcome{
+		Strategy:     config.Strategy,
+		Metric:       config.Metric,
+		Method:       config.Method,
+		Combinations: len(results),
+		Results:      results,
+		Warnings:     []string{},
+	}
+	if config.Top > 0 && len(outcome.Results) > config.Top {
+		outcome.Results = outcome.Results[:config.Top]
+	}
+
+	if config.WalkForward.Windows > 0 {
+		if err := walkForward(candles, combinations, config, &outcome); err != nil {
+			return Outcome{}, err
+		}
+	}
+	outcome.Warnings = append(outcome.Warnings, warnings(results, config, outcome)...)
+	return outcome, nil
+}
+
+// Helper function to run a walk-forward analysis, filling in the outcome's windows and scores
+func walkForward(candles []financeFunctions.Candlestick, combinations []strategy.Params, config Config, outcome *Outcome) error {
+	ratio := config.WalkForward.InSampleRatio
+	if !(ratio > 0 && ratio < 1) {
+		return fmt.Errorf("in-sample ratio must be in (0, 1), got %v", ratio)
+	}
+	// The in-sample period takes `ratio` of a window, and the out-of-sample periods of every window together cover the rest
+	windows := config.WalkForward.Windows
+	outOfSample := int(float64(len(candles)) / (float64(windows) + ratio/(1-ratio)))
+	inSample := len(candles) - windows*outOfSample
+	if outOfSample < 2 || inSample < 2 {
+		return fmt.Errorf("%d candles are too few for %d walk-forward windows", len(candles), windows)
+	}
+
+	var inSampleTotal, outOfSampleTotal float64
+	for i := 0; i < windows; i++ {
+		start := i * outOfSample
+		if config.WalkForward.Anchored {
+			start = 0
+		}
+		split := inSample + i*outOfSample
+		end := split + outOfSample
+
+		ranked := evaluate(candles[start:split], 0, combinations, config)
+		if len(ranked) == 0 {
+			return fmt.Errorf("no combination could be backtested in walk-forward window %d", i+1)
+		}
+		best := ranked[0]
+
+		// The out-of-sample test sees the in-sample candles as warm up, as the live service would have
+		tested := evaluate(candles[start:end], split-start, []strategy.Params{best.Params}, config)
+		if len(tested) == 0 {
+			return fmt.Errorf("could not test walk-forward window %d out-of-sample", i+1)
+		}
+
+		outcome.WalkForward = append(outcome.WalkForward, Window{
+			InSample:     Period{candles[start].OpenTime, candles[split-1].CloseTime},
+			OutOfSample:  Period{candles[split].OpenTime, candles[end-1].CloseTime},
+			Best:         best,
+			Tested:       tested[0],
+			Combinations: len(ranked),
+		})
+		inSampleTotal += best.Score
+		outOfSampleTotal += tested[0].Score
+	}
+
+	outcome.InSampleScore = inSampleTotal / float64(windows)
+	outcome.OutOfSampleScore = outOfSampleTotal / float64(windows)
+	if outcome.InSampleScore > 0 {
+		outcome.Efficiency = outcome.OutOfSampleScore / outcome.InSampleScore
+	}
+	return nil
+}
+
+// Helper function to backtest every combination on all CPU cores, returning the results best first
+// - combinations the strategy rejects (e.g. a fast EMA slower than the slow one) are skipped
+func evaluate(candles []financeFunctions.Candlestick, warmUp int, combinations []strategy.Params, config Config) []Result {
+	jobs := make(chan strategy.Params)
+	var mu sync.Mutex
+	var results []Result
+
+	var wg sync.WaitGroup
+	for w := 0; w < config.Workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			backtestConfig := config.Backtest
+			backtestConfig.WarmUp = warmUp
+			for params := range jobs {
+				factory, err := strategy.NewFactory(config.Strategy, params)
+				if err != nil {
+					continue
+				}
+				report, err := backtest.Run(candles, factory, backtestConfig)
+				if err != nil {
+					continue
+				}
+				result := Result{Params: params, Score: config.Metric.Score(report), Summary: summarize(report)}
+				mu.Lock()
+				results = append(results, result)
+				mu.Unlock()
+			}
+		}()
+	}
+	for _, params := range combinations {
+		jobs <- params
+	}
+	close(jobs)
+	wg.Wait()
+
+	// Ties are broken by parameters, so the ranking does not depend on which worker finished first
+	sort.Slice(results, func(i, j int) bool {
+		if results[i].Score != results[j].Score {
+			return results[i].Score > results[j].Score
+		}
+		return results[i].Params.Key() < results[j].Params.Key()
+	})
+	return results
+}
+
+// Helper function to keep the headline numbers of a report
+func summarize(report backtest.Report) Summary {
+	return Summary{
+		TotalReturn: report.TotalReturn,
+		CAGR:        report.CAGR,
+		Sharpe:      report.Sharpe,
+		Sortino:     report.Sortino,
+		MaxDrawdown: report.MaxDrawdown,
+		WinRate:     report.WinRate,
+		Exposure:    report.Exposure,
+		Trades:      len(report.Trades),
+	}
+}
+
+// Helper function to get the mean of values
+func mean(values []float64) float64 {
+	if len(values) == 0 {
+		return math.NaN()
+	}
+	sum := 0.0
+	for _, value := range values {
+		sum += value
+	}
+	return sum / float64(len(values))
+}
@@ -0,0 +1,148 @@
+package optimize
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/neozhixuan/project-visualgo-backend/trading-algo/strategy"
+)
+
+// Range is the values a parameter is searched over, from Min to Max in steps of Step
+type Range struct {
+	Min  float64 `json:"min"`
+	Max  float64 `json:"max"`
+	Step float64 `json:"step"`
+}
+
+// Values lists every value of the range, Min and Max included
+// - check Count first, as a range with a small step can have more values than fit in memory
+func (r Range) Values() []float64 {
+	values := make([]float64, r.Count())
+	for i := range values {
+		values[i] = r.value(int64(i))
+	}
+	return values
+}
+
+// Count returns the number of values of the range without listing them, at most math.MaxInt64
+func (r Range) Count() int64 {
+	if r.Step <= 0 || r.Max <= r.Min {
+		return 1
+	}
+	count := math.Floor((r.Max-r.Min)/r.Step+1e-9) + 1 // a Max missed by floating point noise is still included
+	if count >= math.MaxInt64 {
+		return math.MaxInt64
+	}
+	return int64(count)
+}
+
+// Helper function to get the i-th value of the range
+func (r Range) value(i int64) float64 {
+	if r.Step <= 0 || r.Max <= r.Min {
+		return r.Min
+	}
+	value := r.Min + float64(i)*r.Step
+	return math.Round(value*1e9) / 1e9 // drop floating point noise, e.g. 0.30000000000000004
+}
+
+// Space is the range of every searched parameter, where parameters left out keep their defaults
+type Space map[string]Range
+
+// ParseSpace reads a search space such as "fast=5:20:1,slow=20:60:5", where "fast=9" fixes a parameter
+func ParseSpace(value string) (Space, error) {
+	space := Space{}
+	for _, entry := range strings.Split(value, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		name, spec, ok := strings.Cut(entry, "=")
+		if !ok {
+			return nil, fmt.Errorf("expected name=min:max:step, got %q", entry)
+		}
+		parts := strings.Split(spec, ":")
+		numbers := make([]float64, len(parts))
+		for i, part := range parts {
+			number, err := strconv.ParseFloat(part, 64)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %w", name, err)
+			}
+			numbers[i] = number
+		}
+		switch len(numbers) {
+		case 1:
+			space[name] = Range{Min: numbers[0], Max: numbers[0]}
+		case 3:
+			if numbers[1] < numbers[0] || numbers[2] <= 0 {
+				return nil, fmt.Errorf("%s: expected min <= max and a positive step", name)
+			}
+			space[name] = Range{Min: numbers[0], Max: numbers[1], Step: numbers[2]}
+		default:
+			return nil, fmt.Errorf("%s: expected a value or min:max:step, got %q", name, spec)
+		}
+	}
+	return space, nil
+}
+
+// Helper function to list the searched parameters, sorted
+func (s Space) names() []string {
+	names := make([]string, 0, len(s))
+	for name := range s {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Helper function to list every combination of the space
+func (s Space) grid(limit int) ([]strategy.Params, error) {
+	// Count the combinations before listing any, as a huge range would not fit in memory
+	size := 1.0
+	for _, name := range s.names() {
+		size *= float64(s[name].Count())
+		if size > float64(limit) {
+			return nil, fmt.Errorf("the grid has over %d combinations, use a random search or larger steps", limit)
+		}
+	}
+
+	combinations := []strategy.Params{{}}
+	for _, name := range s.names() {
+		values := s[name].Values()
+		next := make([]strategy.Params, 0, len(combinations)*len(values))
+		for _, combination := range combinations {
+			for _, value := range values {
+				params := strategy.Params{name: value}
+				for key, existing := range combination {
+					params[key] = existing
+				}
+				next = append(next, params)
+			}
+		}
+		combinations = next
+	}
+	return combinations, nil
+}
+
+// Helper function to draw up to `samples` distinct combinations of the space at random
+func (s Space) random(samples int, seed int64) []strategy.Params {
+	generator := rand.New(rand.NewSource(seed))
+	names := s.names()
+	seen := map[string]bool{}
+	var combinations []strategy.Params
+	for attempt := 0; len(combinations) < samples && attempt < samples*20; attempt++ {
+		params := strategy.Params{}
+		for _, name := range names {
+			r := s[name]
+			params[name] = r.value(generator.Int63n(r.Count()))
+		}
+		if key := params.Key(); !seen[key] {
+			seen[key] = true
+			combinations = append(combinations, params)
+		}
+	}
+	return combinations
+}
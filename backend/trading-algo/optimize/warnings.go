@@ -0,0 +1,89 @@
+package optimize
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/neozhixuan/project-visualgo-backend/trading-algo/strategy"
+)
+
+// Thresholds of the overfitting warnings
+const (
+	minTrades          = 30  // fewer trades than this are too few to tell skill from luck
+	manyCombinations   = 50  // trying more combinations than this on the same data favours lucky ones
+	minEfficiency      = 0.5 // out-of-sample scores below this share of in-sample scores suggest overfitting
+	minNeighbourShare  = 0.5 // neighbours of the best grid point scoring below this share of it make it an isolated peak
+	maxParameterSpread = 0.5 // best values moving across more than this share of a range between windows are unstable
+)
+
+// Helper function to list the signs that the best result is overfit
+func warnings(results []Result, config Config, outcome Outcome) []string {
+	var warnings []string
+	best := results[0]
+
+	if best.Summary.Trades < minTrades {
+		warnings = append(warnings, fmt.Sprintf("the best combination made only %d trades, too few to tell skill from luck", best.Summary.Trades))
+	}
+	if len(results) > manyCombinations && config.WalkForward.Windows == 0 {
+		warnings = append(warnings, fmt.Sprintf("%d combinations were tried on the same candles, so the best is likely to be partly luck; confirm it with a walk-forward analysis", len(results)))
+	}
+	if config.Method == Grid {
+		if warning := isolatedPeak(results, config.Space); warning != "" {
+			warnings = append(warnings, warning)
+		}
+	}
+
+	if len(outcome.WalkForward) > 0 {
+		if outcome.InSampleScore > 0 && outcome.Efficiency < minEfficiency {
+			warnings = append(warnings, fmt.Sprintf("out-of-sample %s is %.0f%% of in-sample, the parameters do not carry over to unseen data", config.Metric, outcome.Efficiency*100))
+		}
+		for _, name := range config.Space.names() {
+			span := config.Space[name].Max - config.Space[name].Min
+			low, high := math.Inf(1), math.Inf(-1)
+			for _, window := range outcome.WalkForward {
+				low, high = math.Min(low, window.Best.Params[name]), math.Max(high, window.Best.Params[name])
+			}
+			if span > 0 && (high-low)/span > maxParameterSpread {
+				warnings = append(warnings, fmt.Sprintf("the best %s moves between %v and %v across walk-forward windows, it is not stable", name, low, high))
+			}
+		}
+	}
+	return warnings
+}
+
+// Helper function to warn when the best grid point scores far above the points next to it
+// - a robust optimum sits on a plateau, while an isolated peak is most likely noise
+func isolatedPeak(results []Result, space Space) string {
+	best := results[0]
+	if best.Score <= 0 {
+		return ""
+	}
+	scores := map[string]float64{}
+	for _, result := range results {
+		scores[result.Params.Key()] = result.Score
+	}
+
+	var neighbours []float64
+	for _, name := range space.names() {
+		for _, step := range []float64{-space[name].Step, space[name].Step} {
+			if step == 0 {
+				continue
+			}
+			neighbour := strategy.Params{}
+			for key, value := range best.Params {
+				neighbour[key] = value
+			}
+			neighbour[name] = math.Round((neighbour[name]+step)*1e9) / 1e9
+			if score, ok := scores[neighbour.Key()]; ok {
+				neighbours = append(neighbours, score)
+			}
+		}
+	}
+	if len(neighbours) == 0 {
+		return ""
+	}
+	if average := mean(neighbours); average < best.Score*minNeighbourShare {
+		return fmt.Sprintf("the best combination is an isolated peak: its neighbours score %.3g on average against its %.3g", average, best.Score)
+	}
+	return ""
+}
@@ -0,0 +1,148 @@
+package orders
+
+import (
+	"fmt"
+	"math"
+)
+
+// Side is the direction of an order
+type Side string
+
+const (
+	Buy  Side = "buy"
+	Sell Side = "sell"
+)
+
+// Sign returns 1 for buys and -1 for sells, the direction the order moves a position
+func (s Side) Sign() float64 {
+	if s == Sell {
+		return -1
+	}
+	return 1
+}
+
+// Opposite returns the side that closes a position opened by `s`
+func (s Side) Opposite() Side {
+	if s == Buy {
+		return Sell
+	}
+	return Buy
+}
+
+// Type decides when and at what price an order fills
+type Type string
+
+const (
+	Market       Type = "market"       // fill at the next traded price
+	Limit        Type = "limit"        // fill at LimitPrice or better
+	Stop         Type = "stop"         // become a market order once the price reaches StopPrice
+	StopLimit    Type = "stopLimit"    // become a limit order at LimitPrice once the price reaches StopPrice
+	TrailingStop Type = "trailingStop" // a stop that follows the best price since placement at a distance of TrailingDelta
+	OCO          Type = "oco"          // two Legs, where one filling cancels the other
+)
+
+// Status is where an order is in its life
+type Status string
+
+const (
+	Open            Status = "open"            // waiting to fill, or for its stop to trigger
+	Triggered       Status = "triggered"       // a stop order whose stop price was reached, now working as a market or limit order
+	PartiallyFilled Status = "partiallyFilled" // some, but not all, of the quantity has filled
+	Filled          Status = "filled"
+	Canceled        Status = "canceled"
+	Rejected        Status = "rejected"
+)
+
+// Done reports whether an order in this status can no longer fill
+func (s Status) Done() bool {
+	return s == Filled || s == Canceled || s == Rejected
+}
+
+// Request is an order as placed by a strategy or a user
+type Request struct {
+	ClientOrderID string    `json:"clientOrderId,omitempty"` // chosen by the caller, unique per account
+	Symbol        string    `json:"symbol"`
+	Side          Side      `json:"side"`
+	Type          Type      `json:"type"`
+	Quantity      float64   `json:"quantity"`
+	LimitPrice    float64   `json:"limitPrice,omitempty"`    // limit and stopLimit orders
+	StopPrice     float64   `json:"stopPrice,omitempty"`     // stop and stopLimit orders
+	TrailingDelta float64   `json:"trailingDelta,omitempty"` // trailingStop orders, as a fraction of the price, e.g. 0.01 for 1%
+	Legs          []Request `json:"legs,omitempty"`          // OCO orders, the two orders that cancel each other
+	Reason        string    `json:"reason,omitempty"`        // human readable explanation, e.g. "stop loss"
+}
+
+// Validate checks a request has every field its type needs
+func (r Request) Validate() error {
+	if r.Type == OCO {
+		if len(r.Legs) != 2 {
+			return fmt.Errorf("oco order needs exactly 2 legs, got %d", len(r.Legs))
+		}
+		for i, leg := range r.Legs {
+			if leg.Type == Market || leg.Type == OCO {
+				return fmt.Errorf("oco leg %d cannot be a %s order", i+1, leg.Type)
+			}
+			if leg.Symbol != r.Legs[0].Symbol || leg.Side != r.Legs[0].Side {
+				return fmt.Errorf("oco legs must share a symbol and side")
+			}
+			if err := leg.Validate(); err != nil {
+				return fmt.Errorf("oco leg %d: %w", i+1, err)
+			}
+		}
+		return nil
+	}
+
+	switch {
+	case r.Symbol == "":
+		return fmt.Errorf("order needs a symbol")
+	case r.Side != Buy && r.Side != Sell:
+		return fmt.Errorf("invalid side %q, expected buy or sell", r.Side)
+	case !positive(r.Quantity):
+		return fmt.Errorf("quantity must be positive, got %v", r.Quantity)
+	}
+
+	switch r.Type {
+	case Market:
+	case Limit:
+		if !positive(r.LimitPrice) {
+			return fmt.Errorf("limit order needs a positive limit price")
+		}
+	case Stop:
+		if !positive(r.StopPrice) {
+			return fmt.Errorf("stop order needs a positive stop price")
+		}
+	case StopLimit:
+		if !positive(r.StopPrice) || !positive(r.LimitPrice) {
+			return fmt.Errorf("stop limit order needs positive stop and limit prices")
+		}
+	case TrailingStop:
+		if !(r.TrailingDelta > 0 && r.TrailingDelta < 1) {
+			return fmt.Errorf("trailing stop needs a trailing delta in (0, 1), got %v", r.TrailingDelta)
+		}
+	default:
+		return fmt.Errorf("invalid order type %q", r.Type)
+	}
+	return nil
+}
+
+// Order is a placed order and how far it has filled
+// - the legs of an OCO order are placed as two orders sharing an OCOGroup
+type Order struct {
+	Request
+	ID             string  `json:"id"`
+	Account        string  `json:"account"`
+	Status         Status  `json:"status"`
+	OCOGroup       string  `json:"ocoGroup,omitempty"`
+	TrailingStop   float64 `json:"trailingStop,omitempty"` // current stop price of a trailing stop
+	FilledQuantity float64 `json:"filledQuantity"`
+	AveragePrice   float64 `json:"averagePrice,omitempty"` // average fill price
+	Fee            float64 `json:"fee"`
+	RejectReason   string  `json:"rejectReason,omitempty"`
+	CreatedAt      int64   `json:"createdAt"` // Unix milliseconds
+	UpdatedAt      int64   `json:"updatedAt"` // Unix milliseconds
+}
+
+// Helper function to check a number is positive and finite
+func positive(x float64) bool {
+	return x > 0 && !math.IsInf(x, 1)
+}
@@ -0,0 +1,585 @@
+package paperTrading
+
+import (
+	"fmt"
+	"math"
+	"sync"
+
+	"github.com/neozhixuan/project-visualgo-backend/trading-algo/financeFunctions"
+	"github.com/neozhixuan/project-visualgo-backend/trading-algo/orders"
+	"github.com/neozhixuan/project-visualgo-backend/trading-algo/risk"
+)
+
+// Config of a paper trading account
+type Config struct {
+	InitialCash float64 `json:"initialCash"`
+	TakerFee    float64 `json:"takerFee"`   // fee rate of fills that take liquidity: market orders, triggered stops and marketable limits
+	MakerFee    float64 `json:"makerFee"`   // fee rate of limit orders that rested on the book before filling
+	Slippage    float64 `json:"slippage"`   // fraction that taker fills move against us
+	AllowShort  bool    `json:"allowShort"` // whether sells can take a position below zero
+}
+
+// DefaultConfig returns a 10,000 account paying Binance's spot fees
+func DefaultConfig() Config {
+	return Config{InitialCash: 10000, TakerFee: 0.001, MakerFee: 0.001, Slippage: 0.0005}
+}
+
+// Position held in one symbol
+type Position struct {
+	Account       string  `json:"account"`
+	Symbol        string  `json:"symbol"`
+	Quantity      float64 `json:"quantity"` // positive when long, negative when short
+	AveragePrice  float64 `json:"averagePrice"`
+	MarkPrice     float64 `json:"markPrice"`
+	RealizedPnL   float64 `json:"realizedPnl"` // before fees
+	UnrealizedPnL float64 `json:"unrealizedPnl"`
+	UpdatedAt     int64   `json:"updatedAt"`
+}
+
+// Balance of a paper trading account
+type Balance struct {
+	Account       string  `json:"account"`
+	Cash          float64 `json:"cash"`
+	Equity        float64 `json:"equity"` // cash plus every position at its mark price
+	Fees          float64 `json:"fees"`
+	RealizedPnL   float64 `json:"realizedPnl"` // before fees
+	UnrealizedPnL float64 `json:"unrealizedPnl"`
+	UpdatedAt     int64   `json:"updatedAt"`
+}
+
+// Listener receives every change to an account
+// - messageType is "order", "position" or "balance", with an orders.Order, Position or Balance as data
+type Listener func(messageType string, data interface{})
+
+// Engine is a paper trading account, filling orders against the live price stream
+// - fills are all or nothing, as a single account's orders are small next to the market
+// - market orders fill at the next tick, never at a price seen before they were placed
+// - a limit order fills at the first tick if marketable (as a taker), and after that only once the price trades through it (as a maker)
+type Engine struct {
+	name     string
+	config   Config
+	listener Listener
+	risk     *risk.Manager // checks every order before it is accepted, if set
+
+	mu        sync.Mutex
+	cash      float64
+	fees      float64
+	positions map[string]*Position
+	open      []*working // open orders, oldest first
+	lastPrice map[string]float64
+	clientIDs map[string]bool
+	nextID    int
+	events    []event // changes waiting to be sent once the lock is released
+
+	day            int64   // UTC day the daily profit is counted from, in days since the Unix epoch
+	dayStartEquity float64 // equity at the start of that day
+}
+
+// An open order and the state needed to fill it
+type working struct {
+	order   orders.Order
+	resting bool    // whether the order has seen a tick without filling, making a limit fill a maker fill
+	extreme float64 // best price seen by a trailing stop
+}
+
+type event struct {
+	messageType string
+	data        interface{}
+}
+
+// NewEngine creates an account called `name`, sending its changes to `listener`
+func NewEngine(name string, config Config, listener Listener) *Engine {
+	if listener == nil {
+		listener = func(string, interface{}) {}
+	}
+	return &Engine{
+		name:      name,
+		config:    config,
+		listener:  listener,
+		cash:      config.InitialCash,
+		positions: map[string]*Position{},
+		lastPrice: map[string]float64{},
+		clientIDs: map[string]bool{},
+	}
+}
+
+// SetRisk makes every order pass the risk checks of `manager` before it is accepted
+func (e *Engine) SetRisk(manager *risk.Manager) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.risk = manager
+}
+
+// Place submits an order, returning the orders created (two for an OCO order)
+// - an order failing the risk checks is sent to the listener as rejected, and its *risk.Rejection returned
+func (e *Engine) Place(request orders.Request, now int64) ([]orders.Order, error) {
+	if err := request.Validate(); err != nil {
+		return nil, err
+	}
+
+	e.mu.Lock()
+	if request.ClientOrderID != "" && e.clientIDs[request.ClientOrderID] {
+		e.mu.Unlock()
+		return nil, fmt.Errorf("duplicate client order id %q", request.ClientOrderID)
+	}
+	if err := e.checkRisk(request, now); err != nil {
+		events := e.drain()
+		e.mu.Unlock()
+		e.send(events)
+		return nil, err
+	}
+	e.clientIDs[request.ClientOrderID] = request.ClientOrderID != ""
+
+	legs, group := []orders.Request{request}, ""
+	if request.Type == orders.OCO {
+		legs, group = request.Legs, e.newID()
+		for i := range legs {
+			if legs[i].ClientOrderID == "" && request.ClientOrderID != "" {
+				legs[i].ClientOrderID = fmt.Sprintf("%s-%d", request.ClientOrderID, i+1)
+			}
+			if legs[i].Reason == "" {
+				legs[i].Reason = request.Reason
+			}
+		}
+	}
+
+	placed := make([]orders.Order, 0, len(legs))
+	for _, leg := range legs {
+		w := &working{order: orders.Order{
+			Request:   leg,
+			ID:        e.newID(),
+			Account:   e.name,
+			Status:    orders.Open,
+			OCOGroup:  group,
+			CreatedAt: now,
+			UpdatedAt: now,
+		}}
+		if price, ok := e.lastPrice[leg.Symbol]; ok && leg.Type == orders.TrailingStop {
+			w.trail(price)
+		}
+		e.open = append(e.open, w)
+		e.emitOrder(w)
+		placed = append(placed, w.order)
+	}
+	events := e.drain()
+	e.mu.Unlock()
+
+	e.send(events)
+	return placed, nil
+}
+
+// Cancel cancels an open order, along with the other leg if it belongs to an OCO order
+func (e *Engine) Cancel(id string, now int64) error {
+	e.mu.Lock()
+	var target *working
+	for _, w := range e.open {
+		if w.order.ID == id {
+			target = w
+		}
+	}
+	if target == nil {
+		e.mu.Unlock()
+		return fmt.Errorf("no open order %q", id)
+	}
+	e.cancel(target, now)
+	e.cancelSiblings(target, now)
+	e.removeDone()
+	events := e.drain()
+	e.mu.Unlock()
+
+	e.send(events)
+	return nil
+}
+
+// CancelAll cancels every open order of `symbol`
+func (e *Engine) CancelAll(symbol string, now int64) {
+	e.mu.Lock()
+	for _, w := range e.open {
+		if w.order.Symbol == symbol {
+			e.cancel(w, now)
+		}
+	}
+	e.removeDone()
+	events := e.drain()
+	e.mu.Unlock()
+
+	e.send(events)
+}
+
+// OnTick fills the open orders of `symbol` that the traded price reaches
+func (e *Engine) OnTick(symbol string, tick financeFunctions.Tick) {
+	e.mu.Lock()
+	e.processTick(symbol, tick.Price, tick.Time)
+	events := e.drain()
+	e.mu.Unlock()
+
+	e.send(events)
+}
+
+// OnCandle fills open orders along the path a candle most likely took, then marks the position at its close
+// - a candle that closed up is assumed to have gone open, low, high, close, and one that closed down open, high, low, close
+func (e *Engine) OnCandle(symbol string, candle financeFunctions.Candlestick) {
+	path := []float64{candle.Open, candle.High, candle.Low, candle.Close}
+	if candle.Close >= candle.Open {
+		path[1], path[2] = candle.Low, candle.High
+	}
+
+	e.mu.Lock()
+	for i, price := range path {
+		time := candle.OpenTime
+		if i == len(path)-1 {
+			time = candle.CloseTime
+		}
+		e.processTick(symbol, price, time)
+	}
+	e.mark(symbol, candle.Close, candle.CloseTime)
+	events := e.drain()
+	e.mu.Unlock()
+
+	e.send(events)
+}
+
+// Mark values the position in `symbol` at `price`, and sends the updated position and balance
+func (e *Engine) Mark(symbol string, price float64, now int64) {
+	e.mu.Lock()
+	e.mark(symbol, price, now)
+	events := e.drain()
+	e.mu.Unlock()
+
+	e.send(events)
+}
+
+// Position returns the position held in `symbol`
+func (e *Engine) Position(symbol string) Position {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return *e.position(symbol)
+}
+
+// Balance returns the account's cash, equity and profit
+func (e *Engine) Balance() Balance {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return e.balance(0)
+}
+
+// OpenOrders returns every order still waiting to fill, oldest first
+func (e *Engine) OpenOrders() []orders.Order {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	open := make([]orders.Order, 0, len(e.open))
+	for _, w := range e.open {
+		open = append(open, w.order)
+	}
+	return open
+}
+
+// Helper function to run the risk checks on an order, queueing it as rejected if it fails them
+func (e *Engine) checkRisk(request orders.Request, now int64) error {
+	if e.risk == nil {
+		return nil
+	}
+	symbol := request.Symbol
+	if request.Type == orders.OCO {
+		symbol = request.Legs[0].Symbol
+	}
+	exposure := risk.Exposure{Position: e.position(symbol).Quantity, OpenOrders: len(e.open), DailyPnL: e.dailyPnL(now)}
+	for _, w := range e.open {
+		if w.order.Symbol != symbol {
+			continue
+		}
+		if w.order.Side == orders.Buy {
+			exposure.OpenBuys += w.order.Quantity
+		} else {
+			exposure.OpenSells += w.order.Quantity
+		}
+	}
+	err := e.risk.Check(e.name, request, exposure)
+	if err != nil {
+		e.events = append(e.events, event{"order", orders.Order{
+			Request:      request,
+			ID:           e.newID(),
+			Account:      e.name,
+			Status:       orders.Rejected,
+			RejectReason: err.Error(),
+			CreatedAt:    now,
+			UpdatedAt:    now,
+		}})
+	}
+	return err
+}
+
+// Helper function to get the profit since the start of the UTC day, starting a new day when one has passed
+func (e *Engine) dailyPnL(now int64) float64 {
+	equity := e.balance(now).Equity
+	if day := now / (24 * 60 * 60 * 1000); day != e.day {
+		e.day, e.dayStartEquity = day, equity
+	}
+	return equity - e.dayStartEquity
+}
+
+// Helper function to fill every open order of `symbol` that `price` reaches
+func (e *Engine) processTick(symbol string, price float64, time int64) {
+	if !(price > 0) || math.IsInf(price, 0) {
+		return
+	}
+	e.lastPrice[symbol] = price
+
+	for _, w := range e.open {
+		// An earlier fill on this tick may have cancelled the other leg of an OCO order
+		if w.order.Symbol != symbol || w.order.Status.Done() {
+			continue
+		}
+		if fillPrice, taker, ok := e.evaluate(w, price, time); ok {
+			e.fill(w, fillPrice, taker, time)
+		}
+	}
+	e.removeDone()
+}
+
+// Helper function to decide whether an order fills at `price`, and at what price
+func (e *Engine) evaluate(w *working, price float64, time int64) (fillPrice float64, taker bool, ok bool) {
+	order := &w.order
+	sign := order.Side.Sign()
+	market := price * (1 + sign*e.config.Slippage)
+
+	switch order.Type {
+	case orders.Market:
+		return market, true, true
+
+	case orders.Stop:
+		if !reached(order.Side, price, order.StopPrice) {
+			return 0, false, false
+		}
+		e.trigger(w, time)
+		return market, true, true
+
+	case orders.TrailingStop:
+		w.trail(price)
+		if !reached(order.Side, price, order.TrailingStop) {
+			return 0, false, false
+		}
+		e.trigger(w, time)
+		return market, true, true
+
+	case orders.StopLimit:
+		if order.Status == orders.Open {
+			if !reached(order.Side, price, order.StopPrice) {
+				return 0, false, false
+			}
+			e.trigger(w, time)
+		}
+	}
+
+	// Limit orders, and stop limit orders once triggered
+	marketable := sign*(order.LimitPrice-price) >= 0
+	if !w.resting {
+		if marketable {
+			return price, true, true
+		}
+		w.resting = true
+		return 0, false, false
+	}
+	if sign*(order.LimitPrice-price) > 0 {
+		return order.LimitPrice, false, true
+	}
+	return 0, false, false
+}
+
+// Helper function to fill an order entirely at `price`, or reject it if the account cannot afford it
+func (e *Engine) fill(w *working, price float64, taker bool, time int64) {
+	order := &w.order
+	feeRate := e.config.MakerFee
+	if taker {
+		feeRate = e.config.TakerFee
+	}
+	fee := order.Quantity * price * feeRate
+
+	if reason := e.checkFunds(order.Symbol, order.Side, order.Quantity, price, fee); reason != "" {
+		order.Status = orders.Rejected
+		order.RejectReason = reason
+		order.UpdatedAt = time
+		e.emitOrder(w)
+		return
+	}
+
+	position := e.position(order.Symbol)
+	e.applyFill(position, order.Side.Sign()*order.Quantity, price, fee)
+	position.UpdatedAt = time
+
+	order.Status = orders.Filled
+	order.FilledQuantity = order.Quantity
+	order.AveragePrice = price
+	order.Fee = fee
+	order.UpdatedAt = time
+	e.emitOrder(w)
+	e.cancelSiblings(w, time)
+	e.mark(order.Symbol, e.lastPrice[order.Symbol], time)
+}
+
+// Helper function to explain why the account cannot afford a fill, or return "" if it can
+// - longs must be paid for in cash, shorts must be covered by equity worth at least the short position
+func (e *Engine) checkFunds(symbol string, side orders.Side, quantity, price, fee float64) string {
+	current := e.position(symbol).Quantity
+	next := current + side.Sign()*quantity
+
+	opening := math.Max(math.Abs(next)-math.Abs(current), 0)
+	if current*next < 0 {
+		opening = math.Abs(next)
+	}
+	if opening == 0 {
+		return ""
+	}
+
+	if next > 0 && e.cash-quantity*price-fee < 0 {
+		return "insufficient cash"
+	}
+	if next < 0 {
+		if !e.config.AllowShort {
+			return "short selling is disabled"
+		}
+		if e.balance(0).Equity-fee < math.Abs(next)*price {
+			return "insufficient margin"
+		}
+	}
+	return ""
+}
+
+// Helper function to move a position by `quantity` (negative to sell) at `price`
+func (e *Engine) applyFill(position *Position, quantity, price, fee float64) {
+	e.cash -= quantity*price + fee
+	e.fees += fee
+
+	current := position.Quantity
+	switch {
+	case current == 0 || current*quantity > 0:
+		// Opening or adding to a position
+		position.AveragePrice = (math.Abs(current)*position.AveragePrice + math.Abs(quantity)*price) / math.Abs(current+quantity)
+		position.Quantity += quantity
+	default:
+		// Reducing, closing or flipping a position
+		closing := math.Min(math.Abs(quantity), math.Abs(current))
+		direction := math.Copysign(1, current)
+		position.RealizedPnL += closing * (price - position.AveragePrice) * direction
+		position.Quantity += quantity
+		if math.Abs(position.Quantity) < 1e-12 {
+			position.Quantity, position.AveragePrice = 0, 0
+		} else if position.Quantity*current < 0 {
+			position.AveragePrice = price
+		}
+	}
+}
+
+// Helper function to mark a position at `price` and queue the position and balance updates
+// - a new UTC day starts before the price is taken, so its profit counts from the last mark before midnight
+func (e *Engine) mark(symbol string, price float64, time int64) {
+	e.dailyPnL(time)
+	position := e.position(symbol)
+	if price > 0 {
+		position.MarkPrice = price
+	}
+	position.UnrealizedPnL = (position.MarkPrice - position.AveragePrice) * position.Quantity
+	position.UpdatedAt = time
+	e.events = append(e.events, event{"position", *position}, event{"balance", e.balance(time)})
+}
+
+// Helper function to get the position in `symbol`, creating a flat one if there is none
+func (e *Engine) position(symbol string) *Position {
+	position, ok := e.positions[symbol]
+	if !ok {
+		position = &Position{Account: e.name, Symbol: symbol}
+		e.positions[symbol] = position
+	}
+	return position
+}
+
+// Helper function to total up the account
+func (e *Engine) balance(time int64) Balance {
+	balance := Balance{Account: e.name, Cash: e.cash, Equity: e.cash, Fees: e.fees, UpdatedAt: time}
+	for _, position := range e.positions {
+		unrealized := (position.MarkPrice - position.AveragePrice) * position.Quantity
+		balance.Equity += position.Quantity * position.MarkPrice
+		balance.RealizedPnL += position.RealizedPnL
+		balance.UnrealizedPnL += unrealized
+	}
+	return balance
+}
+
+// Helper function to mark an order as triggered, once its stop price was reached
+func (e *Engine) trigger(w *working, time int64) {
+	w.order.Status = orders.Triggered
+	w.order.UpdatedAt = time
+	w.resting = false // a triggered stop limit is marketable if the price is still within its limit
+	e.emitOrder(w)
+}
+
+// Helper function to cancel an order that has not filled yet
+func (e *Engine) cancel(w *working, time int64) {
+	if w.order.Status.Done() {
+		return
+	}
+	w.order.Status = orders.Canceled
+	w.order.UpdatedAt = time
+	e.emitOrder(w)
+}
+
+// Helper function to cancel the other leg of an OCO order
+func (e *Engine) cancelSiblings(w *working, time int64) {
+	if w.order.OCOGroup == "" {
+		return
+	}
+	for _, sibling := range e.open {
+		if sibling != w && sibling.order.OCOGroup == w.order.OCOGroup {
+			e.cancel(sibling, time)
+		}
+	}
+}
+
+// Helper function to forget orders that can no longer fill
+func (e *Engine) removeDone() {
+	open := e.open[:0]
+	for _, w := range e.open {
+		if !w.order.Status.Done() {
+			open = append(open, w)
+		}
+	}
+	e.open = open
+}
+
+// Helper function to create a unique order id, e.g. "emaCrossover-12"
+func (e *Engine) newID() string {
+	e.nextID++
+	return fmt.Sprintf("%s-%d", e.name, e.nextID)
+}
+
+func (e *Engine) emitOrder(w *working) {
+	e.events = append(e.events, event{"order", w.order})
+}
+
+// Helper function to take the queued events, so they can be sent without holding the lock
+// - the listener may place orders itself (e.g. a stop loss once an entry fills)
+func (e *Engine) drain() []event {
+	events := e.events
+	e.events = nil
+	return events
+}
+
+func (e *Engine) send(events []event) {
+	for _, event := range events {
+		e.listener(event.messageType, event.data)
+	}
+}
+
+// Move a trailing stop with the best price since placement
+func (w *working) trail(price float64) {
+	sign := w.order.Side.Sign()
+	if w.extreme == 0 || sign*(price-w.extreme) < 0 {
+		w.extreme = price
+	}
+	w.order.TrailingStop = w.extreme * (1 + sign*w.order.TrailingDelta)
+}
+
+// Helper function to check whether the price reached a stop, above it for buys and below it for sells
+func reached(side orders.Side, price, stop float64) bool {
+	return side.Sign()*(price-stop) >= 0
+}
@@ -0,0 +1,223 @@
+package paperTrading
+
+import (
+	"log"
+	"math"
+	"sort"
+	"sync"
+
+	"github.com/neozhixuan/project-visualgo-backend/trading-algo/financeFunctions"
+	"github.com/neozhixuan/project-visualgo-backend/trading-algo/orders"
+	"github.com/neozhixuan/project-visualgo-backend/trading-algo/risk"
+	"github.com/neozhixuan/project-visualgo-backend/trading-algo/strategy"
+)
+
+// TraderConfig decides how strategy signals become orders
+type TraderConfig struct {
+	Account      Config
+	PositionSize float64 // fraction of equity each position is opened with, below 1 to leave room for fees and price moves before the fill
+	StopLoss     float64 // distance of the protective stop from the entry price as a fraction, e.g. 0.02 for 2%, or 0 for none
+	TakeProfit   float64 // distance of the profit taking limit from the entry price as a fraction, or 0 for none
+	TrailingStop bool    // whether the protective stop trails the best price since entry
+}
+
+// DefaultTraderConfig returns a config that trades 95% of equity with no protective orders
+func DefaultTraderConfig() TraderConfig {
+	return TraderConfig{Account: DefaultConfig(), PositionSize: 0.95}
+}
+
+// Trader paper trades strategy signals, with a separate account per strategy so their results can be compared
+// - a signal cancels the strategy's open orders on the symbol, and sends a market order to reach the position it wants
+// - once that order fills, a stop loss and take profit are placed around the entry, as an OCO order when both are set
+type Trader struct {
+	config   TraderConfig
+	listener Listener
+
+	mu       sync.Mutex
+	risk     *risk.Manager
+	accounts map[string]*Engine
+	entries  map[string]bool // ids of market orders that protective orders are placed for once they fill
+}
+
+// NewTrader creates a Trader sending every account's changes to `listener`
+func NewTrader(config TraderConfig, listener Listener) *Trader {
+	if listener == nil {
+		listener = func(string, interface{}) {}
+	}
+	return &Trader{config: config, listener: listener, accounts: map[string]*Engine{}, entries: map[string]bool{}}
+}
+
+// SetRisk makes every account's orders pass the risk checks of `manager`
+func (t *Trader) SetRisk(manager *risk.Manager) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.risk = manager
+	for _, engine := range t.accounts {
+		engine.SetRisk(manager)
+	}
+}
+
+// OnSignal moves the strategy's account to the position the signal asks for
+func (t *Trader) OnSignal(signal strategy.Signal) error {
+	direction := 0.0
+	switch {
+	case signal.Action == strategy.Buy:
+		direction = 1
+	case signal.Action == strategy.Sell && t.config.Account.AllowShort:
+		direction = -1
+	}
+
+	// Keep a position already on the right side, along with its protective orders
+	engine := t.account(signal.Strategy)
+	current := engine.Position(signal.Symbol).Quantity
+	if current*direction > 0 {
+		return nil
+	}
+	engine.CancelAll(signal.Symbol, signal.Time)
+
+	wanted := direction * engine.Balance().Equity * t.config.PositionSize / signal.Price
+	change := wanted - current
+	if change == 0 {
+		return nil
+	}
+
+	side := orders.Buy
+	if change < 0 {
+		side = orders.Sell
+	}
+	placed, err := engine.Place(orders.Request{
+		Symbol:   signal.Symbol,
+		Side:     side,
+		Type:     orders.Market,
+		Quantity: math.Abs(change),
+		Reason:   signal.Reason,
+	}, signal.Time)
+	if err != nil {
+		return err
+	}
+	if direction != 0 {
+		t.mu.Lock()
+		t.entries[placed[0].ID] = true
+		t.mu.Unlock()
+	}
+	return nil
+}
+
+// OnTick fills every account's open orders of `symbol` reached by the traded price
+func (t *Trader) OnTick(symbol string, tick financeFunctions.Tick) {
+	for _, engine := range t.engines() {
+		engine.OnTick(symbol, tick)
+	}
+}
+
+// OnCandleClosed marks every account's position in `symbol` at the candle's close
+func (t *Trader) OnCandleClosed(symbol string, candle financeFunctions.Candlestick) {
+	for _, engine := range t.engines() {
+		engine.Mark(symbol, candle.Close, candle.CloseTime)
+	}
+}
+
+// Account returns the account of a strategy
+func (t *Trader) Account(name string) (*Engine, bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	engine, ok := t.accounts[name]
+	return engine, ok
+}
+
+// Helper function to get the account of a strategy, opening it on its first signal
+func (t *Trader) account(name string) *Engine {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	engine, ok := t.accounts[name]
+	if !ok {
+		listener := func(messageType string, data interface{}) {
+			t.listener(messageType, data)
+			if order, ok := data.(orders.Order); ok && order.Status == orders.Filled {
+				t.protect(engine, order)
+			}
+		}
+		engine = NewEngine(name, t.config.Account, listener)
+		engine.SetRisk(t.risk)
+		t.accounts[name] = engine
+	}
+	return engine
+}
+
+// Helper function to list the accounts in a stable order
+func (t *Trader) engines() []*Engine {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	names := make([]string, 0, len(t.accounts))
+	for name := range t.accounts {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	engines := make([]*Engine, 0, len(names))
+	for _, name := range names {
+		engines = append(engines, t.accounts[name])
+	}
+	return engines
+}
+
+// Helper function to place the stop loss and take profit once an entry order fills
+func (t *Trader) protect(engine *Engine, entry orders.Order) {
+	t.mu.Lock()
+	isEntry := t.entries[entry.ID]
+	delete(t.entries, entry.ID)
+	t.mu.Unlock()
+	if !isEntry {
+		return
+	}
+
+	position := engine.Position(entry.Symbol)
+	if position.Quantity == 0 {
+		return
+	}
+	request, ok := t.brackets(position, entry.AveragePrice)
+	if !ok {
+		return
+	}
+	if _, err := engine.Place(request, entry.UpdatedAt); err != nil {
+		log.Printf("Error while protecting %s position of %s: %v", position.Symbol, engine.name, err)
+	}
+}
+
+// Helper function to build the protective orders of a position entered at `price`
+func (t *Trader) brackets(position Position, price float64) (orders.Request, bool) {
+	exit := orders.Buy
+	if position.Quantity > 0 {
+		exit = orders.Sell
+	}
+	sign := exit.Sign() // the stop is below a long's entry, and above a short's
+	base := orders.Request{Symbol: position.Symbol, Side: exit, Quantity: math.Abs(position.Quantity)}
+
+	var legs []orders.Request
+	if t.config.TakeProfit > 0 {
+		takeProfit := base
+		takeProfit.Type = orders.Limit
+		takeProfit.LimitPrice = price * (1 - sign*t.config.TakeProfit)
+		takeProfit.Reason = "take profit"
+		legs = append(legs, takeProfit)
+	}
+	if t.config.StopLoss > 0 {
+		stopLoss := base
+		stopLoss.Reason = "stop loss"
+		if t.config.TrailingStop {
+			stopLoss.Type = orders.TrailingStop
+			stopLoss.TrailingDelta = t.config.StopLoss
+		} else {
+			stopLoss.Type = orders.Stop
+			stopLoss.StopPrice = price * (1 + sign*t.config.StopLoss)
+		}
+		legs = append(legs, stopLoss)
+	}
+
+	switch len(legs) {
+	case 0:
+		return orders.Request{}, false
+	case 1:
+		return legs[0], true
+	}
+	return orders.Request{Type: orders.OCO, Legs: legs, Reason: "stop loss / take profit"}, true
+}
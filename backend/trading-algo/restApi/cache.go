@@ -0,0 +1,71 @@
+package restApi
+
+import (
+	"sync"
+
+	"github.com/neozhixuan/project-visualgo-backend/trading-algo/financeFunctions"
+)
+
+// Most indicators kept calculated per symbol and interval, beyond which they are all calculated again as they are asked for
+const maxCachedIndicators = 64
+
+// Keeps every symbol and interval's candles, and the indicators calculated on them, until a candle is stored
+// - requests then only pay for the window they ask for, rather than for the whole history every time
+type historyCache struct {
+	mu      sync.Mutex
+	entries map[historyKey]*cachedHistory
+}
+
+type historyKey struct {
+	symbol    string
+	timeframe financeFunctions.Timeframe
+}
+
+// A symbol's candles of an interval, taken from its stored candles when they held `stored` candles, the latest opening at `latest`
+type cachedHistory struct {
+	stored     int
+	latest     int64
+	candles    []financeFunctions.Candlestick
+	indicators map[string][]financeFunctions.IndicatorLine // by overlay key
+}
+
+func newHistoryCache() *historyCache {
+	return &historyCache{entries: map[historyKey]*cachedHistory{}}
+}
+
+// Helper function to find the candles of a symbol and interval taken from `stored`, taking them with `take` unless they are kept
+func (c *historyCache) history(key historyKey, stored []financeFunctions.Candlestick, take func() []financeFunctions.Candlestick) *cachedHistory {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if len(stored) == 0 {
+		delete(c.entries, key)
+		return &cachedHistory{indicators: map[string][]financeFunctions.IndicatorLine{}}
+	}
+	latest := stored[len(stored)-1].OpenTime
+	if entry, ok := c.entries[key]; ok && entry.stored == len(stored) && entry.latest == latest {
+		return entry
+	}
+	entry := &cachedHistory{stored: len(stored), latest: latest, candles: take(), indicators: map[string][]financeFunctions.IndicatorLine{}}
+	c.entries[key] = entry
+	return entry
+}
+
+// Helper function to find an overlay's lines on the history, calculating them unless they are kept
+func (c *historyCache) lines(entry *cachedHistory, o overlay, vwap vwapOptions) []financeFunctions.IndicatorLine {
+	key := o.key()
+	c.mu.Lock()
+	lines, ok := entry.indicators[key]
+	c.mu.Unlock()
+	if ok {
+		return lines
+	}
+
+	lines = o.spec.calculate(entry.candles, o.values, vwap)
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if len(entry.indicators) >= maxCachedIndicators {
+		clear(entry.indicators)
+	}
+	entry.indicators[key] = lines
+	return lines
+}
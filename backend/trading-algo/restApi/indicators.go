@@ -0,0 +1,192 @@
+package restApi
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/neozhixuan/project-visualgo-backend/trading-algo/financeFunctions"
+	"github.com/neozhixuan/project-visualgo-backend/trading-algo/websocketServer"
+)
+
+// Longest period an indicator may be asked for, so a request cannot make the server hold huge windows
+const maxPeriod = 1000
+
+// An indicator the API can calculate, with its parameters in the order they appear in its channel
+type indicatorSpec struct {
+	params    []paramSpec
+	calculate func(candles []financeFunctions.Candlestick, params []float64, vwap vwapOptions) []financeFunctions.IndicatorLine
+}
+
+// A parameter of an indicator, with the value used when a request leaves it out
+type paramSpec struct {
+	name     string
+	fallback float64
+	integer  bool // periods, which must be whole numbers from 1 to maxPeriod
+}
+
+// How VWAP sessions are split, the same way as for the streamed VWAP
+type vwapOptions struct {
+	session  financeFunctions.VWAPSession
+	location *time.Location
+}
+
+// Standard deviation multipliers of the VWAP bands, the same as the streamed VWAP's
+var vwapBands = []float64{1, 2}
+
+// Every indicator the API calculates, by name, defaulting to the parameters they are streamed with
+var indicators = map[string]indicatorSpec{
+	"ema": {
+		params: []paramSpec{{"period", 9, true}},
+		calculate: func(candles []financeFunctions.Candlestick, params []float64, _ vwapOptions) []financeFunctions.IndicatorLine {
+			return []financeFunctions.IndicatorLine{financeFunctions.CalculateEMA(candles, int(params[0]), financeFunctions.SeedSMA)}
+		},
+	},
+	"sma": {
+		params: []paramSpec{{"period", 20, true}},
+		calculate: func(candles []financeFunctions.Candlestick, params []float64, _ vwapOptions) []financeFunctions.IndicatorLine {
+			return []financeFunctions.IndicatorLine{financeFunctions.CalculateSMA(candles, int(params[0]))}
+		},
+	},
+	"rsi": {
+		params: []paramSpec{{"period", 14, true}},
+		calculate: func(candles []financeFunctions.Candlestick, params []float64, _ vwapOptions) []financeFunctions.IndicatorLine {
+			return []financeFunctions.IndicatorLine{financeFunctions.CalculateRSI(candles, int(params[0]))}
+		},
+	},
+	"atr": {
+		params: []paramSpec{{"period", 14, true}},
+		calculate: func(candles []financeFunctions.Candlestick, params []float64, _ vwapOptions) []financeFunctions.IndicatorLine {
+			return []financeFunctions.IndicatorLine{financeFunctions.CalculateATR(candles, int(params[0]))}
+		},
+	},
+	"vwap": {
+		calculate: func(candles []financeFunctions.Candlestick, _ []float64, vwap vwapOptions) []financeFunctions.IndicatorLine {
+			return financeFunctions.CalculateSessionVWAP(candles, vwap.session, vwap.location, vwapBands...)
+		},
+	},
+	"supertrend": {
+		params: []paramSpec{{"period", 10, true}, {"multiplier", 3, false}},
+		calculate: func(candles []financeFunctions.Candlestick, params []float64, _ vwapOptions) []financeFunctions.IndicatorLine {
+			return financeFunctions.CalculateSupertrend(candles, int(params[0]), params[1])
+		},
+	},
+	"ichimoku": {
+		params: []paramSpec{{"tenkan", 9, true}, {"kijun", 26, true}, {"senkouB", 52, true}, {"displacement", 26, true}},
+		calculate: func(candles []financeFunctions.Candlestick, params []float64, _ vwapOptions) []financeFunctions.IndicatorLine {
+			return financeFunctions.CalculateIchimoku(candles, int(params[0]), int(params[1]), int(params[2]), int(params[3]))
+		},
+	},
+	"psar": {
+		params: []paramSpec{{"step", 0.02, false}, {"maxStep", 0.2, false}},
+		calculate: func(candles []financeFunctions.Candlestick, params []float64, _ vwapOptions) []financeFunctions.IndicatorLine {
+			return financeFunctions.CalculateParabolicSAR(candles, params[0], params[1])
+		},
+	},
+	"keltner": {
+		params: []paramSpec{{"emaPeriod", 20, true}, {"atrPeriod", 10, true}, {"multiplier", 2, false}},
+		calculate: func(candles []financeFunctions.Candlestick, params []float64, _ vwapOptions) []financeFunctions.IndicatorLine {
+			return financeFunctions.CalculateKeltnerChannels(candles, int(params[0]), int(params[1]), params[2])
+		},
+	},
+	"donchian": {
+		params: []paramSpec{{"period", 20, true}},
+		calculate: func(candles []financeFunctions.Candlestick, params []float64, _ vwapOptions) []financeFunctions.IndicatorLine {
+			return financeFunctions.CalculateDonchianChannels(candles, int(params[0]))
+		},
+	},
+}
+
+// An indicator with the parameters a request asked for
+type overlay struct {
+	name   string
+	spec   indicatorSpec
+	values []float64
+}
+
+// Helper function to list the indicators the API calculates, alphabetically
+func indicatorNames() []string {
+	names := make([]string, 0, len(indicators))
+	for name := range indicators {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Helper function to look up an indicator, with its parameters read by name from `lookup` and defaulted where it has none
+func newOverlay(name string, lookup func(param string) string) (overlay, error) {
+	spec, ok := indicators[name]
+	if !ok {
+		return overlay{}, fmt.Errorf("unknown indicator %q, expected one of %s", name, strings.Join(indicatorNames(), ", "))
+	}
+	o := overlay{name: name, spec: spec, values: make([]float64, len(spec.params))}
+	for i, param := range spec.params {
+		o.values[i] = param.fallback
+		if value := lookup(param.name); value != "" {
+			number, err := param.parse(value)
+			if err != nil {
+				return overlay{}, fmt.Errorf("%s: %w", name, err)
+			}
+			o.values[i] = number
+		}
+	}
+	return o, nil
+}
+
+// Helper function to parse an overlay written like the end of its channel, e.g. "ema:21" or "supertrend:10:3"
+// - parameters left out at the end keep their defaults, so "keltner" and "keltner:20" are both valid
+func parseOverlay(value string) (overlay, error) {
+	name, rest, _ := strings.Cut(value, ":")
+	var given []string
+	if rest != "" {
+		given = strings.Split(rest, ":")
+	}
+	spec, ok := indicators[name]
+	if ok && len(given) > len(spec.params) {
+		return overlay{}, fmt.Errorf("%s takes at most %d parameters, got %q", name, len(spec.params), value)
+	}
+	return newOverlay(name, func(param string) string {
+		for i, p := range spec.params {
+			if p.name == param && i < len(given) {
+				return given[i]
+			}
+		}
+		return ""
+	})
+}
+
+// Helper function to parse a parameter's value
+func (p paramSpec) parse(value string) (float64, error) {
+	number, err := strconv.ParseFloat(value, 64)
+	switch {
+	case err != nil || math.IsNaN(number) || math.IsInf(number, 0):
+		return 0, fmt.Errorf("invalid %s %q, expected a number", p.name, value)
+	case p.integer && (number != math.Trunc(number) || number < 1 || number > maxPeriod):
+		return 0, fmt.Errorf("invalid %s %q, expected a whole number from 1 to %d", p.name, value, maxPeriod)
+	case !p.integer && number <= 0:
+		return 0, fmt.Errorf("invalid %s %q, expected a positive number", p.name, value)
+	}
+	return number, nil
+}
+
+// Helper function to get the overlay's parameters, in the order they appear in its channel
+func (o overlay) params() []websocketServer.Param {
+	params := make([]websocketServer.Param, len(o.values))
+	for i, value := range o.values {
+		params[i] = websocketServer.Param{Name: o.spec.params[i].name, Value: value}
+	}
+	return params
+}
+
+// Helper function to name the overlay the way its channel ends, e.g. "supertrend:10:3"
+func (o overlay) key() string {
+	segments := []string{o.name}
+	for _, value := range o.values {
+		segments = append(segments, strconv.FormatFloat(value, 'g', -1, 64))
+	}
+	return strings.Join(segments, ":")
+}
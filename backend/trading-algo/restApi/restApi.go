@@ -0,0 +1,306 @@
+package restApi
+
+import (
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"log"
+	"math"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/neozhixuan/project-visualgo-backend/trading-algo/candleStore"
+	"github.com/neozhixuan/project-visualgo-backend/trading-algo/financeFunctions"
+	"github.com/neozhixuan/project-visualgo-backend/trading-algo/websocketServer"
+)
+
+// Candles returned when a request does not set `limit`, and the most it may set
+const (
+	defaultLimit = 500
+	maxLimit     = 5000
+)
+
+// Most indicator overlays one candles request may ask for
+const maxOverlays = 16
+
+// The OpenAPI description of the API, served at /v1/openapi.yaml
+//
+//go:embed openapi.yaml
+var openAPISpec []byte
+
+// Server answers requests for stored candles and the indicators calculated on them
+// - indicators are calculated on demand over the symbol's whole stored history, so their values do not depend on the window asked for
+// - candles of an interval that is not stored are resampled from finer stored candles, e.g. 15m from 1m
+// - indicator values are in the same points as the "indicator" messages of the WebSocket stream, null while an output line is warming up
+// - candles and indicators are kept calculated until the next candle is stored, so a request only pays for the window it asks for
+type Server struct {
+	store *candleStore.Store
+	vwap  vwapOptions
+	cache *historyCache
+	mux   *http.ServeMux
+}
+
+// Candles is the answer to GET /v1/candles
+type Candles struct {
+	Symbol   string    `json:"symbol"`
+	Interval string    `json:"interval"`
+	Overlays []Overlay `json:"overlays"`
+	Candles  []Candle  `json:"candles"` // oldest first
+}
+
+// Overlay is an indicator a candles request asked for, keyed the way its channel ends, e.g. "ema:21"
+type Overlay struct {
+	Key       string             `json:"key"`
+	Channel   string             `json:"channel"` // the WebSocket channel streaming the same indicator
+	Indicator string             `json:"indicator"`
+	Params    map[string]float64 `json:"params,omitempty"`
+}
+
+// Candle is a stored candle, with the value of every output line of every overlay at it, by overlay key
+type Candle struct {
+	financeFunctions.Candlestick
+	Overlays map[string]map[string]*float64 `json:"overlays,omitempty"`
+}
+
+// A symbol, interval and window of candles a request asked for
+type window struct {
+	symbol    string
+	timeframe financeFunctions.Timeframe
+	from, to  int64 // open times, in Unix milliseconds
+	limit     int
+}
+
+// NewServer creates a Server reading candles from `store`, splitting VWAP sessions like the streamed VWAP
+func NewServer(store *candleStore.Store, session financeFunctions.VWAPSession, location *time.Location) *Server {
+	s := &Server{store: store, vwap: vwapOptions{session: session, location: location}, cache: newHistoryCache(), mux: http.NewServeMux()}
+	s.mux.HandleFunc("GET /v1/candles", s.handleCandles)
+	s.mux.HandleFunc("GET /v1/indicators", s.handleIndicatorList)
+	s.mux.HandleFunc("GET /v1/indicators/{name}", s.handleIndicator)
+	s.mux.HandleFunc("GET /v1/openapi.yaml", s.handleOpenAPI)
+	return s
+}
+
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mux.ServeHTTP(w, r)
+}
+
+// GET /v1/candles?symbol=BNBBTC&interval=1m&from=&to=&limit=&overlays=ema:9,rsi:14
+func (s *Server) handleCandles(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	window, err := parseWindow(query)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	var overlays []overlay
+	if value := query.Get("overlays"); value != "" {
+		keys := strings.Split(value, ",")
+		if len(keys) > maxOverlays {
+			writeError(w, http.StatusBadRequest, fmt.Sprintf("too many overlays, at most %d are allowed", maxOverlays))
+			return
+		}
+		for _, key := range keys {
+			o, err := parseOverlay(strings.TrimSpace(key))
+			if err != nil {
+				writeError(w, http.StatusBadRequest, err.Error())
+				return
+			}
+			overlays = append(overlays, o)
+		}
+	}
+	history, first, last, ok := s.load(w, window)
+	if !ok {
+		return
+	}
+
+	response := Candles{Symbol: window.symbol, Interval: string(window.timeframe), Overlays: []Overlay{}, Candles: []Candle{}}
+	lines := make([][]financeFunctions.IndicatorLine, len(overlays))
+	for i, o := range overlays {
+		params := o.params()
+		response.Overlays = append(response.Overlays, Overlay{
+			Key:       o.key(),
+			Channel:   websocketServer.IndicatorChannel(window.symbol, string(window.timeframe), o.name, params),
+			Indicator: o.name,
+			Params:    paramMap(params),
+		})
+		lines[i] = s.cache.lines(history, o, s.vwap)
+	}
+	for i := first; i < last; i++ {
+		candle := Candle{Candlestick: history.candles[i]}
+		if len(overlays) > 0 {
+			candle.Overlays = make(map[string]map[string]*float64, len(overlays))
+		}
+		for j := range overlays {
+			candle.Overlays[response.Overlays[j].Key] = websocketServer.PointAt(lines[j], i, history.candles[i].OpenTime).Values
+		}
+		response.Candles = append(response.Candles, candle)
+	}
+	writeJSON(w, response)
+}
+
+// GET /v1/indicators
+func (s *Server) handleIndicatorList(w http.ResponseWriter, r *http.Request) {
+	type indicator struct {
+		Name   string             `json:"name"`
+		Params map[string]float64 `json:"params,omitempty"` // every parameter, with its default
+	}
+	list := []indicator{}
+	for _, name := range indicatorNames() {
+		o, _ := newOverlay(name, func(string) string { return "" })
+		list = append(list, indicator{Name: name, Params: paramMap(o.params())})
+	}
+	writeJSON(w, list)
+}
+
+// GET /v1/indicators/{name}?symbol=BNBBTC&interval=1m&period=9&from=&to=&limit=
+// - every parameter of the indicator is a query parameter of its own, e.g. period, or emaPeriod and atrPeriod for keltner
+func (s *Server) handleIndicator(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	o, err := newOverlay(r.PathValue("name"), query.Get)
+	if err != nil {
+		status := http.StatusBadRequest
+		if _, known := indicators[r.PathValue("name")]; !known {
+			status = http.StatusNotFound
+		}
+		writeError(w, status, err.Error())
+		return
+	}
+	window, err := parseWindow(query)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	history, first, last, ok := s.load(w, window)
+	if !ok {
+		return
+	}
+
+	params := o.params()
+	series := websocketServer.IndicatorSeries{
+		Channel:   websocketServer.IndicatorChannel(window.symbol, string(window.timeframe), o.name, params),
+		Symbol:    window.symbol,
+		Interval:  string(window.timeframe),
+		Indicator: o.name,
+		Params:    paramMap(params),
+		Points:    []websocketServer.Point{},
+	}
+	lines := s.cache.lines(history, o, s.vwap)
+	for i := first; i < last; i++ {
+		series.Points = append(series.Points, websocketServer.PointAt(lines, i, history.candles[i].OpenTime))
+	}
+	writeJSON(w, series)
+}
+
+// GET /v1/openapi.yaml
+func (s *Server) handleOpenAPI(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/yaml")
+	w.Write(openAPISpec)
+}
+
+// Helper function to load the history a window is taken from, and find the window's candles in it, as history.candles[first:last]
+// - answers the request with an error and returns false if the history cannot be loaded
+func (s *Server) load(w http.ResponseWriter, window window) (*cachedHistory, int, int, bool) {
+	stored, err := s.store.Load(window.symbol)
+	if err != nil {
+		log.Printf("Error loading candles of %s: %v", window.symbol, err)
+		writeError(w, http.StatusInternalServerError, "could not load candles")
+		return nil, 0, 0, false
+	}
+	history := s.cache.history(historyKey{window.symbol, window.timeframe}, stored, func() []financeFunctions.Candlestick {
+		return candlesOf(stored, window.timeframe)
+	})
+	candles := history.candles
+	first := sort.Search(len(candles), func(i int) bool { return candles[i].OpenTime >= window.from })
+	last := sort.Search(len(candles), func(i int) bool { return candles[i].OpenTime > window.to })
+	if last-first > window.limit {
+		// With only `to` set, the latest candles up to it are wanted, and with `from` set, the earliest from it
+		if window.from > 0 {
+			last = first + window.limit
+		} else {
+			first = last - window.limit
+		}
+	}
+	return history, first, last, true
+}
+
+// Helper function to take the stored candles of a timeframe, resampling finer ones when none of the timeframe are stored
+func candlesOf(stored []financeFunctions.Candlestick, timeframe financeFunctions.Timeframe) []financeFunctions.Candlestick {
+	var exact, finer []financeFunctions.Candlestick
+	for _, candle := range stored {
+		candleTimeframe, ok := financeFunctions.TimeframeOf(candle)
+		switch {
+		case !ok:
+			continue
+		case candleTimeframe == timeframe:
+			exact = append(exact, candle)
+		case candleTimeframe.Duration() < timeframe.Duration():
+			finer = append(finer, candle)
+		}
+	}
+	if len(exact) > 0 {
+		return exact
+	}
+	return financeFunctions.Resample(finer, timeframe)
+}
+
+// Helper function to parse the symbol, interval and window of candles a request asked for
+// - `from` and `to` are open times in Unix milliseconds, both included, and `limit` caps how many candles are returned
+func parseWindow(query url.Values) (window, error) {
+	w := window{symbol: strings.ToUpper(query.Get("symbol")), to: math.MaxInt64, limit: defaultLimit}
+	if !candleStore.ValidSymbol(w.symbol) {
+		return window{}, fmt.Errorf("invalid symbol %q, expected e.g. BNBBTC", w.symbol)
+	}
+	interval := query.Get("interval")
+	if interval == "" {
+		interval = string(financeFunctions.Timeframe1m)
+	}
+	timeframe, err := financeFunctions.ParseTimeframe(interval)
+	if err != nil {
+		return window{}, err
+	}
+	w.timeframe = timeframe
+	for name, value := range map[string]*int64{"from": &w.from, "to": &w.to} {
+		if text := query.Get(name); text != "" {
+			if *value, err = strconv.ParseInt(text, 10, 64); err != nil || *value < 0 {
+				return window{}, fmt.Errorf("invalid %s %q, expected Unix milliseconds", name, text)
+			}
+		}
+	}
+	if w.from > w.to {
+		return window{}, fmt.Errorf("from is after to")
+	}
+	if text := query.Get("limit"); text != "" {
+		if w.limit, err = strconv.Atoi(text); err != nil || w.limit < 1 || w.limit > maxLimit {
+			return window{}, fmt.Errorf("invalid limit %q, expected 1 to %d candles", text, maxLimit)
+		}
+	}
+	return w, nil
+}
+
+// Helper function to key parameters by name, or nil if there are none
+func paramMap(params []websocketServer.Param) map[string]float64 {
+	if len(params) == 0 {
+		return nil
+	}
+	named := make(map[string]float64, len(params))
+	for _, param := range params {
+		named[param.Name] = param.Value
+	}
+	return named
+}
+
+func writeJSON(w http.ResponseWriter, data interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(data)
+}
+
+// Helper function to answer a request with an error, as {"error": "..."}
+func writeError(w http.ResponseWriter, status int, message string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(map[string]string{"error": message})
+}
@@ -0,0 +1,61 @@
+package risk
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"net/http"
+	"strings"
+)
+
+// SetAdminToken sets the bearer token the admin endpoint requires, which stays disabled while it is empty
+func (m *Manager) SetAdminToken(token string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.adminToken = token
+}
+
+// KillSwitchHandler serves the kill switch admin endpoint
+// - GET returns the kill switch, POST {"enabled": true, "reason": "..."} turns it on or off
+// - every request needs "Authorization: Bearer <admin token>"
+func (m *Manager) KillSwitchHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		m.mu.Lock()
+		token := m.adminToken
+		m.mu.Unlock()
+		if token == "" {
+			http.Error(w, "Admin endpoint is disabled, set ADMIN_TOKEN to enable it", http.StatusForbidden)
+			return
+		}
+		given, _ := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if subtle.ConstantTimeCompare([]byte(given), []byte(token)) != 1 {
+			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+			return
+		}
+
+		switch r.Method {
+		case http.MethodGet:
+			writeJSON(w, m.KillSwitch())
+		case http.MethodPost:
+			var body struct {
+				Enabled bool   `json:"enabled"`
+				Reason  string `json:"reason"`
+			}
+			if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+				http.Error(w, "Expected {\"enabled\": true|false, \"reason\": \"...\"}", http.StatusBadRequest)
+				return
+			}
+			if body.Reason == "" {
+				body.Reason = "set through the admin endpoint"
+			}
+			writeJSON(w, m.SetKillSwitch(body.Enabled, body.Reason))
+		default:
+			w.Header().Set("Allow", "GET, POST")
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		}
+	})
+}
+
+func writeJSON(w http.ResponseWriter, data interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(data)
+}
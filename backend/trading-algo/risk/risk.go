@@ -0,0 +1,201 @@
+package risk
+
+import (
+	"fmt"
+	"log"
+	"math"
+	"sync"
+	"time"
+
+	"github.com/neozhixuan/project-visualgo-backend/trading-algo/orders"
+)
+
+// Limits enforced on every order before it is placed, where 0 turns a limit off
+type Limits struct {
+	MaxPosition   float64 `json:"maxPosition"`   // largest position per symbol, in the base asset
+	MaxNotional   float64 `json:"maxNotional"`   // largest value of a single order, in the quote asset
+	MaxDailyLoss  float64 `json:"maxDailyLoss"`  // loss in a UTC day after which only orders reducing a position are accepted
+	MaxOpenOrders int     `json:"maxOpenOrders"` // most orders an account can have open at once
+	PriceCollar   float64 `json:"priceCollar"`   // furthest a limit or stop price may be from the last close, as a fraction
+}
+
+// Rule names a risk check, reported with every rejection
+type Rule string
+
+const (
+	RuleKillSwitch    Rule = "killSwitch"
+	RuleMaxPosition   Rule = "maxPosition"
+	RuleMaxNotional   Rule = "maxNotional"
+	RuleDailyLoss     Rule = "dailyLoss"
+	RuleMaxOpenOrders Rule = "maxOpenOrders"
+	RulePriceCollar   Rule = "priceCollar"
+)
+
+// Rejection is the error returned for an order that breaks a rule
+type Rejection struct {
+	Rule    Rule
+	Message string
+}
+
+func (r *Rejection) Error() string {
+	return fmt.Sprintf("risk rule %s: %s", r.Rule, r.Message)
+}
+
+// Exposure is what an account holds when it places an order
+type Exposure struct {
+	Position   float64 // in the order's symbol, positive when long and negative when short
+	OpenBuys   float64 // quantity of open buy orders in the order's symbol
+	OpenSells  float64 // quantity of open sell orders in the order's symbol
+	OpenOrders int     // across every symbol
+	DailyPnL   float64 // realized and unrealized profit since the start of the UTC day
+}
+
+// KillSwitch is the state of the global kill switch
+type KillSwitch struct {
+	Enabled bool   `json:"enabled"`
+	Reason  string `json:"reason,omitempty"`
+	Since   int64  `json:"since,omitempty"` // Unix milliseconds
+}
+
+// Manager checks every order of every account, paper or live, against the limits and the kill switch
+// - orders that only reduce a position are still accepted after the daily loss limit, so positions can always be closed
+// - the kill switch blocks every new order until it is turned off again
+type Manager struct {
+	mu         sync.Mutex
+	limits     Limits
+	lastClose  map[string]float64
+	killSwitch KillSwitch
+	adminToken string
+}
+
+// NewManager creates a Manager enforcing `limits`
+func NewManager(limits Limits) *Manager {
+	return &Manager{limits: limits, lastClose: map[string]float64{}}
+}
+
+// SetLimits replaces the limits enforced from now on
+func (m *Manager) SetLimits(limits Limits) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.limits = limits
+}
+
+// OnKline records the latest close of `symbol`, which price collars and notional values are measured against
+func (m *Manager) OnKline(symbol string, close float64) {
+	if !(close > 0) || math.IsInf(close, 0) {
+		return
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.lastClose[symbol] = close
+}
+
+// LastClose returns the latest close of `symbol`
+func (m *Manager) LastClose(symbol string) (float64, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	close, ok := m.lastClose[symbol]
+	return close, ok
+}
+
+// SetKillSwitch turns the kill switch on or off
+func (m *Manager) SetKillSwitch(enabled bool, reason string) KillSwitch {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if enabled != m.killSwitch.Enabled {
+		log.Printf("Kill switch turned %s: %s", map[bool]string{true: "on", false: "off"}[enabled], reason)
+		m.killSwitch = KillSwitch{Enabled: enabled, Reason: reason, Since: time.Now().UnixMilli()}
+	}
+	return m.killSwitch
+}
+
+// KillSwitch returns the state of the kill switch
+func (m *Manager) KillSwitch() KillSwitch {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.killSwitch
+}
+
+// Check returns a *Rejection if `request` breaks a rule, logging which rule fired
+func (m *Manager) Check(account string, request orders.Request, exposure Exposure) error {
+	m.mu.Lock()
+	rejection := m.check(request, exposure)
+	m.mu.Unlock()
+
+	if rejection != nil {
+		log.Printf("Risk rejected %s order of %s: %s", describe(request), account, rejection)
+		return rejection
+	}
+	return nil
+}
+
+// Helper function to run every rule, returning the first one broken
+func (m *Manager) check(request orders.Request, exposure Exposure) *Rejection {
+	if m.killSwitch.Enabled {
+		return &Rejection{RuleKillSwitch, fmt.Sprintf("trading is halted (%s)", m.killSwitch.Reason)}
+	}
+
+	legs := []orders.Request{request}
+	if request.Type == orders.OCO {
+		legs = request.Legs
+	}
+	if m.limits.MaxOpenOrders > 0 && exposure.OpenOrders+len(legs) > m.limits.MaxOpenOrders {
+		return &Rejection{RuleMaxOpenOrders, fmt.Sprintf("%d orders are open, the limit is %d", exposure.OpenOrders, m.limits.MaxOpenOrders)}
+	}
+
+	for _, leg := range legs {
+		lastClose, ok := m.lastClose[leg.Symbol]
+		if m.limits.PriceCollar > 0 {
+			if !ok {
+				return &Rejection{RulePriceCollar, fmt.Sprintf("no recent close of %s to check prices against", leg.Symbol)}
+			}
+			for _, price := range []float64{leg.LimitPrice, leg.StopPrice} {
+				if price > 0 && math.Abs(price/lastClose-1) > m.limits.PriceCollar {
+					return &Rejection{RulePriceCollar, fmt.Sprintf("price %v is more than %.2f%% from the last close %v", price, m.limits.PriceCollar*100, lastClose)}
+				}
+			}
+		}
+
+		// The remaining rules only apply to the part of an order that opens or adds to a position
+		opening := openingQuantity(exposure.Position, leg.Side, leg.Quantity)
+		if opening == 0 {
+			continue
+		}
+		if m.limits.MaxDailyLoss > 0 && exposure.DailyPnL <= -m.limits.MaxDailyLoss {
+			return &Rejection{RuleDailyLoss, fmt.Sprintf("lost %.2f today, the limit is %.2f", -exposure.DailyPnL, m.limits.MaxDailyLoss)}
+		}
+		// Open orders on the same side count as if they had filled, as they may
+		next := exposure.Position + leg.Quantity + exposure.OpenBuys
+		if leg.Side == orders.Sell {
+			next = exposure.Position - leg.Quantity - exposure.OpenSells
+		}
+		next = math.Abs(next)
+		if m.limits.MaxPosition > 0 && next > m.limits.MaxPosition {
+			return &Rejection{RuleMaxPosition, fmt.Sprintf("position would be %v, the limit is %v", next, m.limits.MaxPosition)}
+		}
+		price := leg.LimitPrice
+		if price == 0 {
+			price = lastClose
+		}
+		if notional := opening * price; m.limits.MaxNotional > 0 && notional > m.limits.MaxNotional {
+			return &Rejection{RuleMaxNotional, fmt.Sprintf("order is worth %.2f, the limit is %.2f", notional, m.limits.MaxNotional)}
+		}
+	}
+	return nil
+}
+
+// Helper function to get how much of an order opens or adds to a position, rather than reducing it
+func openingQuantity(position float64, side orders.Side, quantity float64) float64 {
+	if position*side.Sign() >= 0 {
+		return quantity
+	}
+	return math.Max(quantity-math.Abs(position), 0)
+}
+
+// Helper function to describe an order in a log line, e.g. "buy 2 BNBBTC market"
+func describe(request orders.Request) string {
+	if request.Type == orders.OCO && len(request.Legs) > 0 {
+		request.Symbol, request.Side, request.Quantity = request.Legs[0].Symbol, request.Legs[0].Side, request.Legs[0].Quantity
+	}
+	return fmt.Sprintf("%s %v %s %s", request.Side, request.Quantity, request.Symbol, request.Type)
+}
@@ -0,0 +1,265 @@
+package scripting
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"go.starlark.net/starlark"
+
+	"github.com/neozhixuan/project-visualgo-backend/trading-algo/financeFunctions"
+	"github.com/neozhixuan/project-visualgo-backend/trading-algo/strategy"
+)
+
+// Loader holds every script of a directory, and reloads them when their files change
+// - a script that fails to reload keeps running its previous version
+// - new indicator scripts are picked up while running, new strategy scripts only once registered on the next start
+type Loader struct {
+	dir    string
+	limits Limits
+
+	mu      sync.RWMutex
+	scripts map[string]*Script   // keyed by name
+	files   map[string]string    // name of the script loaded from each path
+	seen    map[string]time.Time // modification time of each path when it was last loaded, or failed to
+}
+
+// LoadDir loads every .star file in `dir`, failing if any of them does not load
+func LoadDir(dir string, limits Limits) (*Loader, error) {
+	loader := &Loader{dir: dir, limits: limits, scripts: map[string]*Script{}, files: map[string]string{}, seen: map[string]time.Time{}}
+	paths, err := loader.paths()
+	if err != nil {
+		return nil, err
+	}
+	for _, path := range paths {
+		info, err := os.Stat(path)
+		if err != nil {
+			return nil, err
+		}
+		script, err := Load(path, limits)
+		if err != nil {
+			return nil, err
+		}
+		loader.seen[path] = info.ModTime()
+		if err := loader.add(path, script); err != nil {
+			return nil, err
+		}
+	}
+	return loader, nil
+}
+
+// RegisterDir loads every .star file in `dir` and registers their strategies
+func RegisterDir(dir string, limits Limits) (*Loader, error) {
+	loader, err := LoadDir(dir, limits)
+	if err != nil {
+		return nil, err
+	}
+	return loader, loader.Register()
+}
+
+// Register makes every strategy script available to the strategy registry, so it can be run live, backtested and optimized
+func (l *Loader) Register() error {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	for _, name := range l.names(func(script *Script) bool { return script.IsStrategy() }) {
+		if _, exists := strategy.Lookup(name); exists {
+			return fmt.Errorf("strategy script %s has the same name as an existing strategy", name)
+		}
+		name := name
+		strategy.Register(strategy.Definition{
+			Name:     name,
+			Defaults: l.scripts[name].Params,
+			New: func(params strategy.Params) (strategy.Strategy, error) {
+				return &ScriptStrategy{loader: l, name: name, params: params, state: starlark.NewDict(0)}, nil
+			},
+		})
+	}
+	return nil
+}
+
+// Script returns the latest loaded version of a script
+func (l *Loader) Script(name string) (*Script, bool) {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	script, ok := l.scripts[name]
+	return script, ok
+}
+
+// Strategies returns the names of every strategy script, sorted
+func (l *Loader) Strategies() []string {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	return l.names(func(script *Script) bool { return script.IsStrategy() })
+}
+
+// Indicators returns the names of every indicator script, sorted
+func (l *Loader) Indicators() []string {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	return l.names(func(script *Script) bool { return script.IsIndicator() })
+}
+
+// Indicator calculates an indicator script over the candles of `ctx`, with its default parameters overridden by `overrides`
+// - the line covers every candle, and only the last `lookback` of them can be valid
+// - the result is cached in `ctx`, like the built-in indicators
+func (l *Loader) Indicator(ctx *strategy.Context, name string, overrides map[string]float64) (financeFunctions.IndicatorLine, error) {
+	script, ok := l.Script(name)
+	if !ok || !script.IsIndicator() {
+		return financeFunctions.IndicatorLine{}, fmt.Errorf("unknown indicator script %q, expected one of %s", name, strings.Join(l.Indicators(), ", "))
+	}
+	params := map[string]float64{}
+	for key, value := range script.Params {
+		params[key] = value
+	}
+	for key, value := range overrides {
+		if _, ok := script.Params[key]; !ok {
+			return financeFunctions.IndicatorLine{}, fmt.Errorf("indicator script %s has no parameter %q", name, key)
+		}
+		params[key] = value
+	}
+
+	var err error
+	line := ctx.Indicator("script:"+name+":"+strategy.Params(params).Key(), func(candles []financeFunctions.Candlestick) financeFunctions.IndicatorLine {
+		count := len(candles)
+		if script.Lookback > 0 {
+			count = min(count, script.Lookback)
+		}
+		var result starlark.Value
+		result, err = script.call(script.calculate, candlesValue(candles[len(candles)-count:]), paramsValue(params))
+		window := financeFunctions.IndicatorLine{}
+		if err == nil {
+			if window, err = lineFromValue(name, result, count); err != nil {
+				err = fmt.Errorf("script %s: %w", name, err)
+			}
+		}
+
+		// Pad the line to every candle, with the candles before the window marked invalid
+		line := financeFunctions.IndicatorLine{Name: name, Values: make(financeFunctions.Series, len(candles)), Valid: make([]bool, len(candles))}
+		for i := range line.Values {
+			line.Values[i] = math.NaN()
+		}
+		if err == nil {
+			copy(line.Values[len(candles)-count:], window.Values)
+			copy(line.Valid[len(candles)-count:], window.Valid)
+		}
+		return line
+	})
+	return line, err
+}
+
+// Calculate runs every indicator script with its default parameters, skipping any that fails
+func (l *Loader) Calculate(ctx *strategy.Context) map[string]financeFunctions.IndicatorLine {
+	lines := map[string]financeFunctions.IndicatorLine{}
+	for _, name := range l.Indicators() {
+		line, err := l.Indicator(ctx, name, nil)
+		if err != nil {
+			log.Printf("Error while calculating indicator script on %s: %v", ctx.Symbol, err)
+			continue
+		}
+		lines[name] = line
+	}
+	return lines
+}
+
+// Watch reloads scripts whose files changed, checking every `interval` until `ctx` is cancelled
+func (l *Loader) Watch(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			l.reload()
+		}
+	}
+}
+
+// Helper function to reload every new or modified script file
+// - only Watch calls it, so `seen` needs no lock
+func (l *Loader) reload() {
+	paths, err := l.paths()
+	if err != nil {
+		log.Printf("Error while listing scripts: %v", err)
+		return
+	}
+	for _, path := range paths {
+		info, err := os.Stat(path)
+		if err != nil {
+			continue
+		}
+		if info.ModTime().Equal(l.seen[path]) {
+			continue
+		}
+		l.seen[path] = info.ModTime()
+
+		l.mu.RLock()
+		previous, known := l.scripts[l.files[path]]
+		l.mu.RUnlock()
+
+		script, err := Load(path, l.limits)
+		switch {
+		case err != nil:
+		case known && script.Name != previous.Name:
+			err = fmt.Errorf("renaming %s to %s needs a restart", previous.Name, script.Name)
+		case known && script.IsStrategy() != previous.IsStrategy():
+			err = fmt.Errorf("adding or removing on_candle needs a restart")
+		default:
+			err = l.add(path, script)
+		}
+		switch {
+		case err != nil && known:
+			log.Printf("Error while reloading %s, keeping the previous version: %v", path, err)
+		case err != nil:
+			log.Printf("Error while loading %s: %v", path, err)
+		case !known && script.IsStrategy():
+			log.Printf("Loaded new script %s, its strategy is only traded after a restart", script.Name)
+		default:
+			log.Printf("Reloaded script %s", script.Name)
+		}
+	}
+}
+
+// Helper function to add or replace a loaded script, keeping names unique across files
+func (l *Loader) add(path string, script *Script) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if existing, ok := l.scripts[script.Name]; ok && existing.Path != path {
+		return fmt.Errorf("%s and %s both define a script named %s", existing.Path, path, script.Name)
+	}
+	l.scripts[script.Name] = script
+	l.files[path] = script.Name
+	return nil
+}
+
+// Helper function to list the script files, sorted
+func (l *Loader) paths() ([]string, error) {
+	paths, err := filepath.Glob(filepath.Join(l.dir, "*.star"))
+	if err != nil {
+		return nil, err
+	}
+	if _, err := os.Stat(l.dir); err != nil {
+		return nil, err
+	}
+	sort.Strings(paths)
+	return paths, nil
+}
+
+// Helper function to list the names of scripts matching `keep`, sorted
+func (l *Loader) names(keep func(script *Script) bool) []string {
+	var names []string
+	for name, script := range l.scripts {
+		if keep(script) {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,238 @@
+package scripting
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"runtime/metrics"
+	"strings"
+	"time"
+
+	starlarkmath "go.starlark.net/lib/math"
+	"go.starlark.net/starlark"
+	"go.starlark.net/syntax"
+)
+
+// Limits bound what a single call into a script may use
+// - MaxSteps and Timeout are enforced per call, and MaxMemory is a best-effort guard on the whole process's heap
+type Limits struct {
+	MaxSteps  uint64        `json:"maxSteps"`  // Starlark computation steps, roughly one per operation
+	Timeout   time.Duration `json:"timeout"`   // wall clock time
+	MaxMemory uint64        `json:"maxMemory"` // bytes the live heap may grow by while the call runs, see Script.watch
+}
+
+// DefaultLimits returns limits that leave room for a few thousand candles per call
+func DefaultLimits() Limits {
+	return Limits{MaxSteps: 5_000_000, Timeout: time.Second, MaxMemory: 64 << 20}
+}
+
+// Script is a loaded Starlark file, defining a strategy, an indicator or both
+// - a strategy defines `on_candle(ctx)`, an indicator defines `calculate(candles, params)`
+// - `params` is an optional dict of numeric parameters with their defaults, e.g. {"period": 20}
+// - `lookback` is how many recent candles the script is given (default 1000, 0 for all of them)
+// - `name` replaces the file name as the strategy's or indicator's name
+type Script struct {
+	Name     string
+	Path     string
+	Params   map[string]float64
+	Lookback int
+
+	onCandle  starlark.Callable
+	calculate starlark.Callable
+	limits    Limits
+}
+
+// Candles a script sees when it does not set `lookback`
+const defaultLookback = 1000
+
+// How often a running call checks how much the live heap has grown
+const memoryCheckInterval = 5 * time.Millisecond
+
+// Load runs a script file once, under the limits, and reads what it defines
+// - scripts cannot load other files, and only get the `math` module on top of the Starlark built-ins
+func Load(path string, limits Limits) (*Script, error) {
+	source, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	script := &Script{
+		Name:     strings.TrimSuffix(filepath.Base(path), filepath.Ext(path)),
+		Path:     path,
+		Params:   map[string]float64{},
+		Lookback: defaultLookback,
+		limits:   limits,
+	}
+	predeclared := starlark.StringDict{"math": starlarkmath.Module}
+
+	var globals starlark.StringDict
+	err = script.run(func(thread *starlark.Thread) error {
+		var err error
+		globals, err = starlark.ExecFileOptions(&syntax.FileOptions{}, thread, path, source, predeclared)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	globals.Freeze()
+
+	if err := script.read(globals); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return script, nil
+}
+
+// IsStrategy reports whether the script defines on_candle
+func (s *Script) IsStrategy() bool {
+	return s.onCandle != nil
+}
+
+// IsIndicator reports whether the script defines calculate
+func (s *Script) IsIndicator() bool {
+	return s.calculate != nil
+}
+
+// Helper function to read the globals a script defines
+func (s *Script) read(globals starlark.StringDict) error {
+	if value, ok := globals["name"]; ok {
+		name, ok := starlark.AsString(value)
+		if !ok || name == "" {
+			return fmt.Errorf("name must be a non-empty string, got %s", value.Type())
+		}
+		s.Name = name
+	}
+
+	if value, ok := globals["params"]; ok {
+		dict, ok := value.(*starlark.Dict)
+		if !ok {
+			return fmt.Errorf("params must be a dict, got %s", value.Type())
+		}
+		for _, item := range dict.Items() {
+			key, okKey := starlark.AsString(item[0])
+			number, okNumber := starlark.AsFloat(item[1])
+			if !okKey || !okNumber {
+				return fmt.Errorf("params must map names to numbers, got %s: %s", item[0], item[1])
+			}
+			s.Params[key] = number
+		}
+	}
+
+	if value, ok := globals["lookback"]; ok {
+		number, ok := value.(starlark.Int)
+		lookback, exact := number.Int64()
+		if !ok || !exact || lookback < 0 {
+			return fmt.Errorf("lookback must be a non-negative int, got %s", value)
+		}
+		s.Lookback = int(lookback)
+	}
+
+	var err error
+	if s.onCandle, err = function(globals, "on_candle", 1); err != nil {
+		return err
+	}
+	if s.calculate, err = function(globals, "calculate", 2); err != nil {
+		return err
+	}
+	if s.onCandle == nil && s.calculate == nil {
+		return fmt.Errorf("script defines neither on_candle(ctx) nor calculate(candles, params)")
+	}
+	return nil
+}
+
+// Helper function to look up an optional global function taking `params` arguments
+func function(globals starlark.StringDict, name string, params int) (starlark.Callable, error) {
+	value, ok := globals[name]
+	if !ok {
+		return nil, nil
+	}
+	fn, ok := value.(*starlark.Function)
+	if !ok || fn.NumParams() != params {
+		return nil, fmt.Errorf("%s must be a function taking %d arguments", name, params)
+	}
+	return fn, nil
+}
+
+// Helper function to call a script function under the limits
+func (s *Script) call(fn starlark.Callable, args ...starlark.Value) (starlark.Value, error) {
+	var result starlark.Value
+	err := s.run(func(thread *starlark.Thread) error {
+		var err error
+		result, err = starlark.Call(thread, fn, args, nil)
+		return err
+	})
+	return result, err
+}
+
+// Helper function to run Starlark code on a fresh thread, cancelling it once it breaks a limit
+func (s *Script) run(execute func(thread *starlark.Thread) error) error {
+	thread := &starlark.Thread{
+		Name: s.Name,
+		Print: func(_ *starlark.Thread, message string) {
+			log.Printf("Script %s: %s", s.Name, message)
+		},
+	}
+	if s.limits.MaxSteps > 0 {
+		thread.SetMaxExecutionSteps(s.limits.MaxSteps)
+	}
+
+	done := make(chan struct{})
+	defer close(done)
+	go s.watch(thread, heapBytes(), done)
+
+	if err := execute(thread); err != nil {
+		if evalError, ok := err.(*starlark.EvalError); ok {
+			return fmt.Errorf("script %s: %s", s.Name, evalError.Backtrace())
+		}
+		return fmt.Errorf("script %s: %w", s.Name, err)
+	}
+	return nil
+}
+
+// Helper function to cancel a running thread once it runs out of time or memory
+// - Starlark cannot tell what a call allocates, so memory is the live heap of the whole process, as measured by the latest
+// garbage collection: a best-effort guard against runaway scripts, which may also stop a call while other goroutines grow
+// the heap, and may notice a call's growth up to a collection late
+// - collections are left to the runtime, which runs them as the heap grows, rather than forced while checking
+func (s *Script) watch(thread *starlark.Thread, baseline uint64, done chan struct{}) {
+	var deadline <-chan time.Time
+	if s.limits.Timeout > 0 {
+		timer := time.NewTimer(s.limits.Timeout)
+		defer timer.Stop()
+		deadline = timer.C
+	}
+	var ticks <-chan time.Time
+	if s.limits.MaxMemory > 0 {
+		ticker := time.NewTicker(memoryCheckInterval)
+		defer ticker.Stop()
+		ticks = ticker.C
+	}
+
+	for {
+		select {
+		case <-done:
+			return
+		case <-deadline:
+			thread.Cancel(fmt.Sprintf("took longer than %s", s.limits.Timeout))
+			return
+		case <-ticks:
+			if s.overMemory(baseline) {
+				thread.Cancel(fmt.Sprintf("used more than %d MB of memory", s.limits.MaxMemory>>20))
+				return
+			}
+		}
+	}
+}
+
+// Helper function to check whether the live heap grew by more than the limit since `baseline`
+func (s *Script) overMemory(baseline uint64) bool {
+	used := heapBytes()
+	return used > baseline && used-baseline > s.limits.MaxMemory
+}
+
+// Helper function to read the bytes of heap objects found live by the latest garbage collection, which leaves out garbage
+func heapBytes() uint64 {
+	sample := []metrics.Sample{{Name: "/gc/heap/live:bytes"}}
+	metrics.Read(sample)
+	return sample[0].Value.Uint64()
+}
@@ -0,0 +1,45 @@
+package scripting
+
+import (
+	"log"
+
+	"go.starlark.net/starlark"
+
+	"github.com/neozhixuan/project-visualgo-backend/trading-algo/strategy"
+)
+
+// ScriptStrategy runs a strategy script's on_candle on every closed candle
+// - the latest loaded version of the script is used, so edits apply from the next candle
+// - `ctx.state` is a dict kept between candles, for state the script wants to carry
+type ScriptStrategy struct {
+	loader *Loader
+	name   string
+	params strategy.Params
+	state  *starlark.Dict
+}
+
+func (s *ScriptStrategy) Name() string {
+	return s.name
+}
+
+func (s *ScriptStrategy) OnCandle(ctx *strategy.Context) (strategy.Action, string, bool) {
+	script, ok := s.loader.Script(s.name)
+	if !ok || !script.IsStrategy() {
+		return "", "", false
+	}
+
+	result, err := script.call(script.onCandle, s.loader.contextValue(ctx, script, s.params, s.state))
+	if err != nil {
+		log.Printf("Error while running strategy script on %s: %v", ctx.Symbol, err)
+		return "", "", false
+	}
+	action, reason, ok, err := signalFromValue(result)
+	if err != nil {
+		log.Printf("Error while running strategy script %s on %s: %v", s.name, ctx.Symbol, err)
+		return "", "", false
+	}
+	if ok && reason == "" {
+		reason = "script " + s.name
+	}
+	return action, reason, ok
+}
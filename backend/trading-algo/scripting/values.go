@@ -0,0 +1,191 @@
+package scripting
+
+import (
+	"fmt"
+	"math"
+	"sort"
+
+	"go.starlark.net/starlark"
+	"go.starlark.net/starlarkstruct"
+
+	"github.com/neozhixuan/project-visualgo-backend/trading-algo/financeFunctions"
+	"github.com/neozhixuan/project-visualgo-backend/trading-algo/strategy"
+)
+
+// Helper function to turn a candle into a struct with the same field names as Go, in snake case
+func candleValue(candle financeFunctions.Candlestick) starlark.Value {
+	return starlarkstruct.FromStringDict(starlark.String("candle"), starlark.StringDict{
+		"open":       starlark.Float(candle.Open),
+		"high":       starlark.Float(candle.High),
+		"low":        starlark.Float(candle.Low),
+		"close":      starlark.Float(candle.Close),
+		"volume":     starlark.Float(candle.Volume),
+		"open_time":  starlark.MakeInt64(candle.OpenTime),
+		"close_time": starlark.MakeInt64(candle.CloseTime),
+	})
+}
+
+// Helper function to turn candles into a frozen list
+func candlesValue(candles []financeFunctions.Candlestick) *starlark.List {
+	values := make([]starlark.Value, len(candles))
+	for i, candle := range candles {
+		values[i] = candleValue(candle)
+	}
+	list := starlark.NewList(values)
+	list.Freeze()
+	return list
+}
+
+// Helper function to turn the last `count` points of a line into a frozen list, with None for points that are not valid
+func lineValue(line financeFunctions.IndicatorLine, count int) *starlark.List {
+	start := max(len(line.Values)-count, 0)
+	values := make([]starlark.Value, 0, len(line.Values)-start)
+	for i := start; i < len(line.Values); i++ {
+		if line.Valid[i] && !math.IsNaN(line.Values[i]) {
+			values = append(values, starlark.Float(line.Values[i]))
+		} else {
+			values = append(values, starlark.None)
+		}
+	}
+	list := starlark.NewList(values)
+	list.Freeze()
+	return list
+}
+
+// Helper function to turn parameters into a frozen dict
+func paramsValue(params map[string]float64) *starlark.Dict {
+	keys := make([]string, 0, len(params))
+	for key := range params {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	dict := starlark.NewDict(len(params))
+	for _, key := range keys {
+		dict.SetKey(starlark.String(key), starlark.Float(params[key]))
+	}
+	dict.Freeze()
+	return dict
+}
+
+// Helper function to read what calculate returned: one number or None per candle it was given
+func lineFromValue(name string, value starlark.Value, count int) (financeFunctions.IndicatorLine, error) {
+	iterable, ok := value.(starlark.Indexable)
+	if !ok {
+		return financeFunctions.IndicatorLine{}, fmt.Errorf("calculate must return a list, got %s", value.Type())
+	}
+	if iterable.Len() != count {
+		return financeFunctions.IndicatorLine{}, fmt.Errorf("calculate must return a value per candle, got %d values for %d candles", iterable.Len(), count)
+	}
+
+	line := financeFunctions.IndicatorLine{Name: name, Values: make(financeFunctions.Series, count), Valid: make([]bool, count)}
+	for i := 0; i < count; i++ {
+		item := iterable.Index(i)
+		line.Values[i] = math.NaN()
+		if item == starlark.None {
+			continue
+		}
+		number, ok := starlark.AsFloat(item)
+		if !ok {
+			return financeFunctions.IndicatorLine{}, fmt.Errorf("calculate returned %s at %d, expected a number or None", item.Type(), i)
+		}
+		line.Values[i] = number
+		line.Valid[i] = !math.IsNaN(number) && !math.IsInf(number, 0)
+	}
+	return line, nil
+}
+
+// Helper function to read what on_candle returned: None, an action, or an action and a reason
+func signalFromValue(value starlark.Value) (action strategy.Action, reason string, ok bool, err error) {
+	if value == starlark.None {
+		return "", "", false, nil
+	}
+
+	if tuple, isTuple := value.(starlark.Tuple); isTuple {
+		if len(tuple) != 2 {
+			return "", "", false, fmt.Errorf("on_candle must return an action, (action, reason) or None, got a tuple of %d", len(tuple))
+		}
+		text, isString := starlark.AsString(tuple[1])
+		if !isString {
+			return "", "", false, fmt.Errorf("on_candle returned a %s reason, expected a string", tuple[1].Type())
+		}
+		value, reason = tuple[0], text
+	}
+
+	text, isString := starlark.AsString(value)
+	switch action := strategy.Action(text); {
+	case !isString:
+		return "", "", false, fmt.Errorf("on_candle must return an action, (action, reason) or None, got %s", value.Type())
+	case action == strategy.Buy || action == strategy.Sell || action == strategy.Flat:
+		return action, reason, true, nil
+	default:
+		return "", "", false, fmt.Errorf("on_candle returned %q, expected \"buy\", \"sell\" or \"flat\"", text)
+	}
+}
+
+// Helper function to build the `ctx` a strategy script's on_candle receives
+// - candles and every indicator list cover the same last `lookback` candles, oldest first
+// - indicators are shared with the Go strategies on the symbol, through the strategy context's cache
+func (l *Loader) contextValue(ctx *strategy.Context, script *Script, params strategy.Params, state *starlark.Dict) starlark.Value {
+	count := len(ctx.Candles)
+	if script.Lookback > 0 {
+		count = min(count, script.Lookback)
+	}
+	window := ctx.Candles[len(ctx.Candles)-count:]
+
+	period := func(name string, calculate func(period int) financeFunctions.IndicatorLine) *starlark.Builtin {
+		return starlark.NewBuiltin(name, func(_ *starlark.Thread, fn *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+			var n int
+			if err := starlark.UnpackPositionalArgs(fn.Name(), args, kwargs, 1, &n); err != nil {
+				return nil, err
+			}
+			if n <= 0 {
+				return nil, fmt.Errorf("%s: period must be positive, got %d", fn.Name(), n)
+			}
+			return lineValue(calculate(n), count), nil
+		})
+	}
+
+	return starlarkstruct.FromStringDict(starlark.String("context"), starlark.StringDict{
+		"symbol":  starlark.String(ctx.Symbol),
+		"candles": candlesValue(window),
+		"last":    candleValue(ctx.Last()),
+		"params":  paramsValue(params),
+		"state":   state,
+		"ema":     period("ema", ctx.EMA),
+		"rsi":     period("rsi", ctx.RSI),
+		"sma":     period("sma", ctx.SMA),
+		"atr":     period("atr", ctx.ATR),
+		"indicator": starlark.NewBuiltin("indicator", func(_ *starlark.Thread, fn *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+			var name string
+			if err := starlark.UnpackPositionalArgs(fn.Name(), args, nil, 1, &name); err != nil {
+				return nil, err
+			}
+			overrides := map[string]float64{}
+			for _, kwarg := range kwargs {
+				number, ok := starlark.AsFloat(kwarg[1])
+				if !ok {
+					return nil, fmt.Errorf("indicator: parameter %s must be a number, got %s", kwarg[0], kwarg[1].Type())
+				}
+				overrides[string(kwarg[0].(starlark.String))] = number
+			}
+			line, err := l.Indicator(ctx, name, overrides)
+			if err != nil {
+				return nil, err
+			}
+			return lineValue(line, count), nil
+		}),
+		"timeframe": starlark.NewBuiltin("timeframe", func(_ *starlark.Thread, fn *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+			var value string
+			if err := starlark.UnpackPositionalArgs(fn.Name(), args, kwargs, 1, &value); err != nil {
+				return nil, err
+			}
+			timeframe, err := financeFunctions.ParseTimeframe(value)
+			if err != nil {
+				return nil, err
+			}
+			candles := ctx.Timeframe(timeframe)
+			return candlesValue(candles[max(len(candles)-count, 0):]), nil
+		}),
+	})
+}
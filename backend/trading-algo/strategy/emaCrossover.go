@@ -0,0 +1,42 @@
+package strategy
+
+import "fmt"
+
+func init() {
+	Register(Definition{
+		Name:     "emaCrossover",
+		Defaults: Params{"fast": 9, "slow": 21},
+		New: func(params Params) (Strategy, error) {
+			fast, slow := int(params["fast"]), int(params["slow"])
+			if fast <= 0 || slow <= fast {
+				return nil, fmt.Errorf("emaCrossover needs 0 < fast < slow, got fast=%d slow=%d", fast, slow)
+			}
+			return &EMACrossover{Fast: fast, Slow: slow}, nil
+		},
+	})
+}
+
+// EMACrossover buys when the fast EMA crosses above the slow EMA, and sells when it crosses below
+type EMACrossover struct {
+	Fast, Slow int
+}
+
+func (s *EMACrossover) Name() string {
+	return "emaCrossover"
+}
+
+func (s *EMACrossover) OnCandle(ctx *Context) (Action, string, bool) {
+	previousFast, fast, okFast := lastTwo(ctx.EMA(s.Fast))
+	previousSlow, slow, okSlow := lastTwo(ctx.EMA(s.Slow))
+	if !okFast || !okSlow {
+		return "", "", false
+	}
+
+	switch {
+	case previousFast <= previousSlow && fast > slow:
+		return Buy, fmt.Sprintf("EMA%d crossed above EMA%d", s.Fast, s.Slow), true
+	case previousFast >= previousSlow && fast < slow:
+		return Sell, fmt.Sprintf("EMA%d crossed below EMA%d", s.Fast, s.Slow), true
+	}
+	return "", "", false
+}
@@ -0,0 +1,121 @@
+package strategy
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Params are a strategy's numeric settings, e.g. {"fast": 9, "slow": 21}
+type Params map[string]float64
+
+// Key identifies a set of parameters, e.g. "fast=9,slow=21", the same whatever order they were set in
+func (p Params) Key() string {
+	names := make([]string, 0, len(p))
+	for name := range p {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	parts := make([]string, len(names))
+	for i, name := range names {
+		parts[i] = name + "=" + strconv.FormatFloat(p[name], 'f', -1, 64)
+	}
+	return strings.Join(parts, ",")
+}
+
+// Definition describes a strategy that can be created by name
+type Definition struct {
+	Name     string
+	Defaults Params                                // every parameter the strategy accepts, with its default value
+	New      func(params Params) (Strategy, error) // params always hold every default, overridden by what was given
+}
+
+var registry = map[string]Definition{}
+
+// Register makes a strategy available to New, ParseConfig and the tools built on them
+func Register(definition Definition) {
+	registry[definition.Name] = definition
+}
+
+// Lookup returns the definition of a registered strategy
+func Lookup(name string) (Definition, bool) {
+	definition, ok := registry[name]
+	return definition, ok
+}
+
+// Names returns every registered strategy, sorted
+func Names() []string {
+	names := make([]string, 0, len(registry))
+	for name := range registry {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// New creates a registered strategy, filling in any parameter not given with its default
+func New(name string, params Params) (Strategy, error) {
+	definition, ok := registry[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown strategy %q, expected one of %s", name, strings.Join(Names(), ", "))
+	}
+
+	merged := Params{}
+	for key, value := range definition.Defaults {
+		merged[key] = value
+	}
+	for key, value := range params {
+		if _, ok := definition.Defaults[key]; !ok {
+			return nil, fmt.Errorf("strategy %q has no parameter %q", name, key)
+		}
+		merged[key] = value
+	}
+	return definition.New(merged)
+}
+
+// Factory creates a fresh instance of a configured strategy, one per symbol
+type Factory func() Strategy
+
+// NewFactory checks a strategy's configuration once, and returns a Factory for it
+func NewFactory(name string, params Params) (Factory, error) {
+	if _, err := New(name, params); err != nil {
+		return nil, err
+	}
+	return func() Strategy {
+		strategy, _ := New(name, params)
+		return strategy
+	}, nil
+}
+
+// ParseConfig reads a list of strategies such as "emaCrossover:fast=9,slow=21;rsiMeanReversion"
+func ParseConfig(value string) ([]Factory, error) {
+	var factories []Factory
+	for _, entry := range strings.Split(value, ";") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+
+		name, settings, _ := strings.Cut(entry, ":")
+		params := Params{}
+		for _, setting := range strings.Split(settings, ",") {
+			if strings.TrimSpace(setting) == "" {
+				continue
+			}
+			key, raw, found := strings.Cut(setting, "=")
+			number, err := strconv.ParseFloat(strings.TrimSpace(raw), 64)
+			if !found || err != nil {
+				return nil, fmt.Errorf("invalid setting %q for strategy %q, expected key=number", setting, name)
+			}
+			params[strings.TrimSpace(key)] = number
+		}
+
+		factory, err := NewFactory(strings.TrimSpace(name), params)
+		if err != nil {
+			return nil, err
+		}
+		factories = append(factories, factory)
+	}
+	return factories, nil
+}
@@ -0,0 +1,55 @@
+package strategy
+
+import "fmt"
+
+func init() {
+	Register(Definition{
+		Name:     "rsiMeanReversion",
+		Defaults: Params{"period": 14, "oversold": 30, "overbought": 70, "exit": 50},
+		New: func(params Params) (Strategy, error) {
+			s := &RSIMeanReversion{
+				Period:     int(params["period"]),
+				Oversold:   params["oversold"],
+				Overbought: params["overbought"],
+				Exit:       params["exit"],
+			}
+			if s.Period <= 0 || !(s.Oversold < s.Exit && s.Exit < s.Overbought) {
+				return nil, fmt.Errorf("rsiMeanReversion needs period > 0 and oversold < exit < overbought, got %+v", *s)
+			}
+			return s, nil
+		},
+	})
+}
+
+// RSIMeanReversion buys when the RSI is oversold and sells when it is overbought,
+// going flat once the RSI is back at the exit level
+type RSIMeanReversion struct {
+	Period                     int
+	Oversold, Overbought, Exit float64
+
+	position Action
+}
+
+func (s *RSIMeanReversion) Name() string {
+	return "rsiMeanReversion"
+}
+
+func (s *RSIMeanReversion) OnCandle(ctx *Context) (Action, string, bool) {
+	_, rsi, ok := lastTwo(ctx.RSI(s.Period))
+	if !ok {
+		return "", "", false
+	}
+
+	switch {
+	case rsi < s.Oversold:
+		s.position = Buy
+		return Buy, fmt.Sprintf("RSI%d at %.1f is below %g", s.Period, rsi, s.Oversold), true
+	case rsi > s.Overbought:
+		s.position = Sell
+		return Sell, fmt.Sprintf("RSI%d at %.1f is above %g", s.Period, rsi, s.Overbought), true
+	case s.position == Buy && rsi >= s.Exit, s.position == Sell && rsi <= s.Exit:
+		s.position = Flat
+		return Flat, fmt.Sprintf("RSI%d at %.1f is back at %g", s.Period, rsi, s.Exit), true
+	}
+	return "", "", false
+}
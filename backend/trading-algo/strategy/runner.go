@@ -0,0 +1,65 @@
+package strategy
+
+import "github.com/neozhixuan/project-visualgo-backend/trading-algo/financeFunctions"
+
+// Runner feeds closed candles of any number of symbols through every configured strategy
+// - the live gRPC client and the backtester both drive strategies through a Runner
+// - a signal is only emitted when a strategy changes its mind, not on every candle it repeats itself
+type Runner struct {
+	factories []Factory
+	symbols   map[string]*symbolState
+}
+
+// Strategy instances of a single symbol
+type symbolState struct {
+	strategies []Strategy
+	actions    []Action // last action of each strategy
+}
+
+// NewRunner creates a Runner that runs one instance of every strategy per symbol
+func NewRunner(factories ...Factory) *Runner {
+	return &Runner{factories: factories, symbols: map[string]*symbolState{}}
+}
+
+// OnCandle runs the strategies of `symbol` on its closed candles, and returns the signals the last candle triggers
+// - `candles` holds every closed candle of the symbol so far, oldest first
+func (r *Runner) OnCandle(symbol string, candles []financeFunctions.Candlestick) []Signal {
+	if len(candles) == 0 {
+		return nil
+	}
+	return r.OnContext(NewContext(symbol, candles))
+}
+
+// OnContext runs the strategies of the context's symbol on its candles, and returns the signals the last candle triggers
+// - e.g. on series.At(i) for every candle i of a replayed Series
+func (r *Runner) OnContext(ctx *Context) []Signal {
+	symbol := ctx.Symbol
+	state, ok := r.symbols[symbol]
+	if !ok {
+		state = &symbolState{}
+		for _, factory := range r.factories {
+			state.strategies = append(state.strategies, factory())
+		}
+		state.actions = make([]Action, len(state.strategies))
+		r.symbols[symbol] = state
+	}
+
+	candle := ctx.Last()
+	var signals []Signal
+	for i, strategy := range state.strategies {
+		action, reason, ok := strategy.OnCandle(ctx)
+		if !ok || action == state.actions[i] {
+			continue
+		}
+		state.actions[i] = action
+		signals = append(signals, Signal{
+			Strategy: strategy.Name(),
+			Symbol:   symbol,
+			Action:   action,
+			Price:    candle.Close,
+			Reason:   reason,
+			Time:     candle.CloseTime,
+		})
+	}
+	return signals
+}
@@ -0,0 +1,179 @@
+package strategy
+
+import (
+	"fmt"
+
+	"github.com/neozhixuan/project-visualgo-backend/trading-algo/financeFunctions"
+)
+
+// Action is the position a strategy wants to hold
+type Action string
+
+const (
+	Buy  Action = "buy"  // hold a long position
+	Sell Action = "sell" // hold a short position, or get out of a long one when shorting is not possible
+	Flat Action = "flat" // hold no position
+)
+
+// Signal is a strategy's decision on a closed candle
+type Signal struct {
+	Strategy string  `json:"strategy"`
+	Symbol   string  `json:"symbol"`
+	Action   Action  `json:"action"`
+	Price    float64 `json:"price"`  // close of the candle that triggered the signal
+	Reason   string  `json:"reason"` // human readable explanation, e.g. "EMA9 crossed above EMA21"
+	Time     int64   `json:"time"`   // close time of the candle that triggered the signal, in Unix milliseconds
+}
+
+// Strategy decides which position to hold for a single symbol
+// - each symbol gets its own instance, so a strategy can keep state between candles
+type Strategy interface {
+	// Name identifies the strategy in signals, e.g. "emaCrossover"
+	Name() string
+
+	// OnCandle is called on every closed candle, returning the action the strategy wants and why
+	// - returning false means the strategy has no opinion yet (e.g. its indicators are warming up)
+	OnCandle(ctx *Context) (action Action, reason string, ok bool)
+}
+
+// Context is what a strategy sees on a closed candle
+// - indicators are calculated on demand and shared between every strategy on the symbol
+// - a context cut from a Series shares the built-in indicators calculated once over the whole series
+type Context struct {
+	Symbol  string
+	Candles []financeFunctions.Candlestick // every closed candle so far, oldest first
+
+	cache  map[string]interface{}
+	series *Series // series the context is cut from, or nil
+}
+
+// NewContext creates the context for the latest candle in `candles`
+func NewContext(symbol string, candles []financeFunctions.Candlestick) *Context {
+	return &Context{Symbol: symbol, Candles: candles, cache: map[string]interface{}{}}
+}
+
+// Series is a whole history of candles replayed one at a time, e.g. by the backtester
+// - the built-in indicators and timeframes only look back, so each is calculated once over the whole series and cut at every candle,
+// rather than calculated again over every candle so far, which made replays quadratic in the candles
+type Series struct {
+	symbol  string
+	candles []financeFunctions.Candlestick
+	cache   map[string]interface{}
+}
+
+// A higher timeframe built from a series, with how many of its candles are complete after each candle of the series
+type seriesTimeframe struct {
+	candles   []financeFunctions.Candlestick
+	completed []int
+}
+
+// NewSeries creates the series of `candles`, oldest first
+func NewSeries(symbol string, candles []financeFunctions.Candlestick) *Series {
+	return &Series{symbol: symbol, candles: candles, cache: map[string]interface{}{}}
+}
+
+// At creates the context of candle i of the series, seeing the candles up to and including it
+func (s *Series) At(i int) *Context {
+	return &Context{Symbol: s.symbol, Candles: s.candles[: i+1 : i+1], cache: map[string]interface{}{}, series: s}
+}
+
+// Last returns the candle that was just closed
+func (c *Context) Last() financeFunctions.Candlestick {
+	return c.Candles[len(c.Candles)-1]
+}
+
+// Indicator calculates an indicator line once per candle, keyed by `key` (e.g. "ema:9")
+func (c *Context) Indicator(key string, calculate func([]financeFunctions.Candlestick) financeFunctions.IndicatorLine) financeFunctions.IndicatorLine {
+	if line, ok := c.cache[key].(financeFunctions.IndicatorLine); ok {
+		return line
+	}
+	line := calculate(c.Candles)
+	c.cache[key] = line
+	return line
+}
+
+// Helper function to calculate a built-in indicator, once over the whole series when the context is cut from one
+// - `calculate` must only look back, so the value at every candle is the same whether or not later candles are there
+func (c *Context) builtIn(key string, calculate func([]financeFunctions.Candlestick) financeFunctions.IndicatorLine) financeFunctions.IndicatorLine {
+	if c.series == nil {
+		return c.Indicator(key, calculate)
+	}
+	line, ok := c.series.cache[key].(financeFunctions.IndicatorLine)
+	if !ok {
+		line = calculate(c.series.candles)
+		c.series.cache[key] = line
+	}
+	end := min(len(c.Candles), len(line.Values), len(line.Valid))
+	return financeFunctions.IndicatorLine{Name: line.Name, Values: line.Values[:end:end], Valid: line.Valid[:end:end]}
+}
+
+// EMA returns the SMA seeded EMA of closes
+func (c *Context) EMA(period int) financeFunctions.IndicatorLine {
+	return c.builtIn(fmt.Sprintf("ema:%d", period), func(candles []financeFunctions.Candlestick) financeFunctions.IndicatorLine {
+		return financeFunctions.CalculateEMA(candles, period, financeFunctions.SeedSMA)
+	})
+}
+
+// RSI returns the Wilder RSI of closes
+func (c *Context) RSI(period int) financeFunctions.IndicatorLine {
+	return c.builtIn(fmt.Sprintf("rsi:%d", period), func(candles []financeFunctions.Candlestick) financeFunctions.IndicatorLine {
+		return financeFunctions.CalculateRSI(candles, period)
+	})
+}
+
+// SMA returns the simple moving average of closes
+func (c *Context) SMA(period int) financeFunctions.IndicatorLine {
+	return c.builtIn(fmt.Sprintf("sma:%d", period), func(candles []financeFunctions.Candlestick) financeFunctions.IndicatorLine {
+		return financeFunctions.CalculateSMA(candles, period)
+	})
+}
+
+// ATR returns the Wilder ATR
+func (c *Context) ATR(period int) financeFunctions.IndicatorLine {
+	return c.builtIn(fmt.Sprintf("atr:%d", period), func(candles []financeFunctions.Candlestick) financeFunctions.IndicatorLine {
+		return financeFunctions.CalculateATR(candles, period)
+	})
+}
+
+// Timeframe returns the complete candles of a higher timeframe, built from the closed candles
+// - e.g. a strategy on 1m candles can read the 1h trend from ctx.Timeframe(financeFunctions.Timeframe1h)
+func (c *Context) Timeframe(timeframe financeFunctions.Timeframe) []financeFunctions.Candlestick {
+	key := "timeframe:" + string(timeframe)
+	if c.series != nil {
+		return c.series.timeframe(key, timeframe, len(c.Candles))
+	}
+	if candles, ok := c.cache[key].([]financeFunctions.Candlestick); ok {
+		return candles
+	}
+	candles := financeFunctions.Resample(c.Candles, timeframe)
+	c.cache[key] = candles
+	return candles
+}
+
+// Helper function to find the candles of a higher timeframe complete after the first `seen` candles of the series
+func (s *Series) timeframe(key string, timeframe financeFunctions.Timeframe, seen int) []financeFunctions.Candlestick {
+	built, ok := s.cache[key].(*seriesTimeframe)
+	if !ok {
+		built = &seriesTimeframe{completed: make([]int, len(s.candles))}
+		resampler := financeFunctions.NewResampler(timeframe)
+		for i, candle := range s.candles {
+			built.candles = append(built.candles, resampler.Add(candle)...)
+			built.completed[i] = len(built.candles)
+		}
+		s.cache[key] = built
+	}
+	if seen == 0 {
+		return nil
+	}
+	n := built.completed[seen-1]
+	return built.candles[:n:n]
+}
+
+// Helper function to read the last two values of a line, only if both are valid
+func lastTwo(line financeFunctions.IndicatorLine) (previous, current float64, ok bool) {
+	n := len(line.Values)
+	if n < 2 || !line.Valid[n-1] || !line.Valid[n-2] {
+		return 0, 0, false
+	}
+	return line.Values[n-2], line.Values[n-1], true
+}
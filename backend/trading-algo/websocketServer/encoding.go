@@ -0,0 +1,98 @@
+package websocketServer
+
+import (
+	"bytes"
+	"encoding/json"
+
+	"github.com/neozhixuan/project-visualgo-backend/pb"
+	"github.com/neozhixuan/project-visualgo-backend/streamHub"
+	"github.com/vmihailenco/msgpack/v5"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+// Helper function to encode a message
+// - protobuf messages are pb.StreamMessage, batched by the hub as pb.StreamMessageBatch
+func encode(message Message, e streamHub.Encoding) ([]byte, error) {
+	switch e {
+	case streamHub.EncodingProtobuf:
+		stream, err := streamMessage(message)
+		if err != nil {
+			return nil, err
+		}
+		return proto.Marshal(stream)
+	case streamHub.EncodingMsgpack:
+		jsonBytes, err := json.Marshal(message)
+		if err != nil {
+			return nil, err
+		}
+		// Decode the JSON envelope again, so MessagePack holds exactly what JSON clients get
+		decoder := json.NewDecoder(bytes.NewReader(jsonBytes))
+		decoder.UseNumber()
+		var value interface{}
+		if err := decoder.Decode(&value); err != nil {
+			return nil, err
+		}
+		return msgpack.Marshal(msgpackValue(value))
+	default:
+		return json.Marshal(message)
+	}
+}
+
+// Helper function to convert a message to its protobuf form
+// - Data is carried in its JSON form, as a google.protobuf.Value
+func streamMessage(message Message) (*pb.StreamMessage, error) {
+	stream := &pb.StreamMessage{
+		Version:   int32(message.Version),
+		Type:      message.Type,
+		Channel:   message.Channel,
+		Symbol:    message.Symbol,
+		Interval:  message.Interval,
+		Indicator: message.Indicator,
+		Params:    message.Params,
+		OpenTime:  message.OpenTime,
+		WarmUp:    message.WarmUp,
+	}
+	if message.Values != nil {
+		stream.Values = make(map[string]*structpb.Value, len(message.Values))
+		for name, value := range message.Values {
+			stream.Values[name] = structpb.NewNullValue()
+			if value != nil {
+				stream.Values[name] = structpb.NewNumberValue(*value)
+			}
+		}
+	}
+	if message.Data != nil {
+		jsonBytes, err := json.Marshal(message.Data)
+		if err != nil {
+			return nil, err
+		}
+		stream.Data = &structpb.Value{}
+		if err := protojson.Unmarshal(jsonBytes, stream.Data); err != nil {
+			return nil, err
+		}
+	}
+	return stream, nil
+}
+
+// Helper function to turn the numbers of decoded JSON into integers where they are whole, and floats elsewhere, for MessagePack
+func msgpackValue(value interface{}) interface{} {
+	switch v := value.(type) {
+	case json.Number:
+		if i, err := v.Int64(); err == nil {
+			return i
+		}
+		f, _ := v.Float64()
+		return f
+	case []interface{}:
+		for i := range v {
+			v[i] = msgpackValue(v[i])
+		}
+	case map[string]interface{}:
+		for key := range v {
+			v[key] = msgpackValue(v[key])
+		}
+	}
+	return value
+}
@@ -0,0 +1,96 @@
+package websocketServer
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/neozhixuan/project-visualgo-backend/trading-algo/financeFunctions"
+)
+
+// EnvelopeVersion is sent in every message as "v", and bumped whenever the envelope changes in a breaking way
+const EnvelopeVersion = 1
+
+// Message is a single update pushed to WebSocket clients, in a versioned envelope
+// - Type tells the client how to read the rest, e.g. "snapshot", "candle", "indicator" or "signal"
+// - Channel is what clients subscribe to, e.g. "kline:BNBBTC:1m" or "ema:BNBBTC:1m:9"
+// - indicator messages carry one point: Indicator, Params, OpenTime, Values and WarmUp
+// - other messages carry their payload in Data
+type Message struct {
+	Version   int                 `json:"v"`
+	Type      string              `json:"type"`
+	Channel   string              `json:"channel,omitempty"`
+	Symbol    string              `json:"symbol,omitempty"`
+	Interval  string              `json:"interval,omitempty"`
+	Indicator string              `json:"indicator,omitempty"`
+	Params    map[string]float64  `json:"params,omitempty"`
+	OpenTime  int64               `json:"openTime,omitempty"` // open time of the candle the message is about, in Unix milliseconds
+	Values    map[string]*float64 `json:"values,omitempty"`   // value of each output line, null where the line has no valid value
+	WarmUp    bool                `json:"warmUp,omitempty"`   // true when no output line has a valid value yet
+	Data      interface{}         `json:"data,omitempty"`
+
+	// Conflate marks messages that only carry their channel's latest state, like tick charts, so rate limited clients may skip them for a later one
+	Conflate bool `json:"-"`
+}
+
+// Param is a named indicator parameter, whose values make up the end of the indicator's channel in order
+type Param struct {
+	Name  string
+	Value float64
+}
+
+// Helper function to name the channel a message is published on
+// - unless it is set, it is the type followed by the symbol and interval, e.g. "signal:BNBBTC:1m", or only the type when it has neither, e.g. "balance"
+func (m Message) channel() string {
+	if m.Channel != "" {
+		return m.Channel
+	}
+	segments := []string{m.Type}
+	for _, segment := range []string{m.Symbol, m.Interval} {
+		if segment != "" {
+			segments = append(segments, segment)
+		}
+	}
+	return strings.Join(segments, ":")
+}
+
+// IndicatorChannel names an indicator's channel, e.g. "ema:BNBBTC:1m:9"
+func IndicatorChannel(symbol, interval, name string, params []Param) string {
+	segments := []string{name, symbol, interval}
+	for _, param := range params {
+		segments = append(segments, strconv.FormatFloat(param.Value, 'g', -1, 64))
+	}
+	return strings.Join(segments, ":")
+}
+
+// Snapshot is the Data of the "snapshot" message every client receives on connect
+// - later "candle" and "indicator" messages update it point by point, keyed by openTime
+type Snapshot struct {
+	Candles    []CandleSeries    `json:"candles"`
+	Indicators []IndicatorSeries `json:"indicators"`
+	Latest     []Message         `json:"latest"` // last message of every type that carries its full state, e.g. "profile"
+}
+
+// CandleSeries is the recent closed candles of a symbol and interval
+type CandleSeries struct {
+	Channel  string                         `json:"channel"`
+	Symbol   string                         `json:"symbol"`
+	Interval string                         `json:"interval"`
+	Candles  []financeFunctions.Candlestick `json:"candles"`
+}
+
+// IndicatorSeries is the recent points of an indicator on a symbol and interval
+type IndicatorSeries struct {
+	Channel   string             `json:"channel"`
+	Symbol    string             `json:"symbol"`
+	Interval  string             `json:"interval"`
+	Indicator string             `json:"indicator"`
+	Params    map[string]float64 `json:"params,omitempty"`
+	Points    []Point            `json:"points"`
+}
+
+// Point is an indicator's value at one candle, the same fields an "indicator" message carries
+type Point struct {
+	OpenTime int64               `json:"openTime"`
+	Values   map[string]*float64 `json:"values"`
+	WarmUp   bool                `json:"warmUp,omitempty"`
+}
@@ -0,0 +1,41 @@
+package websocketServer
+
+import (
+	"github.com/neozhixuan/project-visualgo-backend/streamHub"
+)
+
+// NewHub creates a streamHub.Hub for the stream's messages, letting clients in as `access` allows
+// - every message is sent in the versioned envelope, and a new client first gets a snapshot of the stream
+// - clients only get the channels they subscribed to, and get a snapshot of those channels whenever they subscribe
+func NewHub(access streamHub.Access, stream *Stream) *streamHub.Hub {
+	return streamHub.NewHub(streamHub.Config{
+		Access:  access,
+		Version: EnvelopeVersion,
+		Snapshot: func(wanted func(channel string) bool) streamHub.Message {
+			snapshot := stream.Snapshot(wanted)
+			snapshot.Version = EnvelopeVersion
+			return hubMessage(snapshot, "")
+		},
+	})
+}
+
+// Run broadcasts every update of `updateChannel` to every client, until the channel is closed
+func Run(hub *streamHub.Hub, updateChannel chan Message) {
+	for update := range updateChannel {
+		update.Version = EnvelopeVersion
+		update.Channel = update.channel()
+		hub.Broadcast(hubMessage(update, update.Channel))
+	}
+}
+
+// Helper function to wrap a message for the hub, published on `channel`, or on none for snapshots
+func hubMessage(message Message, channel string) streamHub.Message {
+	return streamHub.Message{
+		Type:     message.Type,
+		Channel:  channel,
+		Conflate: message.Conflate,
+		Encode: func(e streamHub.Encoding) ([]byte, error) {
+			return encode(message, e)
+		},
+	}
+}
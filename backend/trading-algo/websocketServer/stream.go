@@ -0,0 +1,210 @@
+package websocketServer
+
+import (
+	"math"
+	"sync"
+
+	"github.com/neozhixuan/project-visualgo-backend/trading-algo/financeFunctions"
+)
+
+// SnapshotPoints is how many points of every candle, indicator and chart series are kept for the snapshot
+const SnapshotPoints = 500
+
+// Stream remembers what has been sent to clients, so a new client can start from a snapshot and every client only gets what changed
+// - indicators are recalculated over the whole history on every candle, and only their new or changed points are sent
+// - the last few hundred points of every series are kept, older ones are dropped from the snapshot
+type Stream struct {
+	mu         sync.Mutex
+	candles    map[string]*CandleSeries    // keyed by channel
+	indicators map[string]*IndicatorSeries // keyed by channel
+	charts     map[string][]interface{}    // last items of every chart, keyed by channel
+	latest     map[string]Message          // keyed by channel
+	order      []string                    // channels in the order they first appeared, so snapshots are stable
+}
+
+// NewStream creates a Stream that has sent nothing yet
+func NewStream() *Stream {
+	return &Stream{
+		candles:    map[string]*CandleSeries{},
+		indicators: map[string]*IndicatorSeries{},
+		charts:     map[string][]interface{}{},
+		latest:     map[string]Message{},
+	}
+}
+
+// Candle records a closed candle and returns the message announcing it
+func (s *Stream) Candle(symbol, interval string, candle financeFunctions.Candlestick) Message {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	channel := "kline:" + symbol + ":" + interval
+	series, known := s.candles[channel]
+	if !known {
+		series = &CandleSeries{Channel: channel, Symbol: symbol, Interval: interval}
+		s.candles[channel] = series
+		s.order = append(s.order, channel)
+	}
+	candles := append(series.Candles, candle)
+	series.Candles = candles[max(len(candles)-SnapshotPoints, 0):]
+	return Message{Type: "candle", Channel: channel, Symbol: symbol, Interval: interval, OpenTime: candle.OpenTime, Data: candle}
+}
+
+// Indicator records an indicator's lines, calculated over `candles`, and returns a message for every new or changed point
+// - lines may run past the last candle, like the projected Ichimoku cloud, whose points are one interval apart
+func (s *Stream) Indicator(symbol, interval, name string, params []Param, candles []financeFunctions.Candlestick, lines []financeFunctions.IndicatorLine) []Message {
+	if len(candles) == 0 || len(lines) == 0 {
+		return nil
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	channel := IndicatorChannel(symbol, interval, name, params)
+	series, known := s.indicators[channel]
+	if !known {
+		series = &IndicatorSeries{Channel: channel, Symbol: symbol, Interval: interval, Indicator: name}
+		for _, param := range params {
+			if series.Params == nil {
+				series.Params = map[string]float64{}
+			}
+			series.Params[param.Name] = param.Value
+		}
+		s.indicators[channel] = series
+		s.order = append(s.order, channel)
+	}
+	previous := make(map[int64]Point, len(series.Points))
+	for _, point := range series.Points {
+		previous[point.OpenTime] = point
+	}
+
+	// Rebuild the kept points from the latest lines, and send those that differ from what was sent before
+	last := candles[len(candles)-1]
+	step := last.CloseTime - last.OpenTime + 1
+	length := len(lines[0].Values)
+	var points []Point
+	var messages []Message
+	for i := max(length-SnapshotPoints, 0); i < length; i++ {
+		openTime := last.OpenTime + int64(i-len(candles)+1)*step
+		if i < len(candles) {
+			openTime = candles[i].OpenTime
+		}
+		point := PointAt(lines, i, openTime)
+		points = append(points, point)
+		if sent, ok := previous[openTime]; ok && samePoint(sent, point) {
+			continue
+		}
+		messages = append(messages, Message{
+			Type:      "indicator",
+			Channel:   channel,
+			Symbol:    symbol,
+			Interval:  interval,
+			Indicator: name,
+			Params:    series.Params,
+			OpenTime:  point.OpenTime,
+			Values:    point.Values,
+			WarmUp:    point.WarmUp,
+		})
+	}
+	series.Points = points
+	return messages
+}
+
+// Latest records a message that carries its channel's full state, like "profile", so new clients get it in the snapshot, and returns it
+func (s *Stream) Latest(message Message) Message {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	message.Channel = message.channel()
+	if _, known := s.latest[message.Channel]; !known {
+		s.order = append(s.order, message.Channel)
+	}
+	s.latest[message.Channel] = message
+	return message
+}
+
+// Chart records a message adding `items` to the end of its channel's chart, like the bricks a price completed, and returns it with them as its Data
+// - new clients get the last SnapshotPoints items of the chart as one message of the same type in the snapshot
+func (s *Stream) Chart(message Message, items []interface{}) Message {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	message.Channel = message.channel()
+	if _, known := s.latest[message.Channel]; !known {
+		s.order = append(s.order, message.Channel)
+	}
+	kept := append(s.charts[message.Channel], items...)
+	kept = kept[max(len(kept)-SnapshotPoints, 0):]
+	s.charts[message.Channel] = kept
+
+	full := message
+	full.Data = kept[:len(kept):len(kept)]
+	s.latest[message.Channel] = full
+	message.Data = items
+	return message
+}
+
+// Snapshot returns the "snapshot" message holding everything recorded so far on the channels `wanted` accepts
+func (s *Stream) Snapshot(wanted func(channel string) bool) Message {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	snapshot := Snapshot{Candles: []CandleSeries{}, Indicators: []IndicatorSeries{}, Latest: []Message{}}
+	for _, channel := range s.order {
+		if !wanted(channel) {
+			continue
+		}
+		if series, ok := s.candles[channel]; ok {
+			snapshot.Candles = append(snapshot.Candles, CandleSeries{
+				Channel:  series.Channel,
+				Symbol:   series.Symbol,
+				Interval: series.Interval,
+				Candles:  series.Candles[:len(series.Candles):len(series.Candles)],
+			})
+		}
+		if series, ok := s.indicators[channel]; ok {
+			copied := *series
+			copied.Points = series.Points[:len(series.Points):len(series.Points)]
+			snapshot.Indicators = append(snapshot.Indicators, copied)
+		}
+		if message, ok := s.latest[channel]; ok {
+			message.Version = EnvelopeVersion
+			snapshot.Latest = append(snapshot.Latest, message)
+		}
+	}
+	return Message{Type: "snapshot", Data: snapshot}
+}
+
+// PointAt reads every line's value at index i, as the point of the candle opening at `openTime`
+// - a value is null where its line is invalid, and the point is warming up when all of them are
+func PointAt(lines []financeFunctions.IndicatorLine, i int, openTime int64) Point {
+	point := Point{OpenTime: openTime, Values: make(map[string]*float64, len(lines)), WarmUp: true}
+	for _, line := range lines {
+		point.Values[line.Name] = nil
+		if i >= len(line.Values) || !line.Valid[i] || math.IsNaN(line.Values[i]) || math.IsInf(line.Values[i], 0) {
+			continue
+		}
+		value := line.Values[i]
+		point.Values[line.Name] = &value
+		point.WarmUp = false
+	}
+	return point
+}
+
+// Helper function to check whether two points hold the same values
+func samePoint(a, b Point) bool {
+	if a.WarmUp != b.WarmUp || len(a.Values) != len(b.Values) {
+		return false
+	}
+	for name, value := range a.Values {
+		other, ok := b.Values[name]
+		switch {
+		case !ok:
+			return false
+		case value == nil || other == nil:
+			if value != other {
+				return false
+			}
+		case *value != *other:
+			return false
+		}
+	}
+	return true
+}
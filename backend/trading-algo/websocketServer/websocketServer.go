@@ -1,51 +1,22 @@
 package websocketServer
 
 import (
-	"encoding/json"
-	"fmt"
 	"log"
 	"net/http"
 
-	"github.com/gorilla/websocket"
+	"github.com/neozhixuan/project-visualgo-backend/streamHub"
 )
 
-func StartWebSocketServer(ema9Channel chan []float64) {
-	// Configure WebSocket upgrade
-	upgrader := websocket.Upgrader{
-		ReadBufferSize:  1024,
-		WriteBufferSize: 1024,
-		CheckOrigin:     func(r *http.Request) bool { return true }, // Allow all origins
-	}
+func StartWebSocketServer(updateChannel chan Message, stream *Stream, access streamHub.Access) {
+	// Broadcast every update to every connected client, each starting from a snapshot of the stream
+	hub := NewHub(access, stream)
+	go Run(hub, updateChannel)
 
 	// Handle WebSocket connections
-	http.HandleFunc("/ws", func(w http.ResponseWriter, r *http.Request) {
-		// Upgrade HTTP connection to WebSocket
-		conn2, err := upgrader.Upgrade(w, r, nil)
-		if err != nil {
-			log.Printf("Error upgrading connection to WebSocket: %v", err)
-			return
-		}
-		defer conn2.Close()
+	http.Handle("/ws", hub)
 
-		// Broadcast ema9 data to this WebSocket client
-		for {
-			ema9 := <-ema9Channel
-			// Convert ema9 to JSON
-			jsonBytes, err := json.Marshal(ema9)
-			if err != nil {
-				log.Printf("Error marshaling ema9 to JSON: %v", err)
-				return
-			}
-
-			// Write the JSON data to the WebSocket connection
-			fmt.Println("Writing JSON data to websocket")
-			err = conn2.WriteMessage(websocket.TextMessage, jsonBytes)
-			if err != nil {
-				log.Printf("Error sending ema9 data to WebSocket client: %v", err)
-				return
-			}
-		}
-	})
+	// Stream the same messages as Server-Sent Events, for clients that cannot use WebSockets
+	http.HandleFunc("/sse", hub.ServeSSE)
 
 	// Start HTTP server for WebSocket connections
 	log.Println("Starting WebSocket server on port 8090")
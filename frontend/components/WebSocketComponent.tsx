@@ -14,6 +14,22 @@ interface SeriesData {
   data: KlineDataPoint[];
 }
 
+// One candle's 9-EMA, null while the EMA is warming up
+interface EmaPoint {
+  openTime: number;
+  value: number | null;
+}
+
+// The trading-algo channel the EMA panel subscribes to, and how many of its points it keeps
+const emaChannel = "ema:BNBBTC:1m:9";
+const emaPoints = 500;
+
+// Reads a snapshot point or an "indicator" message of the EMA channel
+const toEmaPoint = (point: any): EmaPoint => ({
+  openTime: Number(point.openTime),
+  value: point.values?.ema ?? null,
+});
+
 interface ScrollableBoxProps {
   children: React.ReactNode;
 }
@@ -58,7 +74,7 @@ const ScrollableBox: React.FC<ScrollableBoxProps> = ({
 const WebSocketComponent = () => {
   const [data, setData] = useState<any[]>([]);
   const [series, setSeries] = useState<SeriesData[]>([{ data: [] }]);
-  const [ema, setEma] = useState<any[]>([]);
+  const [ema, setEma] = useState<EmaPoint[]>([]);
   const tablet = useMediaQuery("(min-width:960px)");
   const wssUrl = "ws://host.docker.internal:8080/ws";
   // process.env.NEXT_PUBLIC_STAGE === "production"
@@ -75,7 +91,7 @@ const WebSocketComponent = () => {
   useEffect(() => {
     if (typeof window !== "undefined") {
       const socket = new WebSocket(wssUrl);
-      const socket2 = new WebSocket(wss2Url);
+      const socket2 = new WebSocket(`${wss2Url}?channels=${emaChannel}`);
 
       socket.onerror = (error) => {
         console.error("WebSocket error:", error);
@@ -84,30 +100,32 @@ const WebSocketComponent = () => {
       // console.log("hi");
       socket.onmessage = (event) => {
         const newData = JSON.parse(event.data);
-        if (newData.e === "trade") {
-          var date = new Date(newData.E * 1000);
+        if (newData.type === "trade") {
+          // 64-bit integers such as times arrive as strings
+          var date = new Date(Number(newData.trade.time));
 
           // Extract hours, minutes, and seconds
           const hours = String(date.getHours()).padStart(2, "0");
           const minutes = String(date.getMinutes()).padStart(2, "0");
           const seconds = String(date.getSeconds()).padStart(2, "0");
-          newData.date = hours + ":" + minutes + ":" + seconds;
-          // console.log(newData);
-          // Add to data if it's a kline event, regardless of "x" status
-          setData((prevData) => [...prevData, newData]);
-        } else if (newData.e === "kline") {
-          if (newData.k.x === true) {
+          const trade = { ...newData.trade };
+          trade.date = hours + ":" + minutes + ":" + seconds;
+          // console.log(trade);
+          setData((prevData) => [...prevData, trade]);
+        } else if (newData.type === "kline") {
+          const kline = newData.kline;
+          if (kline.isKlineClosed === true) {
             setSeries((prevSeries) => [
               {
                 data: [
                   ...prevSeries[0].data,
                   {
-                    x: new Date(newData.E), // Convert UNIX timestamp to JavaScript Date object
+                    x: new Date(Number(kline.openTime)), // Convert UNIX timestamp to JavaScript Date object
                     y: [
-                      parseFloat(newData.k.o), // open
-                      parseFloat(newData.k.h), // high
-                      parseFloat(newData.k.l), // low
-                      parseFloat(newData.k.c), // close
+                      kline.openPrice, // open
+                      kline.highPrice, // high
+                      kline.lowPrice, // low
+                      kline.closePrice, // close
                     ],
                   },
                 ],
@@ -119,13 +137,29 @@ const WebSocketComponent = () => {
 
       socket2.onmessage = (event) => {
         const newData = JSON.parse(event.data);
-        console.log("lol");
-        console.log(newData);
-        setEma(newData);
+        if (newData.type === "snapshot") {
+          // Start from the points the server kept, as a new client gets no earlier updates
+          const indicator = newData.data.indicators.find(
+            (series: any) => series.channel === emaChannel
+          );
+          setEma((indicator?.points ?? []).map(toEmaPoint));
+        } else if (
+          newData.type === "indicator" &&
+          newData.channel === emaChannel
+        ) {
+          // Points are keyed by openTime, so a changed point replaces the one sent before
+          const point = toEmaPoint(newData);
+          setEma((prevEma) =>
+            [...prevEma.filter((item) => item.openTime !== point.openTime), point]
+              .sort((a, b) => a.openTime - b.openTime)
+              .slice(-emaPoints)
+          );
+        }
       };
 
       return () => {
         socket.close();
+        socket2.close();
       };
     }
   }, []);
@@ -184,8 +218,8 @@ const WebSocketComponent = () => {
               .reverse()
               .map((item, index) => (
                 <p style={{ fontSize: 12 }} key={index}>
-                  {item.date}: {item.s} was bought for {item.p} at volume{" "}
-                  {item.q}
+                  {item.date}: {item.symbol} was bought for {item.price} at volume{" "}
+                  {item.quantity}
                 </p>
               ))
           ) : (
@@ -205,8 +239,11 @@ const WebSocketComponent = () => {
             {ema
               .slice()
               .reverse()
-              .map((item: string, idx: number) => (
-                <p key={idx}>{item}</p>
+              .map((item) => (
+                <p style={{ fontSize: 12 }} key={item.openTime}>
+                  {new Date(item.openTime).toLocaleTimeString()}:{" "}
+                  {item.value ?? "warming up"}
+                </p>
               ))}
           </div>
         ) : (